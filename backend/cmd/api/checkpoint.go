@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"integration-app/internal/app/modules"
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/infrastructure/logger"
+	"integration-app/internal/repository"
+	"integration-app/internal/usecase"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+func newCheckpointCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Manage polling checkpoints",
+	}
+
+	var connectionID int
+	var entity, since string
+
+	reset := &cobra.Command{
+		Use:   "reset",
+		Short: "Restart polling of a connection entity from a point in time",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				return fmt.Errorf("invalid --since, expected RFC 3339 time: %w", err)
+			}
+			return runCheckpointReset(connectionID, entity, t)
+		},
+	}
+	reset.Flags().IntVar(&connectionID, "connection", 0, "connection ID")
+	reset.Flags().StringVar(&entity, "entity", "", "entity (default: poll_entity of the connection)")
+	reset.Flags().StringVar(&since, "since", "", "time to poll from, e.g. 2024-01-01T00:00:00Z")
+	reset.MarkFlagRequired("connection")
+	reset.MarkFlagRequired("since")
+
+	cmd.AddCommand(reset)
+	return cmd
+}
+
+func runCheckpointReset(connectionID int, entity string, since time.Time) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var resetErr error
+	app := fx.New(
+		fx.Provide(func() *config.Config { return cfg }),
+		fx.Provide(
+			logger.NewLogger,
+			func(l *logger.Logger) domain.Logger { return l },
+		),
+		fx.Provide(modules.NewDatabase),
+		fx.Provide(
+			fx.Annotate(repository.NewConnectionRepository, fx.As(new(domain.ConnectionRepository))),
+			fx.Annotate(repository.NewSyncCheckpointRepository, fx.As(new(domain.SyncCheckpointRepository))),
+			usecase.NewSyncCheckpointUseCase,
+		),
+		fx.Invoke(func(lc fx.Lifecycle, uc *usecase.SyncCheckpointUseCase) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					checkpoint, err := uc.ResetCheckpoint(ctx, connectionID, entity, since)
+					if err != nil {
+						resetErr = err
+						return nil
+					}
+					fmt.Printf("✓ Checkpoint of connection %d, entity %s reset to %s\n",
+						checkpoint.ConnectionID, checkpoint.Entity, checkpoint.ModifiedAt.Format(time.RFC3339))
+					return nil
+				},
+			})
+		}),
+	)
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		return err
+	}
+	if err := app.Stop(ctx); err != nil {
+		log.Printf("Failed to stop app: %v", err)
+	}
+
+	return resetErr
+}
@@ -8,6 +8,7 @@ import (
 	"integration-app/internal/app/modules"
 	"integration-app/internal/config"
 	"integration-app/internal/domain"
+	"integration-app/internal/infrastructure/database"
 	"integration-app/internal/infrastructure/logger"
 
 	"github.com/spf13/cobra"
@@ -55,6 +56,18 @@ func checkHealth(lc fx.Lifecycle, logger domain.Logger, db *bun.DB, cfg *config.
 			}
 			fmt.Printf("✓ Database connection: OK\n")
 
+			leader, err := database.CurrentLeader(ctx, db)
+			if err != nil {
+				logger.Error("Leader check failed", err)
+				fmt.Printf("❌ Leader: FAILED\n")
+				return err
+			}
+			if leader == "" {
+				fmt.Printf("⚠ Leader: none\n")
+			} else {
+				fmt.Printf("✓ Leader: %s\n", leader)
+			}
+
 			// Check config
 			fmt.Printf("✓ Config loaded successfully\n")
 			fmt.Printf("✓ Database: %s:%d\n", cfg.DBHost, cfg.DBPort)
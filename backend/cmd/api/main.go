@@ -19,6 +19,7 @@ func newRootCmd() *cobra.Command {
 		newServerCmd(),
 		newMigrateCmd(),
 		newHealthCmd(),
+		newCheckpointCmd(),
 	)
 
 	return rootCmd
@@ -16,6 +16,7 @@ import (
 	"integration-app/internal/app/modules"
 	"integration-app/internal/config"
 	"integration-app/internal/domain"
+	"integration-app/internal/infrastructure/connector"
 	"integration-app/internal/infrastructure/logger"
 	"integration-app/internal/repository"
 	"integration-app/internal/usecase"
@@ -44,21 +45,55 @@ func serverRunner(cfg *config.Config) error {
 			func(l *logger.Logger) domain.Logger { return l }, // <-- Биндинг для Fx
 		),
 		fx.Provide(modules.NewDatabase),
+		fx.Provide(fx.Annotate(modules.NewCache, fx.As(new(domain.CacheService)))),
+		fx.Provide(fx.Annotate(modules.NewURLGuard, fx.As(new(domain.URLGuard)))),
 
 		fx.Invoke(database.RunMigrations),
+		fx.Provide(fx.Annotate(database.NewLeaderElector, fx.As(new(domain.LeaderElector)))),
+
+		fx.Provide(fx.Annotate(connector.NewRegistry, fx.As(new(domain.ConnectorRegistry)))),
 
 		fx.Provide(
-			repository.NewConnectionRepository,
-			repository.NewMappingRepository,
+			fx.Annotate(repository.NewConnectionRepository, fx.As(new(domain.ConnectionRepository))),
+			fx.Annotate(repository.NewMappingRepository, fx.As(new(domain.MappingRepository))),
+			fx.Annotate(repository.NewMappingVersionRepository, fx.As(new(domain.MappingVersionRepository))),
 			repository.NewWebhookRepository,
-			repository.NewSyncLogRepository,
+			fx.Annotate(repository.NewWebhookDeliveryRepository, fx.As(new(domain.WebhookDeliveryRepository))),
+			fx.Annotate(repository.NewSyncLogRepository, fx.As(new(domain.SyncLogRepository))),
+			fx.Annotate(repository.NewInboundSampleRepository, fx.As(new(domain.InboundSampleRepository))),
+			fx.Annotate(repository.NewInboundEventRepository, fx.As(new(domain.InboundEventRepository))),
+			fx.Annotate(repository.NewRecordLinkRepository, fx.As(new(domain.RecordLinkRepository))),
+			fx.Annotate(repository.NewSyncPairRepository, fx.As(new(domain.SyncPairRepository))),
+			fx.Annotate(repository.NewSyncConflictRepository, fx.As(new(domain.SyncConflictRepository))),
+			fx.Annotate(repository.NewFlowRepository, fx.As(new(domain.FlowRepository))),
+			fx.Annotate(repository.NewFlowJobRepository, fx.As(new(domain.FlowJobRepository))),
+			fx.Annotate(repository.NewScheduleRepository, fx.As(new(domain.ScheduleRepository))),
+			fx.Annotate(repository.NewSyncCheckpointRepository, fx.As(new(domain.SyncCheckpointRepository))),
+			fx.Annotate(repository.NewSyncQueueRepository, fx.As(new(domain.SyncQueueRepository))),
+			fx.Annotate(repository.NewSyncBackfillRepository, fx.As(new(domain.SyncBackfillRepository))),
+			fx.Annotate(repository.NewSyncRunRepository, fx.As(new(domain.SyncRunRepository))),
 		),
 
 		fx.Provide(
+			fx.Annotate(usecase.NewWebhookDispatcher, fx.As(fx.Self()), fx.As(new(domain.EventPublisher))),
 			usecase.NewConnectionUseCase,
+			usecase.NewSchemaUseCase,
 			usecase.NewMappingUseCase,
 			usecase.NewWebhookUseCase,
 			usecase.NewSyncUseCase,
+			usecase.NewSyncRunUseCase,
+			usecase.NewSyncEngine,
+			usecase.NewInboundUseCase,
+			usecase.NewRecordLinkUseCase,
+			usecase.NewSyncPairUseCase,
+			usecase.NewSyncConflictUseCase,
+			usecase.NewFlowExecutor,
+			usecase.NewFlowUseCase,
+			usecase.NewScheduler,
+			usecase.NewSyncQueueWorker,
+			usecase.NewSyncCheckpointUseCase,
+			usecase.NewSyncBackfillUseCase,
+			usecase.NewBackfillRunner,
 		),
 
 		fx.Provide(
@@ -66,10 +101,24 @@ func serverRunner(cfg *config.Config) error {
 			handlers.NewMappingHandler,
 			handlers.NewWebhookHandler,
 			handlers.NewHealthHandler,
+			handlers.NewInboundHandler,
+			handlers.NewRecordLinkHandler,
+			handlers.NewSyncPairHandler,
+			handlers.NewSyncConflictHandler,
+			handlers.NewFlowHandler,
+			handlers.NewSyncLogHandler,
+			handlers.NewScheduleHandler,
+			handlers.NewSyncCheckpointHandler,
+			handlers.NewSyncBackfillHandler,
+			handlers.NewSyncRunHandler,
 		),
 
 		fx.Provide(api.NewRouter),
 
+		// От воркеров очереди опроса и выгрузок никто не зависит, поэтому они создаются явно
+		fx.Invoke(func(*usecase.SyncQueueWorker) {}),
+		fx.Invoke(func(*usecase.BackfillRunner) {}),
+
 		fx.Invoke(setupServer),
 	)
 
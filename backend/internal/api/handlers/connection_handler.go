@@ -13,17 +13,20 @@ import (
 )
 
 type ConnectionHandler struct {
-	uc     *usecase.ConnectionUseCase
-	logger domain.Logger
+	uc       *usecase.ConnectionUseCase
+	schemaUC *usecase.SchemaUseCase
+	logger   domain.Logger
 }
 
 func NewConnectionHandler(
 	uc *usecase.ConnectionUseCase,
+	schemaUC *usecase.SchemaUseCase,
 	logger domain.Logger,
 ) *ConnectionHandler {
 	return &ConnectionHandler{
-		uc:     uc,
-		logger: logger,
+		uc:       uc,
+		schemaUC: schemaUC,
+		logger:   logger,
 	}
 }
 
@@ -111,3 +114,28 @@ func (h *ConnectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
 }
+
+func (h *ConnectionHandler) GetFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	entity := r.URL.Query().Get("entity")
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh"))
+
+	schema, err := h.schemaUC.GetFields(r.Context(), id, entity, refresh)
+	if err != nil {
+		h.logger.Error("API: Failed to get connection fields", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  schema,
+		"count": len(schema.Fields),
+	})
+}
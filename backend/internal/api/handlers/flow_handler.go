@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type FlowHandler struct {
+	uc     *usecase.FlowUseCase
+	logger domain.Logger
+}
+
+func NewFlowHandler(
+	uc *usecase.FlowUseCase,
+	logger domain.Logger,
+) *FlowHandler {
+	return &FlowHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+func (h *FlowHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	flows, err := h.uc.GetAllFlows(r.Context())
+	if err != nil {
+		h.logger.Error("API: Failed to get flows", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  flows,
+		"count": len(flows),
+	})
+}
+
+func (h *FlowHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	flow, err := h.uc.GetFlowByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to get flow", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": flow,
+	})
+}
+
+func (h *FlowHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var flow models.Flow
+	if err := json.NewDecoder(r.Body).Decode(&flow); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.uc.CreateFlow(r.Context(), &flow); err != nil {
+		h.logger.Error("API: Failed to create flow", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "created",
+		"data":   flow,
+	})
+}
+
+func (h *FlowHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var flow models.Flow
+	if err := json.NewDecoder(r.Body).Decode(&flow); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	flow.ID = id
+
+	if err := h.uc.UpdateFlow(r.Context(), &flow); err != nil {
+		h.logger.Error("API: Failed to update flow", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "updated",
+		"data":   flow,
+	})
+}
+
+func (h *FlowHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.uc.DeleteFlow(r.Context(), id); err != nil {
+		h.logger.Error("API: Failed to delete flow", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+}
+
+// Run - ручной запуск потока; тело запроса - данные записи-триггера
+func (h *FlowHandler) Run(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload == nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.uc.RunFlow(r.Context(), id, payload)
+	if err != nil {
+		h.logger.Error("API: Failed to run flow", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": run,
+	})
+}
+
+// GetJobs - отложенные шаги потоков; фильтры flow_id, connection_id, record_id, status
+func (h *FlowHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.FlowJobFilter{
+		SourceRecordID: query.Get("record_id"),
+		Status:         query.Get("status"),
+	}
+	for name, dst := range map[string]*int{"flow_id": &filter.FlowID, "connection_id": &filter.ConnectionID} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	jobs, err := h.uc.GetFlowJobs(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("API: Failed to get flow jobs", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  jobs,
+		"count": len(jobs),
+	})
+}
+
+func (h *FlowHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.uc.CancelFlowJob(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to cancel flow job", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "cancelled"})
+}
+
+type cancelRecordJobsRequest struct {
+	ConnectionID int    `json:"connection_id"`
+	RecordID     string `json:"record_id"`
+	FlowID       int    `json:"flow_id"`
+}
+
+// CancelRecordJobs - отмена всех ожидающих шагов по записи-триггеру
+func (h *FlowHandler) CancelRecordJobs(w http.ResponseWriter, r *http.Request) {
+	var req cancelRecordJobsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.uc.CancelRecordJobs(r.Context(), req.ConnectionID, req.RecordID, req.FlowID)
+	if err != nil {
+		h.logger.Error("API: Failed to cancel flow jobs", err, "connection_id", req.ConnectionID, "record_id", req.RecordID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "cancelled",
+		"count":  count,
+	})
+}
@@ -9,20 +9,32 @@ import (
 )
 
 type HealthHandler struct {
+	leader domain.LeaderElector
 	logger domain.Logger
 }
 
-func NewHealthHandler(logger domain.Logger) *HealthHandler {
-	return &HealthHandler{logger: logger}
+func NewHealthHandler(leader domain.LeaderElector, logger domain.Logger) *HealthHandler {
+	return &HealthHandler{
+		leader: leader,
+		logger: logger,
+	}
 }
 
 func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("API: Health check")
 
+	// Ошибка запроса лидера не делает экземпляр нездоровым, роль видна и без нее
+	leader, err := h.leader.Status(r.Context())
+	if err != nil {
+		h.logger.Error("API: Failed to get leader status", err)
+		leader = &domain.LeaderStatus{IsLeader: h.leader.IsLeader()}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":    "ok",
 		"timestamp": time.Now(),
 		"version":   "0.0.1",
+		"leader":    leader,
 	})
 }
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+const inboundMaxBodySize = 1 << 20
+
+type InboundHandler struct {
+	uc     *usecase.InboundUseCase
+	logger domain.Logger
+}
+
+func NewInboundHandler(
+	uc *usecase.InboundUseCase,
+	logger domain.Logger,
+) *InboundHandler {
+	return &InboundHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+// Receive - входящий вебхук. Принимает JSON (объект или массив объектов),
+// form-urlencoded, multipart или параметры запроса. Для массива возвращает
+// результат каждой записи и 207, если часть записей не обработана.
+func (h *InboundHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+
+	records, contentType, err := parseInboundPayload(w, r)
+	if err != nil {
+		h.logger.Warn("API: Invalid inbound payload", "error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	headerKey := r.Header.Get("Idempotency-Key")
+	if headerKey == "" {
+		headerKey = r.Header.Get("X-Idempotency-Key")
+	}
+
+	if len(records) == 1 {
+		results, err := h.uc.Receive(r.Context(), token, contentType, headerKey, records[0])
+		if err != nil {
+			h.receiveError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "accepted",
+			"data":   results,
+		})
+		return
+	}
+
+	// Записи массива обрабатываются все: ошибка одной не мешает остальным,
+	// а ее ключ идемпотентности освобождается для повторной доставки
+	results, err := h.uc.ReceiveBatch(r.Context(), token, contentType, headerKey, records)
+	if err != nil {
+		h.receiveError(w, err)
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	status := "accepted"
+	w.Header().Set("Content-Type", "application/json")
+	if failed > 0 {
+		status = "partial"
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"data":   results,
+		"count":  len(results),
+		"failed": failed,
+	})
+}
+
+func (h *InboundHandler) receiveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrInactive):
+		http.Error(w, "Connection is inactive", http.StatusGone)
+	default:
+		h.logger.Error("API: Failed to receive inbound webhook", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetEndpoint - входящий URL подключения и последние принятые данные
+func (h *InboundHandler) GetEndpoint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := h.uc.GetEndpoint(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to get inbound endpoint", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": endpoint,
+	})
+}
+
+// parseInboundPayload - записи из тела запроса, дополненные параметрами URL
+func parseInboundPayload(w http.ResponseWriter, r *http.Request) ([]map[string]interface{}, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, inboundMaxBodySize)
+
+	query := valuesToMap(r.URL.Query())
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var records []map[string]interface{}
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return nil, mediaType, err
+		}
+		records = append(records, valuesToMap(r.PostForm))
+
+	case mediaType == "multipart/form-data":
+		if err := r.ParseMultipartForm(inboundMaxBodySize); err != nil {
+			return nil, mediaType, err
+		}
+		records = append(records, valuesToMap(url.Values(r.MultipartForm.Value)))
+
+	default:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, mediaType, err
+		}
+
+		trimmed := strings.TrimSpace(string(body))
+		switch {
+		case trimmed == "":
+			records = append(records, map[string]interface{}{})
+		case strings.HasPrefix(trimmed, "["):
+			if err := json.Unmarshal(body, &records); err != nil {
+				return nil, mediaType, domain.NewError("body must be a JSON object or an array of objects")
+			}
+		default:
+			var record map[string]interface{}
+			if err := json.Unmarshal(body, &record); err != nil {
+				return nil, mediaType, domain.NewError("body must be a JSON object or an array of objects")
+			}
+			records = append(records, record)
+		}
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, mediaType, domain.NewError("no records in request")
+	}
+
+	// Параметры URL дополняют, но не перезаписывают поля тела
+	for _, record := range records {
+		if record == nil {
+			return nil, mediaType, domain.NewError("body must be a JSON object or an array of objects")
+		}
+		for key, value := range query {
+			if _, ok := record[key]; !ok {
+				record[key] = value
+			}
+		}
+	}
+
+	if len(query) > 0 && r.ContentLength == 0 {
+		mediaType = "query"
+	}
+
+	return records, mediaType, nil
+}
+
+// valuesToMap - одиночные значения строкой, повторяющиеся - списком
+func valuesToMap(values url.Values) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for key, list := range values {
+		if len(list) == 1 {
+			result[key] = list[0]
+			continue
+		}
+		items := make([]interface{}, 0, len(list))
+		for _, v := range list {
+			items = append(items, v)
+		}
+		result[key] = items
+	}
+	return result
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"integration-app/internal/domain"
 	"integration-app/internal/domain/models"
 	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
 )
 
 type MappingHandler struct {
@@ -60,3 +63,283 @@ func (h *MappingHandler) Save(w http.ResponseWriter, r *http.Request) {
 		"count":  len(mappings),
 	})
 }
+
+func (h *MappingHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req usecase.PreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.uc.PreviewMappings(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("API: Failed to preview mappings", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": preview,
+	})
+}
+
+func (h *MappingHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	var req usecase.SuggestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	suggestions, err := h.uc.SuggestMappings(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("API: Failed to suggest mappings", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  suggestions,
+		"count": len(suggestions.Suggestions),
+	})
+}
+
+func (h *MappingHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := h.uc.ListVersions(r.Context(), sourceID, targetID)
+	if err != nil {
+		h.logger.Error("API: Failed to get mapping versions", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  versions,
+		"count": len(versions),
+	})
+}
+
+func (h *MappingHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	v, err := h.uc.GetVersion(r.Context(), sourceID, targetID, version)
+	if err != nil {
+		h.logger.Error("API: Failed to get mapping version", err, "version", version)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": v,
+	})
+}
+
+func (h *MappingHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "Query parameters from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.uc.DiffVersions(r.Context(), sourceID, targetID, from, to)
+	if err != nil {
+		h.logger.Error("API: Failed to diff mapping versions", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"data": diff,
+	})
+}
+
+func (h *MappingHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	v, err := h.uc.RollbackToVersion(r.Context(), sourceID, targetID, version)
+	if err != nil {
+		h.logger.Error("API: Failed to roll back mappings", err, "version", version)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "rolled_back",
+		"data":   v,
+	})
+}
+
+func (h *MappingHandler) GetByPair(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	mappings, err := h.uc.GetMappingsByPair(r.Context(), sourceID, targetID)
+	if err != nil {
+		h.logger.Error("API: Failed to get pair mappings", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  mappings,
+		"count": len(mappings),
+	})
+}
+
+func (h *MappingHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	var mappings []models.FieldMapping
+	if err := json.NewDecoder(r.Body).Decode(&mappings); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	version, err := h.uc.ReplaceMappings(r.Context(), sourceID, targetID, mappings)
+	if err != nil {
+		h.logger.Error("API: Failed to replace mappings", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "replaced",
+		"count":   len(mappings),
+		"version": version.Version,
+	})
+}
+
+func (h *MappingHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var patch usecase.MappingPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	mapping, err := h.uc.UpdateMapping(r.Context(), sourceID, targetID, id, &patch)
+	if err != nil {
+		h.logger.Error("API: Failed to update mapping", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "updated",
+		"data":   mapping,
+	})
+}
+
+func (h *MappingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.uc.DeletePairMapping(r.Context(), sourceID, targetID, id); err != nil {
+		h.logger.Error("API: Failed to delete mapping", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+}
+
+func (h *MappingHandler) DeleteByPair(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.uc.DeleteMappingsByPair(r.Context(), sourceID, targetID); err != nil {
+		h.logger.Error("API: Failed to delete pair mappings", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+}
+
+// connectionPair - разбирает {src} и {dst} из пути /connections/{src}/targets/{dst}/...
+func connectionPair(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+
+	sourceID, err := strconv.Atoi(vars["src"])
+	if err != nil {
+		http.Error(w, "Invalid source connection ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	targetID, err := strconv.Atoi(vars["dst"])
+	if err != nil {
+		http.Error(w, "Invalid target connection ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return sourceID, targetID, true
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type RecordLinkHandler struct {
+	uc     *usecase.RecordLinkUseCase
+	logger domain.Logger
+}
+
+func NewRecordLinkHandler(
+	uc *usecase.RecordLinkUseCase,
+	logger domain.Logger,
+) *RecordLinkHandler {
+	return &RecordLinkHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+// GetByRecord - связи записи: ?connection_id=1&record_id=123
+func (h *RecordLinkHandler) GetByRecord(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.Atoi(r.URL.Query().Get("connection_id"))
+	if err != nil {
+		http.Error(w, "Invalid connection_id", http.StatusBadRequest)
+		return
+	}
+
+	links, err := h.uc.GetLinks(r.Context(), connectionID, r.URL.Query().Get("record_id"))
+	if err != nil {
+		h.logger.Error("API: Failed to get record links", err, "connection_id", connectionID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  links,
+		"count": len(links),
+	})
+}
+
+func (h *RecordLinkHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.uc.GetLinkByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to get record link", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": link,
+	})
+}
+
+func (h *RecordLinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var link models.RecordLink
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.uc.CreateLink(r.Context(), &link); err != nil {
+		h.logger.Error("API: Failed to create record link", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "created",
+		"data":   link,
+	})
+}
+
+// Repair - исправить запись цели, на которую указывает связь
+func (h *RecordLinkHandler) Repair(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var patch usecase.RecordLinkPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.uc.RepairLink(r.Context(), id, &patch)
+	if err != nil {
+		h.logger.Error("API: Failed to repair record link", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "updated",
+		"data":   link,
+	})
+}
+
+func (h *RecordLinkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.uc.DeleteLink(r.Context(), id); err != nil {
+		h.logger.Error("API: Failed to delete record link", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+}
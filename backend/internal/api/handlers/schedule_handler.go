@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+)
+
+type ScheduleHandler struct {
+	scheduler *usecase.Scheduler
+	logger    domain.Logger
+}
+
+func NewScheduleHandler(
+	scheduler *usecase.Scheduler,
+	logger domain.Logger,
+) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// GetAll - задания планировщика с последним и следующим запуском
+func (h *ScheduleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.scheduler.GetSchedules(r.Context())
+	if err != nil {
+		h.logger.Error("API: Failed to get schedules", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  schedules,
+		"count": len(schedules),
+	})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type SyncBackfillHandler struct {
+	uc     *usecase.SyncBackfillUseCase
+	logger domain.Logger
+}
+
+func NewSyncBackfillHandler(
+	uc *usecase.SyncBackfillUseCase,
+	logger domain.Logger,
+) *SyncBackfillHandler {
+	return &SyncBackfillHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+func (h *SyncBackfillHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	backfills, err := h.uc.GetBackfills(r.Context())
+	if err != nil {
+		h.logger.Error("API: Failed to get sync backfills", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  backfills,
+		"count": len(backfills),
+	})
+}
+
+// Create - выгрузка записей источника за период from..to (RFC 3339) в цель пары
+func (h *SyncBackfillHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req usecase.BackfillParams
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	backfill, err := h.uc.CreateBackfill(r.Context(), req)
+	if err != nil {
+		h.logger.Error("API: Failed to create sync backfill", err, "source_id", req.SourceConnectionID, "target_id", req.TargetConnectionID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "created",
+		"data":   backfill,
+	})
+}
+
+func (h *SyncBackfillHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	backfill, err := h.uc.GetBackfill(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to get sync backfill", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": backfill})
+}
+
+// GetLogs - журнал синхронизации записей выгрузки
+func (h *SyncBackfillHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.uc.GetBackfillLogs(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to get sync backfill logs", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  logs,
+		"count": len(logs),
+	})
+}
+
+func (h *SyncBackfillHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.setStatus(w, r, "paused", h.uc.PauseBackfill)
+}
+
+func (h *SyncBackfillHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.setStatus(w, r, "resumed", h.uc.ResumeBackfill)
+}
+
+func (h *SyncBackfillHandler) setStatus(w http.ResponseWriter, r *http.Request, status string, op func(ctx context.Context, id int) (*usecase.BackfillProgress, error)) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	backfill, err := op(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to change sync backfill status", err, "id", id, "status", status)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"data":   backfill,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+)
+
+type SyncCheckpointHandler struct {
+	uc     *usecase.SyncCheckpointUseCase
+	logger domain.Logger
+}
+
+func NewSyncCheckpointHandler(
+	uc *usecase.SyncCheckpointUseCase,
+	logger domain.Logger,
+) *SyncCheckpointHandler {
+	return &SyncCheckpointHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+func (h *SyncCheckpointHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	checkpoints, err := h.uc.GetCheckpoints(r.Context())
+	if err != nil {
+		h.logger.Error("API: Failed to get sync checkpoints", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  checkpoints,
+		"count": len(checkpoints),
+	})
+}
+
+type resetCheckpointRequest struct {
+	ConnectionID int       `json:"connection_id"`
+	Entity       string    `json:"entity"`
+	Since        time.Time `json:"since"`
+}
+
+// Reset - сдвинуть позицию опроса на момент since (RFC 3339)
+func (h *SyncCheckpointHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var req resetCheckpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	checkpoint, err := h.uc.ResetCheckpoint(r.Context(), req.ConnectionID, req.Entity, req.Since)
+	if err != nil {
+		h.logger.Error("API: Failed to reset sync checkpoint", err, "connection_id", req.ConnectionID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "reset",
+		"data":   checkpoint,
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type SyncConflictHandler struct {
+	uc     *usecase.SyncConflictUseCase
+	logger domain.Logger
+}
+
+func NewSyncConflictHandler(
+	uc *usecase.SyncConflictUseCase,
+	logger domain.Logger,
+) *SyncConflictHandler {
+	return &SyncConflictHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+// GetAll - конфликты, ?status=open для очереди ручного разбора
+func (h *SyncConflictHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	conflicts, err := h.uc.GetConflicts(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		h.logger.Error("API: Failed to get sync conflicts", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  conflicts,
+		"count": len(conflicts),
+	})
+}
+
+func (h *SyncConflictHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	conflict, err := h.uc.GetConflictByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to get sync conflict", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": conflict,
+	})
+}
+
+// Resolve - {"winner": "source"} или {"winner": "target"}
+func (h *SyncConflictHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Winner string `json:"winner"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	conflict, err := h.uc.ResolveConflict(r.Context(), id, req.Winner)
+	if err != nil {
+		h.logger.Error("API: Failed to resolve sync conflict", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "resolved",
+		"data":   conflict,
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type SyncLogHandler struct {
+	uc     *usecase.SyncUseCase
+	logger domain.Logger
+}
+
+func NewSyncLogHandler(
+	uc *usecase.SyncUseCase,
+	logger domain.Logger,
+) *SyncLogHandler {
+	return &SyncLogHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+// GetTrace - доставки и шаги потоков одного входящего события
+func (h *SyncLogHandler) GetTrace(w http.ResponseWriter, r *http.Request) {
+	correlationID := mux.Vars(r)["correlation_id"]
+
+	logs, err := h.uc.GetTrace(r.Context(), correlationID)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to get sync trace", err, "correlation_id", correlationID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  logs,
+		"count": len(logs),
+	})
+}
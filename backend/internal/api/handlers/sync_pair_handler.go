@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/usecase"
+)
+
+type SyncPairHandler struct {
+	uc     *usecase.SyncPairUseCase
+	logger domain.Logger
+}
+
+func NewSyncPairHandler(
+	uc *usecase.SyncPairUseCase,
+	logger domain.Logger,
+) *SyncPairHandler {
+	return &SyncPairHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+func (h *SyncPairHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	pair, err := h.uc.GetPair(r.Context(), sourceID, targetID)
+	if err != nil {
+		h.logger.Error("API: Failed to get sync pair", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": pair,
+	})
+}
+
+func (h *SyncPairHandler) Save(w http.ResponseWriter, r *http.Request) {
+	sourceID, targetID, ok := connectionPair(w, r)
+	if !ok {
+		return
+	}
+
+	var settings usecase.SyncPairSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pair, err := h.uc.SavePair(r.Context(), sourceID, targetID, &settings)
+	if err != nil {
+		h.logger.Error("API: Failed to save sync pair", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "updated",
+		"data":   pair,
+	})
+}
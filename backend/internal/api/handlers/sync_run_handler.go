@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type SyncRunHandler struct {
+	uc     *usecase.SyncRunUseCase
+	logger domain.Logger
+}
+
+func NewSyncRunHandler(
+	uc *usecase.SyncRunUseCase,
+	logger domain.Logger,
+) *SyncRunHandler {
+	return &SyncRunHandler{
+		uc:     uc,
+		logger: logger,
+	}
+}
+
+// GetAll - запуски синхронизации; фильтры trigger_type, status, connection_id
+func (h *SyncRunHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.SyncRunFilter{
+		TriggerType: query.Get("trigger_type"),
+		Status:      query.Get("status"),
+	}
+	if value := query.Get("connection_id"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid connection_id", http.StatusBadRequest)
+			return
+		}
+		filter.ConnectionID = n
+	}
+
+	runs, err := h.uc.GetRuns(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("API: Failed to get sync runs", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  runs,
+		"count": len(runs),
+	})
+}
+
+func (h *SyncRunHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.uc.GetRun(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to get sync run", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": run})
+}
+
+// GetLogs - записи журнала запуска
+func (h *SyncRunHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.uc.GetRunLogs(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to get sync run logs", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  logs,
+		"count": len(logs),
+	})
+}
+
+func (h *SyncRunHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.uc.CancelRun(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("API: Failed to cancel sync run", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "cancelled",
+		"data":   run,
+	})
+}
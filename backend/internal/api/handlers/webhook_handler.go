@@ -57,6 +57,17 @@ func (h *WebhookHandler) GetActive(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetEventTypes - типы событий с JSON Schema тела вебхука
+func (h *WebhookHandler) GetEventTypes(w http.ResponseWriter, r *http.Request) {
+	eventTypes := h.uc.GetEventTypes()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  eventTypes,
+		"count": len(eventTypes),
+	})
+}
+
 func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var webhook models.Webhook
 	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
@@ -79,6 +90,164 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *WebhookHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.uc.GetWebhookByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to get webhook", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": webhook,
+	})
+}
+
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	webhook.ID = id
+
+	if err := h.uc.UpdateWebhook(r.Context(), &webhook); err != nil {
+		h.logger.Error("API: Failed to update webhook", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "updated",
+		"data":   webhook,
+	})
+}
+
+func (h *WebhookHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var patch usecase.WebhookPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		h.logger.Warn("API: Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.uc.PatchWebhook(r.Context(), id, &patch)
+	if err != nil {
+		h.logger.Error("API: Failed to update webhook", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "updated",
+		"data":   webhook,
+	})
+}
+
+func (h *WebhookHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+func (h *WebhookHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+func (h *WebhookHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.uc.SetWebhookActive(r.Context(), id, active)
+	if err != nil {
+		h.logger.Error("API: Failed to switch webhook", err, "id", id, "active", active)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := "disabled"
+	if active {
+		status = "enabled"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"data":   webhook,
+	})
+}
+
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.uc.RotateSecret(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to rotate webhook secret", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "rotated",
+		"data":   webhook,
+	})
+}
+
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.uc.TestWebhook(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to test webhook", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": delivery.Status,
+		"data":   delivery,
+	})
+}
+
 func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -96,3 +265,53 @@ func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
 }
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.uc.GetDeliveries(r.Context(), id)
+	if err != nil {
+		h.logger.Error("API: Failed to get webhook deliveries", err, "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  deliveries,
+		"count": len(deliveries),
+	})
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(vars["delivery_id"])
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.uc.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		h.logger.Error("API: Failed to redeliver webhook", err, "id", id, "delivery_id", deliveryID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": delivery.Status,
+		"data":   delivery,
+	})
+}
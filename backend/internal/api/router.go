@@ -11,16 +11,30 @@ func NewRouter(
 	mapHandler *handlers.MappingHandler,
 	webHandler *handlers.WebhookHandler,
 	healthHandler *handlers.HealthHandler,
+	inboundHandler *handlers.InboundHandler,
+	linkHandler *handlers.RecordLinkHandler,
+	pairHandler *handlers.SyncPairHandler,
+	conflictHandler *handlers.SyncConflictHandler,
+	flowHandler *handlers.FlowHandler,
+	syncLogHandler *handlers.SyncLogHandler,
+	scheduleHandler *handlers.ScheduleHandler,
+	checkpointHandler *handlers.SyncCheckpointHandler,
+	backfillHandler *handlers.SyncBackfillHandler,
+	runHandler *handlers.SyncRunHandler,
 ) *mux.Router {
 	router := mux.NewRouter()
 
 	// Middleware
 	router.Use(middleware.CORS)
 	router.Use(middleware.Logger)
+	router.Use(middleware.User)
 
 	// Health check (без auth)
 	router.HandleFunc("/health", healthHandler.Check).Methods("GET")
 
+	// Входящие вебхуки (доступ по секретному токену в URL)
+	router.HandleFunc("/hooks/{token:[0-9a-f]+}", inboundHandler.Receive).Methods("GET", "POST")
+
 	// API routes
 	api := router.PathPrefix("/api").Subrouter()
 
@@ -29,16 +43,91 @@ func NewRouter(
 	api.HandleFunc("/connections", connHandler.Create).Methods("POST")
 	api.HandleFunc("/connections/{id}", connHandler.Update).Methods("PUT")
 	api.HandleFunc("/connections/{id}", connHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/connections/{id}/fields", connHandler.GetFields).Methods("GET")
+	api.HandleFunc("/connections/{id}/inbound", inboundHandler.GetEndpoint).Methods("GET")
 
 	// Mappings
 	api.HandleFunc("/mappings", mapHandler.GetAll).Methods("GET")
 	api.HandleFunc("/mappings", mapHandler.Save).Methods("POST")
+	api.HandleFunc("/mappings/preview", mapHandler.Preview).Methods("POST")
+	api.HandleFunc("/mappings/suggest", mapHandler.Suggest).Methods("POST")
+
+	// Mappings of a connection pair
+	pair := api.PathPrefix("/connections/{src:[0-9]+}/targets/{dst:[0-9]+}").Subrouter()
+	pair.HandleFunc("/mappings", mapHandler.GetByPair).Methods("GET")
+	pair.HandleFunc("/mappings", mapHandler.Replace).Methods("PUT")
+	pair.HandleFunc("/mappings", mapHandler.DeleteByPair).Methods("DELETE")
+	pair.HandleFunc("/mappings/{id:[0-9]+}", mapHandler.Patch).Methods("PATCH")
+	pair.HandleFunc("/mappings/{id:[0-9]+}", mapHandler.Delete).Methods("DELETE")
+	pair.HandleFunc("/mapping-versions", mapHandler.ListVersions).Methods("GET")
+	pair.HandleFunc("/mapping-versions/diff", mapHandler.DiffVersions).Methods("GET")
+	pair.HandleFunc("/mapping-versions/{version:[0-9]+}", mapHandler.GetVersion).Methods("GET")
+	pair.HandleFunc("/mapping-versions/{version:[0-9]+}/rollback", mapHandler.Rollback).Methods("POST")
+	pair.HandleFunc("/settings", pairHandler.Get).Methods("GET")
+	pair.HandleFunc("/settings", pairHandler.Save).Methods("PUT")
 
 	// Webhooks
 	api.HandleFunc("/webhooks", webHandler.GetAll).Methods("GET")
 	api.HandleFunc("/webhooks/active", webHandler.GetActive).Methods("GET")
+	api.HandleFunc("/webhooks/event-types", webHandler.GetEventTypes).Methods("GET")
 	api.HandleFunc("/webhooks", webHandler.Create).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", webHandler.GetByID).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", webHandler.Update).Methods("PUT")
+	api.HandleFunc("/webhooks/{id}", webHandler.Patch).Methods("PATCH")
 	api.HandleFunc("/webhooks/{id}", webHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/enable", webHandler.Enable).Methods("POST")
+	api.HandleFunc("/webhooks/{id}/disable", webHandler.Disable).Methods("POST")
+	api.HandleFunc("/webhooks/{id}/rotate-secret", webHandler.RotateSecret).Methods("POST")
+	api.HandleFunc("/webhooks/{id}/test", webHandler.Test).Methods("POST")
+	api.HandleFunc("/webhooks/{id}/deliveries", webHandler.GetDeliveries).Methods("GET")
+	api.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/redeliver", webHandler.Redeliver).Methods("POST")
+
+	// Record links (связи записей источника и цели)
+	api.HandleFunc("/record-links", linkHandler.GetByRecord).Methods("GET")
+	api.HandleFunc("/record-links", linkHandler.Create).Methods("POST")
+	api.HandleFunc("/record-links/{id:[0-9]+}", linkHandler.GetByID).Methods("GET")
+	api.HandleFunc("/record-links/{id:[0-9]+}", linkHandler.Repair).Methods("PATCH")
+	api.HandleFunc("/record-links/{id:[0-9]+}", linkHandler.Delete).Methods("DELETE")
+
+	// Конфликты двусторонней синхронизации
+	api.HandleFunc("/sync/conflicts", conflictHandler.GetAll).Methods("GET")
+	api.HandleFunc("/sync/conflicts/{id:[0-9]+}", conflictHandler.GetByID).Methods("GET")
+	api.HandleFunc("/sync/conflicts/{id:[0-9]+}/resolve", conflictHandler.Resolve).Methods("POST")
+
+	// Журнал одного входящего события по correlation_id
+	api.HandleFunc("/sync/traces/{correlation_id}", syncLogHandler.GetTrace).Methods("GET")
+
+	// Запуски синхронизации и их журнал
+	api.HandleFunc("/sync/runs", runHandler.GetAll).Methods("GET")
+	api.HandleFunc("/sync/runs/{id:[0-9]+}", runHandler.GetByID).Methods("GET")
+	api.HandleFunc("/sync/runs/{id:[0-9]+}/logs", runHandler.GetLogs).Methods("GET")
+	api.HandleFunc("/sync/runs/{id:[0-9]+}/cancel", runHandler.Cancel).Methods("POST")
+
+	// Позиции опроса подключений без вебхуков
+	api.HandleFunc("/sync/checkpoints", checkpointHandler.GetAll).Methods("GET")
+	api.HandleFunc("/sync/checkpoints/reset", checkpointHandler.Reset).Methods("POST")
+
+	// Выгрузки исторических записей источника в цель пары
+	api.HandleFunc("/sync/backfills", backfillHandler.GetAll).Methods("GET")
+	api.HandleFunc("/sync/backfills", backfillHandler.Create).Methods("POST")
+	api.HandleFunc("/sync/backfills/{id:[0-9]+}", backfillHandler.GetByID).Methods("GET")
+	api.HandleFunc("/sync/backfills/{id:[0-9]+}/logs", backfillHandler.GetLogs).Methods("GET")
+	api.HandleFunc("/sync/backfills/{id:[0-9]+}/pause", backfillHandler.Pause).Methods("POST")
+	api.HandleFunc("/sync/backfills/{id:[0-9]+}/resume", backfillHandler.Resume).Methods("POST")
+
+	// Flows (многошаговые автоматизации)
+	api.HandleFunc("/flows", flowHandler.GetAll).Methods("GET")
+	api.HandleFunc("/flows", flowHandler.Create).Methods("POST")
+	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.GetByID).Methods("GET")
+	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.Update).Methods("PUT")
+	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/flows/{id:[0-9]+}/run", flowHandler.Run).Methods("POST")
+	api.HandleFunc("/flow-jobs", flowHandler.GetJobs).Methods("GET")
+	api.HandleFunc("/flow-jobs/cancel", flowHandler.CancelRecordJobs).Methods("POST")
+	api.HandleFunc("/flow-jobs/{id:[0-9]+}/cancel", flowHandler.CancelJob).Methods("POST")
+
+	// Фоновые задания планировщика
+	api.HandleFunc("/schedules", scheduleHandler.GetAll).Methods("GET")
 
 	return router
 }
@@ -0,0 +1,10 @@
+package modules
+
+import (
+	"integration-app/internal/config"
+	"integration-app/internal/infrastructure/netguard"
+)
+
+func NewURLGuard(cfg *config.Config) *netguard.Guard {
+	return netguard.NewGuard(cfg.OutboundAllowlist, cfg.OutboundAllowedPorts)
+}
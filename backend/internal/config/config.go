@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -15,14 +18,45 @@ type Config struct {
 	DBName     string `env:"POSTGRES_DB"`
 
 	// Cache
-	CacheSize int `env:"CACHE_SIZE"`
+	CacheSize int `env:"CACHE_SIZE"` // в байтах; запись больше CacheSize/1024 не кэшируется
 
 	// HTTP
-	HttpPort string `env:"HTTP_PORT"`
+	HttpPort      string `env:"HTTP_PORT"`
+	PublicBaseURL string `env:"PUBLIC_BASE_URL"` // внешний адрес API для входящих вебхуков
+
+	// Outbound webhooks
+	WebhookTimeoutSec   int `env:"WEBHOOK_TIMEOUT_SEC"`
+	WebhookMaxAttempts  int `env:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookDisableAfter int `env:"WEBHOOK_DISABLE_AFTER"`      // отключать после N неудач подряд
+	WebhookSecretGrace  int `env:"WEBHOOK_SECRET_GRACE_HOURS"` // сколько часов старый секрет действует после ротации
+
+	// Inbound events
+	InboundDedupeWindow int `env:"INBOUND_DEDUPE_WINDOW_HOURS"` // повтор события с тем же ключом в течение окна игнорируется
+
+	// Sync
+	SyncMaxAttempts int `env:"SYNC_MAX_ATTEMPTS"` // попыток записи в цель при временных сбоях
+
+	// Scheduler: cron-выражения фоновых заданий, "off" - задание выключено
+	LogRetentionDays     int    `env:"LOG_RETENTION_DAYS"`
+	LogRetentionSchedule string `env:"LOG_RETENTION_SCHEDULE"`
+	TokenRefreshSchedule string `env:"TOKEN_REFRESH_SCHEDULE"`
+	SyncReportSchedule   string `env:"SYNC_REPORT_SCHEDULE"`
+
+	// Outbound requests (SSRF protection)
+	OutboundAllowlist    []string `env:"OUTBOUND_ALLOWLIST"`     // хосты, IP и CIDR внутренних систем, через запятую
+	OutboundAllowedPorts []int    `env:"OUTBOUND_ALLOWED_PORTS"` // через запятую
+
 	// Environment
 	AppEnv string `env:"APP_ENV"`
 }
 
+const (
+	defaultCacheSize = 64 << 20
+	// minCacheSize - при меньшем размере freecache не примет запись больше
+	// 16 КБ, а схемы полей Bitrix24 крупнее
+	minCacheSize = 16 << 20
+)
+
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
@@ -55,7 +89,92 @@ func LoadConfig() (*Config, error) {
 
 		HttpPort: viper.GetString("HTTP_PORT"),
 		AppEnv:   viper.GetString("APP_ENV"),
+
+		WebhookTimeoutSec:   viper.GetInt("WEBHOOK_TIMEOUT_SEC"),
+		WebhookMaxAttempts:  viper.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+		WebhookDisableAfter: viper.GetInt("WEBHOOK_DISABLE_AFTER"),
+		WebhookSecretGrace:  viper.GetInt("WEBHOOK_SECRET_GRACE_HOURS"),
+	}
+
+	if config.CacheSize <= 0 {
+		config.CacheSize = defaultCacheSize
+	}
+	// Старые конфигурации задавали CACHE_SIZE числом записей (1000):
+	// такой размер поднимается до минимума, а не останавливает запуск
+	if config.CacheSize < minCacheSize {
+		log.Printf("Warning: CACHE_SIZE=%d bytes is too small, using %d", config.CacheSize, minCacheSize)
+		config.CacheSize = minCacheSize
+	}
+
+	if config.WebhookTimeoutSec <= 0 {
+		config.WebhookTimeoutSec = 10
+	}
+	if config.WebhookMaxAttempts <= 0 {
+		config.WebhookMaxAttempts = 5
+	}
+	if config.WebhookDisableAfter <= 0 {
+		config.WebhookDisableAfter = 20
+	}
+	if config.WebhookSecretGrace <= 0 {
+		config.WebhookSecretGrace = 24
+	}
+
+	config.InboundDedupeWindow = viper.GetInt("INBOUND_DEDUPE_WINDOW_HOURS")
+	if config.InboundDedupeWindow <= 0 {
+		config.InboundDedupeWindow = 72
+	}
+
+	config.SyncMaxAttempts = viper.GetInt("SYNC_MAX_ATTEMPTS")
+	if config.SyncMaxAttempts <= 0 {
+		config.SyncMaxAttempts = 3
+	}
+
+	config.LogRetentionDays = viper.GetInt("LOG_RETENTION_DAYS")
+	if config.LogRetentionDays <= 0 {
+		config.LogRetentionDays = 30
+	}
+	config.LogRetentionSchedule = viper.GetString("LOG_RETENTION_SCHEDULE")
+	if config.LogRetentionSchedule == "" {
+		config.LogRetentionSchedule = "0 3 * * *"
+	}
+	config.TokenRefreshSchedule = viper.GetString("TOKEN_REFRESH_SCHEDULE")
+	if config.TokenRefreshSchedule == "" {
+		config.TokenRefreshSchedule = "*/10 * * * *"
+	}
+	config.SyncReportSchedule = viper.GetString("SYNC_REPORT_SCHEDULE")
+	if config.SyncReportSchedule == "" {
+		config.SyncReportSchedule = "0 8 * * *"
+	}
+
+	config.PublicBaseURL = viper.GetString("PUBLIC_BASE_URL")
+	if config.PublicBaseURL == "" {
+		config.PublicBaseURL = "http://localhost:" + config.HttpPort
+	}
+
+	config.OutboundAllowlist = splitList(viper.GetString("OUTBOUND_ALLOWLIST"))
+
+	ports := splitList(viper.GetString("OUTBOUND_ALLOWED_PORTS"))
+	if len(ports) == 0 {
+		ports = []string{"80", "443", "8080", "8443"}
+	}
+	for _, p := range ports {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q in OUTBOUND_ALLOWED_PORTS", p)
+		}
+		config.OutboundAllowedPorts = append(config.OutboundAllowedPorts, port)
 	}
 
 	return config, nil
 }
+
+// splitList - "a, b,,c" -> [a b c]
+func splitList(s string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "context"
+
+const SystemUser = "system"
+
+// WithUser - сохраняет в контексте имя пользователя, выполняющего запрос
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, CtxAuthedUser{}, user)
+}
+
+// UserFromContext - имя пользователя из контекста, для фоновых задач - "system"
+func UserFromContext(ctx context.Context) string {
+	if user, ok := ctx.Value(CtxAuthedUser{}).(string); ok && user != "" {
+		return user
+	}
+	return SystemUser
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // CustomError — пользовательская ошибка
 type CustomError struct {
@@ -21,6 +24,31 @@ func (e *CustomError) Error() string {
 	return e.message
 }
 
+// TemporaryError - временный сбой внешней системы (сеть, 5xx, лимит
+// запросов): операцию можно повторить позже
+type TemporaryError struct {
+	err error
+}
+
+// NewTemporaryError помечает ошибку как временную
+func NewTemporaryError(err error) error {
+	return &TemporaryError{err: err}
+}
+
+func (e *TemporaryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TemporaryError) Unwrap() error {
+	return e.err
+}
+
+// IsTemporary - можно ли повторить операцию, завершившуюся ошибкой
+func IsTemporary(err error) bool {
+	var temporary *TemporaryError
+	return errors.As(err, &temporary)
+}
+
 // Predefined errors
 var (
 	ErrNotFound       = NewError("not found")
@@ -28,4 +56,6 @@ var (
 	ErrAlreadyExists  = NewError("already exists")
 	ErrUnauthorized   = NewError("unauthorized")
 	ErrInternalServer = NewError("internal server error")
+	ErrCacheTooLarge  = NewError("cache entry is too large")
+	ErrInactive       = NewError("inactive")
 )
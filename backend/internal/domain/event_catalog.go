@@ -0,0 +1,234 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EventTypeInfo - описание типа события и JSON Schema тела вебхука
+type EventTypeInfo struct {
+	Type        string                 `json:"type"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+	Example     map[string]interface{} `json:"example"` // пример поля data, отправляется тестовым вызовом
+}
+
+// EventCatalog - все типы событий, на которые можно подписать вебхук
+var EventCatalog = []EventTypeInfo{
+	{
+		Type:        EventSyncSucceeded,
+		Description: "Record was synced from source to target connection",
+		Schema:      eventSchema(syncEventData, "sync_log_id", "source_connection_id", "target_connection_id", "status"),
+		Example: map[string]interface{}{
+			"sync_log_id":          1,
+			"source_connection_id": 1,
+			"target_connection_id": 2,
+			"event_type":           "lead",
+			"status":               "success",
+		},
+	},
+	{
+		Type:        EventSyncFailed,
+		Description: "Sync of a record from source to target connection failed",
+		Schema:      eventSchema(syncEventData, "sync_log_id", "source_connection_id", "target_connection_id", "status", "error_message"),
+		Example: map[string]interface{}{
+			"sync_log_id":          1,
+			"source_connection_id": 1,
+			"target_connection_id": 2,
+			"event_type":           "lead",
+			"status":               "error",
+			"error_message":        "required field PHONE is empty",
+		},
+	},
+	{
+		Type:        EventConnectionActivated,
+		Description: "Connection was switched on",
+		Schema:      eventSchema(connectionEventData, "connection_id"),
+		Example:     map[string]interface{}{"connection_id": 1, "name": "Facebook Lead Ads", "system_type": "facebook"},
+	},
+	{
+		Type:        EventConnectionDeactivated,
+		Description: "Connection was switched off",
+		Schema:      eventSchema(connectionEventData, "connection_id"),
+		Example:     map[string]interface{}{"connection_id": 1, "name": "Facebook Lead Ads", "system_type": "facebook"},
+	},
+	{
+		Type:        EventMappingUpdated,
+		Description: "New mapping version was activated for a connection pair",
+		Schema: eventSchema(map[string]interface{}{
+			"source_connection_id": schemaType("integer"),
+			"target_connection_id": schemaType("integer"),
+			"version":              schemaType("integer"),
+			"author":               schemaType("string"),
+			"diff":                 schemaType("object"),
+		}, "source_connection_id", "target_connection_id", "version"),
+		Example: map[string]interface{}{
+			"source_connection_id": 1,
+			"target_connection_id": 2,
+			"version":              3,
+			"author":               "admin",
+			"diff":                 map[string]interface{}{"added": []interface{}{}, "removed": []interface{}{}, "changed": []interface{}{}},
+		},
+	},
+	{
+		Type:        EventSyncReport,
+		Description: "Periodic summary of records synced from the connection",
+		Schema: eventSchema(map[string]interface{}{
+			"connection_id": schemaType("integer"),
+			"period_start":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"period_end":    map[string]interface{}{"type": "string", "format": "date-time"},
+			"total":         schemaType("integer"),
+			"statuses":      schemaType("object"),
+		}, "connection_id", "period_start", "period_end", "total", "statuses"),
+		Example: map[string]interface{}{
+			"connection_id": 1,
+			"period_start":  "2024-01-01T08:00:00Z",
+			"period_end":    "2024-01-02T08:00:00Z",
+			"total":         42,
+			"statuses":      map[string]interface{}{"success": 40, "error": 2},
+		},
+	},
+}
+
+var syncEventData = map[string]interface{}{
+	"sync_log_id":          schemaType("integer"),
+	"source_connection_id": schemaType("integer"),
+	"target_connection_id": schemaType("integer"),
+	"event_type":           schemaType("string"),
+	"status":               map[string]interface{}{"type": "string", "enum": []string{"success", "error"}},
+	"error_message":        schemaType("string"),
+}
+
+var connectionEventData = map[string]interface{}{
+	"connection_id": schemaType("integer"),
+	"name":          schemaType("string"),
+	"system_type":   schemaType("string"),
+}
+
+// eventSchema - схема тела вебхука с заданным содержимым поля data
+func eventSchema(data map[string]interface{}, required ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"id":            schemaType("string"),
+			"type":          schemaType("string"),
+			"webhook_id":    schemaType("integer"),
+			"connection_id": schemaType("integer"),
+			"occurred_at":   map[string]interface{}{"type": "string", "format": "date-time"},
+			"data": map[string]interface{}{
+				"type":       "object",
+				"properties": data,
+				"required":   required,
+			},
+		},
+		"required": []string{"id", "type", "webhook_id", "occurred_at", "data"},
+	}
+}
+
+func schemaType(t string) map[string]interface{} {
+	return map[string]interface{}{"type": t}
+}
+
+// LookupEventType - первый тип каталога, подходящий под подписку
+func LookupEventType(pattern string) (*EventTypeInfo, bool) {
+	for i := range EventCatalog {
+		if MatchEventType(pattern, EventCatalog[i].Type) {
+			return &EventCatalog[i], true
+		}
+	}
+	return nil, false
+}
+
+// MatchEventType - подходит ли тип события под подписку: точное имя,
+// "*" для всех событий или префикс с "*" ("sync.*")
+func MatchEventType(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return false
+}
+
+// MatchEventFilters - все фильтры должны совпасть с полями data события.
+// Значение фильтра - скаляр или список допустимых значений.
+func MatchEventFilters(filters, data map[string]interface{}) bool {
+	for key, expected := range filters {
+		actual, ok := data[key]
+		if !ok {
+			return false
+		}
+
+		if options, ok := expected.([]interface{}); ok {
+			if !containsValue(options, actual) {
+				return false
+			}
+			continue
+		}
+
+		if !sameValue(expected, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateEventSubscription - тип события (или шаблон) должен быть в каталоге,
+// а фильтры - ссылаться на поля data подходящих типов
+func ValidateEventSubscription(pattern string, filters map[string]interface{}) error {
+	if pattern == "" {
+		return NewError("event type is required")
+	}
+
+	if strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+		return NewErrorf("invalid event type pattern %q: only a trailing * is supported", pattern)
+	}
+
+	fields := make(map[string]bool)
+	matched := 0
+	for _, info := range EventCatalog {
+		if !MatchEventType(pattern, info.Type) {
+			continue
+		}
+		matched++
+
+		data := info.Schema["properties"].(map[string]interface{})["data"].(map[string]interface{})
+		for field := range data["properties"].(map[string]interface{}) {
+			fields[field] = true
+		}
+	}
+
+	if matched == 0 {
+		return NewErrorf("unknown event type %q, see GET /api/webhooks/event-types", pattern)
+	}
+
+	for key := range filters {
+		if !fields[key] {
+			known := make([]string, 0, len(fields))
+			for f := range fields {
+				known = append(known, f)
+			}
+			sort.Strings(known)
+			return NewErrorf("unknown filter field %q for %q, allowed: %s", key, pattern, strings.Join(known, ", "))
+		}
+	}
+
+	return nil
+}
+
+func containsValue(options []interface{}, actual interface{}) bool {
+	for _, option := range options {
+		if sameValue(option, actual) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue - сравнение без учета числового типа (float64 из JSON против int)
+func sameValue(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Типы событий, на которые можно подписать исходящие вебхуки
+const (
+	EventSyncSucceeded         = "sync.succeeded"
+	EventSyncFailed            = "sync.failed"
+	EventConnectionActivated   = "connection.activated"
+	EventConnectionDeactivated = "connection.deactivated"
+	EventMappingUpdated        = "mapping.updated"
+	EventSyncReport            = "sync.report"
+)
+
+// Event - событие приложения. Доставляется вебхукам всех ConnectionIDs.
+type Event struct {
+	ID            string                 `json:"id"`
+	Type          string                 `json:"type"`
+	ConnectionIDs []int                  `json:"connection_ids"`
+	OccurredAt    time.Time              `json:"occurred_at"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event *Event)
+}
@@ -3,6 +3,9 @@ package domain
 import (
 	"context"
 	_ "context"
+	"net/http"
+	"time"
+
 	"integration-app/internal/domain/models"
 	_ "integration-app/internal/domain/models"
 )
@@ -14,6 +17,73 @@ type CacheService interface {
 	Get(key string) ([]byte, error)
 }
 
+// Connector - доступ к API внешней системы (bitrix24, facebook, ...)
+type Connector interface {
+	SystemType() string
+	DefaultEntity() string
+	GetFields(ctx context.Context, conn *models.Connection, entity string) ([]models.FieldDefinition, error)
+}
+
+// RecordWriter - коннектор, умеющий создавать и обновлять записи в своей системе
+type RecordWriter interface {
+	CreateRecord(ctx context.Context, conn *models.Connection, entity string, fields map[string]interface{}) (string, error)
+	UpdateRecord(ctx context.Context, conn *models.Connection, entity, id string, fields map[string]interface{}) error
+}
+
+// RecordMatcher - коннектор, умеющий искать существующие записи по значению
+// поля (телефон, email) перед созданием новой
+type RecordMatcher interface {
+	FindRecords(ctx context.Context, conn *models.Connection, entity, field string, values []string) ([]string, error)
+}
+
+// RecordReader - коннектор, умеющий читать текущее состояние записи по ID.
+// Для отсутствующей записи возвращает nil без ошибки.
+type RecordReader interface {
+	GetRecord(ctx context.Context, conn *models.Connection, entity, id string) (map[string]interface{}, error)
+}
+
+// RecordPoller - коннектор, из которого можно забирать записи опросом,
+// если система не умеет отправлять вебхуки. Возвращает записи после
+// позиции cursor по возрастанию времени изменения и новую позицию.
+// Ненулевой until ограничивает выборку записями, измененными не позже него.
+type RecordPoller interface {
+	PollRecords(ctx context.Context, conn *models.Connection, entity string, cursor models.SyncCursor, until time.Time) ([]map[string]interface{}, models.SyncCursor, error)
+}
+
+// TokenRefresher - коннектор, умеющий обновлять OAuth-токен подключения
+// по RefreshToken. Новые токены и ExpiresAt записываются в conn.
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context, conn *models.Connection) error
+}
+
+// SystemWebhook - тип подключения, принимающего произвольные данные на свой входящий URL
+const SystemWebhook = "webhook"
+
+type ConnectorRegistry interface {
+	Get(systemType string) (Connector, error)
+}
+
+// URLGuard - защита исходящих запросов на адреса пользователей от SSRF
+type URLGuard interface {
+	ValidateURL(ctx context.Context, rawURL string) error
+	NewClient(timeout time.Duration) *http.Client
+}
+
+// LeaderStatus - роль экземпляра приложения и текущий лидер
+type LeaderStatus struct {
+	Instance    string    `json:"instance"`
+	IsLeader    bool      `json:"is_leader"`
+	Leader      string    `json:"leader"` // экземпляр-лидер, пусто - лидера сейчас нет
+	LeaderSince time.Time `json:"leader_since,omitempty"`
+}
+
+// LeaderElector - выбор одного экземпляра для фоновой работы, которая не
+// должна выполняться параллельно на нескольких репликах
+type LeaderElector interface {
+	IsLeader() bool
+	Status(ctx context.Context) (*LeaderStatus, error)
+}
+
 type Logger interface {
 	Debug(msg string, args ...interface{})
 	Info(msg string, args ...interface{})
@@ -24,16 +94,17 @@ type Logger interface {
 type ConnectionRepository interface {
 	GetAll(ctx context.Context) ([]models.Connection, error)
 	GetByID(ctx context.Context, id int) (*models.Connection, error)
+	GetByAccessToken(ctx context.Context, systemType, token string) (*models.Connection, error)
 	Create(ctx context.Context, conn *models.Connection) error
 	Update(ctx context.Context, conn *models.Connection) error
 	Delete(ctx context.Context, id int) error
-	DeleteByUserID(ctx context.Context, userID int) error
 }
 
 type MappingRepository interface {
 	GetAll(ctx context.Context) ([]models.FieldMapping, error)
 	GetByUserID(ctx context.Context, userID int) ([]models.FieldMapping, error)
 	GetByConnectionPair(ctx context.Context, sourceID, targetID int) ([]models.FieldMapping, error)
+	GetTargetIDs(ctx context.Context, sourceID int) ([]int, error)
 	GetByID(ctx context.Context, id int) (*models.FieldMapping, error)
 	Create(ctx context.Context, mapping *models.FieldMapping) error
 	CreateBatch(ctx context.Context, mappings []models.FieldMapping) error
@@ -43,6 +114,18 @@ type MappingRepository interface {
 	DeleteByConnectionPair(ctx context.Context, sourceID, targetID int) error
 }
 
+type MappingVersionRepository interface {
+	GetByConnectionPair(ctx context.Context, sourceID, targetID int) ([]models.MappingVersion, error)
+	GetByVersion(ctx context.Context, sourceID, targetID, version int) (*models.MappingVersion, error)
+	GetActive(ctx context.Context, sourceID, targetID int) (*models.MappingVersion, error)
+	Activate(ctx context.Context, sourceID, targetID int, build MappingVersionBuilder) (*models.MappingVersion, bool, error)
+}
+
+// MappingVersionBuilder - строит новую версию пары по текущим сопоставлениям
+// и активной версии, прочитанным под блокировкой пары. nil - набор не
+// изменился, новая версия не нужна.
+type MappingVersionBuilder func(current []models.FieldMapping, active *models.MappingVersion) (*models.MappingVersion, []models.FieldMapping, error)
+
 type WebhookRepository interface {
 	GetAll(ctx context.Context) ([]models.Webhook, error)
 	GetByID(ctx context.Context, id int) (*models.Webhook, error)
@@ -55,13 +138,122 @@ type WebhookRepository interface {
 	DeleteByConnectionID(ctx context.Context, connectionID int) error
 }
 
+type WebhookDeliveryRepository interface {
+	GetByID(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	GetByWebhookID(ctx context.Context, webhookID int, limit int) ([]models.WebhookDelivery, error)
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	ClaimDueRetries(ctx context.Context, limit int) ([]models.WebhookDelivery, error)
+}
+
+type InboundSampleRepository interface {
+	GetLatest(ctx context.Context, connectionID int, limit int) ([]models.InboundSample, error)
+	Add(ctx context.Context, sample *models.InboundSample, keep int) error
+}
+
+type InboundEventRepository interface {
+	Register(ctx context.Context, event *models.InboundEvent, window time.Duration) (bool, error)
+	Release(ctx context.Context, connectionID int, idempotencyKey string) error
+}
+
+type RecordLinkRepository interface {
+	GetByID(ctx context.Context, id int) (*models.RecordLink, error)
+	Find(ctx context.Context, sourceID int, sourceRecordID string, targetID int, entity string) (*models.RecordLink, error)
+	FindByTarget(ctx context.Context, sourceID, targetID int, targetRecordID, entity string) (*models.RecordLink, error)
+	GetByRecord(ctx context.Context, connectionID int, recordID string) ([]models.RecordLink, error)
+	Save(ctx context.Context, link *models.RecordLink) error
+	Update(ctx context.Context, link *models.RecordLink) error
+	SaveSyncState(ctx context.Context, link *models.RecordLink) error
+	Delete(ctx context.Context, id int) error
+}
+
+type SyncPairRepository interface {
+	Get(ctx context.Context, sourceID, targetID int) (*models.SyncPair, error)
+	GetReverse(ctx context.Context, targetID int) ([]models.SyncPair, error)
+	Save(ctx context.Context, pair *models.SyncPair) error
+}
+
+type SyncConflictRepository interface {
+	GetByID(ctx context.Context, id int) (*models.SyncConflict, error)
+	GetByStatus(ctx context.Context, status string) ([]models.SyncConflict, error)
+	Create(ctx context.Context, conflict *models.SyncConflict) error
+	Resolve(ctx context.Context, id int, resolution string) (*models.SyncConflict, error)
+}
+
+type FlowRepository interface {
+	GetAll(ctx context.Context) ([]models.Flow, error)
+	GetByID(ctx context.Context, id int) (*models.Flow, error)
+	GetActiveByTrigger(ctx context.Context, connectionID int) ([]models.Flow, error)
+	Create(ctx context.Context, flow *models.Flow) error
+	Update(ctx context.Context, flow *models.Flow) error
+	Delete(ctx context.Context, id int) error
+}
+
+type FlowJobRepository interface {
+	GetByID(ctx context.Context, id int) (*models.FlowJob, error)
+	Find(ctx context.Context, filter models.FlowJobFilter) ([]models.FlowJob, error)
+	Create(ctx context.Context, job *models.FlowJob) error
+	ClaimDue(ctx context.Context, limit int, staleAfter time.Duration) ([]models.FlowJob, error)
+	Finish(ctx context.Context, id int, status, errMsg string) error
+	Cancel(ctx context.Context, id int) (bool, error)
+	CancelByRecord(ctx context.Context, connectionID int, recordID string, flowID int) (int, error)
+	CancelBySyncID(ctx context.Context, syncID int) (int, error)
+}
+
 type SyncLogRepository interface {
 	GetAll(ctx context.Context) ([]models.SyncLog, error)
 	GetByID(ctx context.Context, id int) (*models.SyncLog, error)
 	GetByConnectionPair(ctx context.Context, sourceID, targetID int) ([]models.SyncLog, error)
 	GetByStatus(ctx context.Context, status string) ([]models.SyncLog, error)
 	GetErrorLogs(ctx context.Context) ([]models.SyncLog, error)
+	GetByCorrelationID(ctx context.Context, correlationID string) ([]models.SyncLog, error)
+	GetBySyncID(ctx context.Context, syncID int) ([]models.SyncLog, error)
 	Create(ctx context.Context, log *models.SyncLog) error
 	CreateBatch(ctx context.Context, logs []models.SyncLog) error
 	DeleteOldLogs(ctx context.Context, olderThanDays int) error
+	CountBySource(ctx context.Context, since time.Time) (map[int]map[string]int, error)
+}
+
+type SyncCheckpointRepository interface {
+	GetAll(ctx context.Context) ([]models.SyncCheckpoint, error)
+	Get(ctx context.Context, connectionID int, entity string) (*models.SyncCheckpoint, error)
+	Advance(ctx context.Context, checkpoint *models.SyncCheckpoint, items []models.SyncQueueItem) error
+	Reset(ctx context.Context, checkpoint *models.SyncCheckpoint) error
+}
+
+type SyncQueueRepository interface {
+	ClaimDue(ctx context.Context, limit int, staleAfter time.Duration) ([]models.SyncQueueItem, error)
+	Finish(ctx context.Context, id int, status, errMsg string) error
+	Retry(ctx context.Context, id int, errMsg string, nextRetryAt time.Time) error
+	CountOpen(ctx context.Context, syncID int) (int, error)
+	CancelBySyncID(ctx context.Context, syncID int) (int, error)
+}
+
+type SyncBackfillRepository interface {
+	GetAll(ctx context.Context) ([]models.SyncBackfill, error)
+	GetByID(ctx context.Context, id int) (*models.SyncBackfill, error)
+	GetActive(ctx context.Context) ([]models.SyncBackfill, error)
+	Create(ctx context.Context, backfill *models.SyncBackfill) error
+	Start(ctx context.Context, backfill *models.SyncBackfill) (bool, error)
+	SetStatus(ctx context.Context, id int, status string, from ...string) (bool, error)
+	AddItems(ctx context.Context, backfill *models.SyncBackfill, items []models.SyncBackfillItem, cursor models.SyncCursor, fetched bool) error
+	NextItems(ctx context.Context, backfillID, limit int) ([]models.SyncBackfillItem, error)
+	FinishItem(ctx context.Context, backfill *models.SyncBackfill, item *models.SyncBackfillItem) error
+	Finish(ctx context.Context, id int, status, errMsg string) error
+	CancelBySyncID(ctx context.Context, syncID int) (bool, error)
+}
+
+type SyncRunRepository interface {
+	Find(ctx context.Context, filter models.SyncRunFilter) ([]models.SyncRun, error)
+	GetByID(ctx context.Context, id int) (*models.SyncRun, error)
+	Create(ctx context.Context, run *models.SyncRun) error
+	UpdateCounts(ctx context.Context, id int) error
+	Finish(ctx context.Context, run *models.SyncRun, errMsg string) error
+	Cancel(ctx context.Context, id int) (bool, error)
+	Reopen(ctx context.Context, id int) error
+	DeleteOld(ctx context.Context, olderThanDays int) error
+}
+
+type ScheduleRepository interface {
+	GetAll(ctx context.Context) ([]models.ScheduleState, error)
+	Save(ctx context.Context, state *models.ScheduleState) error
 }
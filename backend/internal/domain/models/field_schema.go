@@ -0,0 +1,19 @@
+package models
+
+// FieldOption - вариант значения поля-списка
+type FieldOption struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// FieldDefinition - описание поля сущности во внешней системе
+type FieldDefinition struct {
+	Name     string        `json:"name"`
+	Title    string        `json:"title"`
+	Type     string        `json:"type"`
+	Required bool          `json:"required"`
+	ReadOnly bool          `json:"read_only"`
+	Multiple bool          `json:"multiple"`
+	Custom   bool          `json:"custom"`
+	Options  []FieldOption `json:"options,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// FlowRoute - ветка шага router: запись в свое подключение со своими
+// сопоставлениями. Condition - выражение фильтра, пустое - ветка выполняется всегда.
+type FlowRoute struct {
+	ID           string        `json:"id"`
+	Condition    string        `json:"condition,omitempty"`
+	ConnectionID int           `json:"connection_id"`
+	Entity       string        `json:"entity,omitempty"`
+	Mappings     []MappingRule `json:"mappings"`
+	Operation    string        `json:"operation,omitempty"`
+	RecordID     string        `json:"record_id,omitempty"`
+}
+
+// FlowStep - шаг потока. Набор полей зависит от типа шага.
+// Результаты lookup и action доступны следующим шагам по пути "<id>.<поле>",
+// результаты веток router - по пути "<id>.<id ветки>.<поле>".
+type FlowStep struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"`                    // filter, transform, lookup, action, router, delay
+	Filters      map[string]interface{} `json:"filters,omitempty"`       // filter: путь -> значение или список значений
+	Expression   string                 `json:"expression,omitempty"`    // filter: выражение вместо или вместе с filters; delay, run_at: условие при срабатывании
+	Mappings     []MappingRule          `json:"mappings,omitempty"`      // transform, action
+	ConnectionID int                    `json:"connection_id,omitempty"` // lookup, action; delay, run_at: запись перечитывается при срабатывании
+	Entity       string                 `json:"entity,omitempty"`        // lookup, action; по умолчанию сущность коннектора
+	Field        string                 `json:"field,omitempty"`         // lookup: поле для поиска в подключении
+	Value        string                 `json:"value,omitempty"`         // lookup: путь к искомому значению
+	Operation    string                 `json:"operation,omitempty"`     // action: create, update, upsert
+	RecordID     string                 `json:"record_id,omitempty"`     // action: путь к ID записи для update/upsert; delay, run_at: запись для перечитывания
+	Routes       []FlowRoute            `json:"routes,omitempty"`        // router
+	RunAt        string                 `json:"run_at,omitempty"`        // run_at: путь к времени в данных или само время
+	DelaySeconds int                    `json:"delay_seconds,omitempty"` // delay; run_at: сдвиг относительно времени
+}
+
+// Flow - автоматизация: событие подключения-триггера проходит по шагам потока
+type Flow struct {
+	ID                  int        `bun:"id,pk,autoincrement"`
+	Name                string     `bun:"name"`
+	TriggerConnectionID int        `bun:"trigger_connection_id"`
+	TriggerEventType    string     `bun:"trigger_event_type"`      // тип события или шаблон "webhook", "*"
+	TriggerFilter       string     `bun:"trigger_filter,nullzero"` // выражение над данными события
+	Steps               []FlowStep `bun:"steps,type:jsonb"`
+	IsActive            bool       `bun:"is_active,default:true"`
+	CreatedAt           time.Time  `bun:"created_at,default:current_timestamp"`
+	UpdatedAt           time.Time  `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:flows"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// FlowJob - отложенное продолжение потока: с шага delay или run_at в
+// назначенное время. Хранит запись-триггер и данные потока на момент паузы.
+type FlowJob struct {
+	ID                  int                    `bun:"id,pk,autoincrement"`
+	FlowID              int                    `bun:"flow_id"`
+	StepID              string                 `bun:"step_id"` // шаг delay/run_at, с которого продолжится поток
+	TriggerConnectionID int                    `bun:"trigger_connection_id"`
+	EventType           string                 `bun:"event_type"`
+	SourceRecordID      string                 `bun:"source_record_id,nullzero"`
+	IdempotencyKey      string                 `bun:"idempotency_key,nullzero"`
+	CorrelationID       string                 `bun:"correlation_id,nullzero"`
+	SyncID              int                    `bun:"sync_id,nullzero"` // запуск, в журнал которого пишутся шаги
+	Payload             map[string]interface{} `bun:"payload,type:jsonb"`
+	Data                map[string]interface{} `bun:"data,type:jsonb"`
+	RunAt               time.Time              `bun:"run_at"`
+	Status              string                 `bun:"status,default:'pending'"` // pending, running, done, skipped, cancelled, failed
+	Error               string                 `bun:"error,nullzero"`
+	CreatedAt           time.Time              `bun:"created_at,default:current_timestamp"`
+	UpdatedAt           time.Time              `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:flow_jobs"`
+}
+
+// FlowJobFilter - отбор отложенных шагов; нулевые поля не ограничивают выборку
+type FlowJobFilter struct {
+	FlowID         int
+	ConnectionID   int
+	SourceRecordID string
+	Status         string
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// InboundEvent - ключ идемпотентности принятого события. Повтор с тем же
+// ключом в пределах окна дедупликации не обрабатывается заново.
+type InboundEvent struct {
+	ID             int       `bun:"id,pk,autoincrement"`
+	ConnectionID   int       `bun:"connection_id"`
+	IdempotencyKey string    `bun:"idempotency_key"`
+	ReceivedAt     time.Time `bun:"received_at"`
+
+	bun.BaseModel `bun:"table:inbound_events"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// InboundSample - последние данные, принятые входящим вебхуком подключения.
+// По ним определяется схема полей источника.
+type InboundSample struct {
+	ID           int                    `bun:"id,pk,autoincrement"`
+	ConnectionID int                    `bun:"connection_id"`
+	ContentType  string                 `bun:"content_type"`
+	Payload      map[string]interface{} `bun:"payload,type:jsonb"`
+	ReceivedAt   time.Time              `bun:"received_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:inbound_samples"`
+}
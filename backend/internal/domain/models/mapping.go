@@ -12,6 +12,7 @@ type FieldMapping struct {
 	TargetConnectionID int       `bun:"target_connection_id"`
 	SourceField        string    `bun:"source_field"`
 	TargetField        string    `bun:"target_field"`
+	Transform          string    `bun:"transform"` // trim|lower|phone, применяется слева направо
 	CreatedAt          time.Time `bun:"created_at,default:current_timestamp"`
 
 	bun.BaseModel `bun:"table:field_mappings"`
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MappingRule - сопоставление внутри снимка версии
+type MappingRule struct {
+	SourceField string `json:"source_field"`
+	TargetField string `json:"target_field"`
+	Transform   string `json:"transform,omitempty"`
+}
+
+// MappingChange - изменение сопоставления одного поля источника
+type MappingChange struct {
+	SourceField string      `json:"source_field"`
+	Before      MappingRule `json:"before"`
+	After       MappingRule `json:"after"`
+}
+
+type MappingDiff struct {
+	Added   []MappingRule   `json:"added"`
+	Removed []MappingRule   `json:"removed"`
+	Changed []MappingChange `json:"changed"`
+}
+
+// MappingVersion - неизменяемый снимок набора сопоставлений пары подключений.
+// Активная версия пары всегда материализована в field_mappings.
+type MappingVersion struct {
+	ID                 int           `bun:"id,pk,autoincrement"`
+	SourceConnectionID int           `bun:"source_connection_id"`
+	TargetConnectionID int           `bun:"target_connection_id"`
+	Version            int           `bun:"version"`
+	Mappings           []MappingRule `bun:"mappings,type:jsonb"`
+	Diff               *MappingDiff  `bun:"diff,type:jsonb"`
+	Author             string        `bun:"author"`
+	Comment            string        `bun:"comment"`
+	IsActive           bool          `bun:"is_active"`
+	CreatedAt          time.Time     `bun:"created_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:mapping_versions"`
+}
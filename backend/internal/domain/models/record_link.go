@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// RecordLink - связь записи источника с записью, созданной по ней в целевой
+// системе. По ней движок синхронизации решает, обновить запись или создать.
+type RecordLink struct {
+	ID                 int                    `bun:"id,pk,autoincrement"`
+	SourceConnectionID int                    `bun:"source_connection_id"`
+	SourceRecordID     string                 `bun:"source_record_id"`
+	TargetConnectionID int                    `bun:"target_connection_id"`
+	TargetRecordID     string                 `bun:"target_record_id"`
+	EntityType         string                 `bun:"entity_type"`              // сущность цели: lead, contact, deal
+	LastSyncedHash     string                 `bun:"last_synced_hash"`         // хэш последних отправленных в цель данных
+	SyncedData         map[string]interface{} `bun:"synced_data,type:jsonb"`   // значения полей цели после последней синхронизации
+	SyncedFields       []string               `bun:"synced_fields,type:jsonb"` // поля, измененные последней синхронизацией
+	SyncedFrom         string                 `bun:"synced_from"`              // сторона, с которой пришли изменения: source, target
+	SyncedAt           time.Time              `bun:"synced_at,nullzero"`
+	ChangedAt          time.Time              `bun:"changed_at,nullzero"` // время изменения на стороне SyncedFrom
+	CreatedAt          time.Time              `bun:"created_at,default:current_timestamp"`
+	UpdatedAt          time.Time              `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:record_links"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ScheduleState - последний запуск задания планировщика. Сами задания
+// задаются конфигурацией и метаданными подключений, в таблице хранится
+// только их состояние, чтобы оно переживало перезапуск.
+type ScheduleState struct {
+	Name           string    `bun:"name,pk"`
+	LastRunAt      time.Time `bun:"last_run_at,nullzero"`
+	LastSuccessAt  time.Time `bun:"last_success_at,nullzero"`
+	LastStatus     string    `bun:"last_status,nullzero"` // success, error
+	LastError      string    `bun:"last_error,nullzero"`
+	LastDurationMs int64     `bun:"last_duration_ms"`
+	NextRunAt      time.Time `bun:"next_run_at,nullzero"`
+	UpdatedAt      time.Time `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:schedules"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SyncBackfill - разовая выгрузка исторических записей источника за период
+// в цель пары. Записи сначала забираются из источника в sync_backfill_items
+// (позиция чтения - Cursor*), затем синхронизируются с ограничением скорости.
+type SyncBackfill struct {
+	ID                 int       `bun:"id,pk,autoincrement"`
+	SourceConnectionID int       `bun:"source_connection_id"`
+	TargetConnectionID int       `bun:"target_connection_id"`
+	Entity             string    `bun:"entity"`
+	DateFrom           time.Time `bun:"date_from"`
+	DateTo             time.Time `bun:"date_to"`
+	RatePerMinute      int       `bun:"rate_per_minute"`
+	Status             string    `bun:"status,default:'pending'"` // pending, running, paused, done, failed, cancelled
+	Fetched            bool      `bun:"fetched,notnull"`          // все записи периода забраны из источника
+	CursorModifiedAt   time.Time `bun:"cursor_modified_at,nullzero"`
+	CursorRecordID     string    `bun:"cursor_record_id,nullzero"`
+	Total              int       `bun:"total,notnull"`
+	Processed          int       `bun:"processed,notnull"`
+	Failed             int       `bun:"failed,notnull"`
+	CorrelationID      string    `bun:"correlation_id"` // общий для всех записей журнала выгрузки
+	SyncID             int       `bun:"sync_id,nullzero"`
+	Error              string    `bun:"error,nullzero"`
+	CreatedBy          string    `bun:"created_by"`
+	StartedAt          time.Time `bun:"started_at,nullzero"`
+	FinishedAt         time.Time `bun:"finished_at,nullzero"`
+	CreatedAt          time.Time `bun:"created_at,default:current_timestamp"`
+	UpdatedAt          time.Time `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:sync_backfills"`
+}
+
+func (b *SyncBackfill) Cursor() SyncCursor {
+	return SyncCursor{
+		ModifiedAt: b.CursorModifiedAt,
+		RecordID:   b.CursorRecordID,
+	}
+}
+
+// Remaining - записи, забранные из источника, но еще не синхронизированные
+func (b *SyncBackfill) Remaining() int {
+	return b.Total - b.Processed - b.Failed
+}
+
+// SyncBackfillItem - запись источника, забранная выгрузкой
+type SyncBackfillItem struct {
+	ID             int                    `bun:"id,pk,autoincrement"`
+	BackfillID     int                    `bun:"backfill_id"`
+	SourceRecordID string                 `bun:"source_record_id,nullzero"`
+	Payload        map[string]interface{} `bun:"payload,type:jsonb"`
+	Status         string                 `bun:"status,default:'pending'"` // pending, done, failed
+	Error          string                 `bun:"error,nullzero"`
+	SyncLogID      int                    `bun:"sync_log_id,nullzero"`
+	UpdatedAt      time.Time              `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:sync_backfill_items"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SyncCursor - позиция опроса источника: время изменения и ID последней
+// забранной записи (для записей с одинаковым временем) или токен страницы
+// провайдера
+type SyncCursor struct {
+	ModifiedAt time.Time
+	RecordID   string
+	PageToken  string
+}
+
+// SyncCheckpoint - сохраненная позиция опроса сущности подключения
+type SyncCheckpoint struct {
+	ConnectionID int       `bun:"connection_id,pk"`
+	Entity       string    `bun:"entity,pk"`
+	ModifiedAt   time.Time `bun:"modified_at,nullzero"`
+	RecordID     string    `bun:"record_id,nullzero"`
+	PageToken    string    `bun:"page_token,nullzero"`
+	UpdatedAt    time.Time `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:sync_checkpoints"`
+}
+
+func (c *SyncCheckpoint) Cursor() SyncCursor {
+	return SyncCursor{
+		ModifiedAt: c.ModifiedAt,
+		RecordID:   c.RecordID,
+		PageToken:  c.PageToken,
+	}
+}
+
+// SyncQueueItem - забранная опросом запись, ожидающая синхронизации
+type SyncQueueItem struct {
+	ID             int                    `bun:"id,pk,autoincrement"`
+	ConnectionID   int                    `bun:"connection_id"`
+	Entity         string                 `bun:"entity"`
+	SourceRecordID string                 `bun:"source_record_id,nullzero"`
+	Payload        map[string]interface{} `bun:"payload,type:jsonb"`
+	Status         string                 `bun:"status,default:'pending'"` // pending, running, done, failed, cancelled
+	SyncID         int                    `bun:"sync_id,nullzero"`         // запуск опроса, поставивший запись
+	Error          string                 `bun:"error,nullzero"`
+	Attempts       int                    `bun:"attempts"`               // неудачных попыток синхронизации
+	NextRetryAt    time.Time              `bun:"next_retry_at,nullzero"` // время повтора после ошибки
+	CreatedAt      time.Time              `bun:"created_at,default:current_timestamp"`
+	UpdatedAt      time.Time              `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:sync_queue"`
+}
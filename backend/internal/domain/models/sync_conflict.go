@@ -0,0 +1,32 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SyncConflict - одно поле связанной записи изменено на обеих сторонах
+// двусторонней пары. Конфликты с политикой manual ждут решения пользователя,
+// остальные сохраняются уже разрешенными.
+type SyncConflict struct {
+	ID                 int             `bun:"id,pk,autoincrement"`
+	RecordLinkID       int             `bun:"record_link_id"`
+	SourceConnectionID int             `bun:"source_connection_id"`
+	TargetConnectionID int             `bun:"target_connection_id"`
+	SourceRecordID     string          `bun:"source_record_id"`
+	TargetRecordID     string          `bun:"target_record_id"`
+	SourceField        string          `bun:"source_field"`
+	TargetField        string          `bun:"target_field"`
+	SourceValue        json.RawMessage `bun:"source_value,type:jsonb"`
+	TargetValue        json.RawMessage `bun:"target_value,type:jsonb"`
+	Policy             string          `bun:"policy"`     // source_wins, target_wins, last_writer_wins, manual
+	Status             string          `bun:"status"`     // open, resolved
+	Resolution         string          `bun:"resolution"` // source, target
+	CreatedAt          time.Time       `bun:"created_at,default:current_timestamp"`
+	ResolvedAt         sql.NullTime    `bun:"resolved_at"`
+
+	bun.BaseModel `bun:"table:sync_conflicts"`
+}
@@ -10,12 +10,21 @@ import (
 type SyncLog struct {
 	ID                 int             `bun:"id,pk,autoincrement"`
 	SourceConnectionID int             `bun:"source_connection_id"`
-	TargetConnectionID int             `bun:"target_connection_id"`
+	TargetConnectionID int             `bun:"target_connection_id,nullzero"` // пусто для шагов потока без подключения
 	EventType          string          `bun:"event_type"`
-	Status             string          `bun:"status"` // success, error, pending
+	Status             string          `bun:"status"`          // success, error, pending, duplicate, skipped
+	Action             string          `bun:"action,nullzero"` // created, updated, unchanged, matched_*
+	SourceRecordID     string          `bun:"source_record_id,nullzero"`
+	TargetRecordID     string          `bun:"target_record_id,nullzero"`
 	SourceData         json.RawMessage `bun:"source_data,type:jsonb"`
 	TargetData         json.RawMessage `bun:"target_data,type:jsonb"`
 	ErrorMessage       string          `bun:"error_message"`
+	IdempotencyKey     string          `bun:"idempotency_key,nullzero"`
+	FlowID             int             `bun:"flow_id,nullzero"`
+	FlowStep           string          `bun:"flow_step,nullzero"`      // ID шага потока
+	CorrelationID      string          `bun:"correlation_id,nullzero"` // общий для всех записей журнала одного входящего события
+	SyncID             int             `bun:"sync_id,nullzero"`        // запуск синхронизации (sync_runs)
+	Attempts           int             `bun:"attempts,default:1"`      // попыток записи в цель
 	CreatedAt          time.Time       `bun:"created_at,default:current_timestamp"`
 
 	bun.BaseModel `bun:"table:sync_logs"`
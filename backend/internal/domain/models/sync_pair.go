@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SyncPair - настройки синхронизации пары подключений источник -> цель
+type SyncPair struct {
+	ID                 int               `bun:"id,pk,autoincrement"`
+	SourceConnectionID int               `bun:"source_connection_id"`
+	TargetConnectionID int               `bun:"target_connection_id"`
+	MatchFields        []string          `bun:"match_fields,type:jsonb"`                    // поля цели для поиска дубликата: PHONE, EMAIL
+	OnMatch            string            `bun:"on_match,default:'update'"`                  // update, create, skip
+	Bidirectional      bool              `bun:"bidirectional"`                              // изменения цели возвращаются в источник
+	ConflictPolicy     string            `bun:"conflict_policy,default:'last_writer_wins'"` // политика по умолчанию
+	FieldPolicies      map[string]string `bun:"field_policies,type:jsonb"`                  // поле цели -> политика
+	Filter             string            `bun:"filter,nullzero"`                            // выражение; записи, не прошедшие его, не синхронизируются
+	CreatedAt          time.Time         `bun:"created_at,default:current_timestamp"`
+	UpdatedAt          time.Time         `bun:"updated_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:sync_pairs"`
+}
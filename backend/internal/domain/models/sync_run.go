@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SyncRun - один запуск синхронизации: входящий вебхук, опрос подключения,
+// выгрузка или ручной запуск потока. Записи журнала запуска ссылаются на
+// него через sync_logs.sync_id, счетчики пересчитываются по журналу.
+type SyncRun struct {
+	ID                 int       `bun:"id,pk,autoincrement"`
+	TriggerType        string    `bun:"trigger_type"` // webhook, poll, backfill, manual
+	SourceConnectionID int       `bun:"source_connection_id,nullzero"`
+	TargetConnectionID int       `bun:"target_connection_id,nullzero"` // только для выгрузок в одну цель
+	FlowID             int       `bun:"flow_id,nullzero"`              // только для ручного запуска потока
+	Status             string    `bun:"status,default:'running'"`      // running, success, error, cancelled
+	Initiator          string    `bun:"initiator"`
+	Processed          int       `bun:"processed,notnull"`
+	Succeeded          int       `bun:"succeeded,notnull"`
+	Failed             int       `bun:"failed,notnull"`
+	Skipped            int       `bun:"skipped,notnull"` // skipped и duplicate
+	Error              string    `bun:"error,nullzero"`
+	StartedAt          time.Time `bun:"started_at,default:current_timestamp"`
+	FinishedAt         time.Time `bun:"finished_at,nullzero"`
+
+	bun.BaseModel `bun:"table:sync_runs"`
+}
+
+// SyncRunFilter - отбор запусков; нулевые поля не ограничивают выборку
+type SyncRunFilter struct {
+	TriggerType  string
+	Status       string
+	ConnectionID int
+}
@@ -8,13 +8,20 @@ import (
 )
 
 type Webhook struct {
-	ID           int            `bun:"id,pk,autoincrement"`
-	ConnectionID int            `bun:"connection_id"`
-	EventType    string         `bun:"event_type"`
-	CallbackURL  string         `bun:"callback_url"`
-	SecretKey    sql.NullString `bun:"secret_key"`
-	IsActive     bool           `bun:"is_active,default:true"`
-	CreatedAt    time.Time      `bun:"created_at,default:current_timestamp"`
+	ID                  int                    `bun:"id,pk,autoincrement"`
+	ConnectionID        int                    `bun:"connection_id"`
+	EventType           string                 `bun:"event_type"`         // тип события или шаблон "sync.*"
+	Filters             map[string]interface{} `bun:"filters,type:jsonb"` // условия на поля data события
+	CallbackURL         string                 `bun:"callback_url"`
+	PayloadFormat       string                 `bun:"payload_format,default:'json'"` // json, cloudevents, cloudevents-binary, form
+	Headers             map[string]string      `bun:"headers,type:jsonb"`            // дополнительные заголовки запроса
+	SecretKey           sql.NullString         `bun:"secret_key"`
+	PreviousSecretKey   sql.NullString         `bun:"previous_secret_key"`        // действует до PreviousSecretUntil
+	PreviousSecretUntil sql.NullTime           `bun:"previous_secret_expires_at"` // конец периода двойной подписи
+	IsActive            bool                   `bun:"is_active,default:true"`
+	ConsecutiveFailures int                    `bun:"consecutive_failures"` // подряд неудачных доставок
+	DisabledReason      sql.NullString         `bun:"disabled_reason"`
+	CreatedAt           time.Time              `bun:"created_at,default:current_timestamp"`
 
 	bun.BaseModel `bun:"table:webhooks"`
 }
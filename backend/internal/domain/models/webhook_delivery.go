@@ -0,0 +1,28 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// WebhookDelivery - одна попытка доставки события на вебхук
+type WebhookDelivery struct {
+	ID             int           `bun:"id,pk,autoincrement"`
+	WebhookID      int           `bun:"webhook_id"`
+	EventID        string        `bun:"event_id"`
+	EventType      string        `bun:"event_type"`
+	RequestBody    string        `bun:"request_body"`
+	ResponseStatus int           `bun:"response_status"`
+	ResponseBody   string        `bun:"response_body"` // первые 1024 байта ответа
+	ErrorMessage   string        `bun:"error_message"`
+	LatencyMs      int64         `bun:"latency_ms"`
+	Attempt        int           `bun:"attempt"`
+	Status         string        `bun:"status"` // success, failed
+	NextRetryAt    sql.NullTime  `bun:"next_retry_at"`
+	RedeliveryOf   sql.NullInt64 `bun:"redelivery_of"`
+	CreatedAt      time.Time     `bun:"created_at,default:current_timestamp"`
+
+	bun.BaseModel `bun:"table:webhook_deliveries"`
+}
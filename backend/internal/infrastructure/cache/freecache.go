@@ -33,6 +33,9 @@ func (c *Cache) Set(key string, value []byte) error {
 
 func (c *Cache) SetWithTTL(key string, value []byte, ttl int) error {
 	err := c.cache.Set([]byte(key), value, ttl)
+	if errors.Is(err, freecache.ErrLargeEntry) {
+		return fmt.Errorf("%w: %d bytes for key %s, limit is 1/1024 of CACHE_SIZE", domain.ErrCacheTooLarge, len(value), key)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to set cache value with TTL: %w", err)
 	}
@@ -0,0 +1,541 @@
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+const SystemBitrix24 = "bitrix24"
+
+var bitrix24Entities = map[string]bool{
+	"lead":    true,
+	"contact": true,
+	"company": true,
+	"deal":    true,
+}
+
+type Bitrix24Connector struct {
+	client *http.Client
+	logger domain.Logger
+}
+
+func NewBitrix24Connector(client *http.Client, logger domain.Logger) *Bitrix24Connector {
+	return &Bitrix24Connector{
+		client: client,
+		logger: logger,
+	}
+}
+
+func (c *Bitrix24Connector) SystemType() string {
+	return SystemBitrix24
+}
+
+func (c *Bitrix24Connector) DefaultEntity() string {
+	return "lead"
+}
+
+type bitrix24Field struct {
+	Type       string `json:"type"`
+	IsRequired bool   `json:"isRequired"`
+	IsReadOnly bool   `json:"isReadOnly"`
+	IsMultiple bool   `json:"isMultiple"`
+	Title      string `json:"title"`
+	FormLabel  string `json:"formLabel"`
+	ListLabel  string `json:"listLabel"`
+	Items      []struct {
+		ID    string `json:"ID"`
+		Value string `json:"VALUE"`
+	} `json:"items"`
+}
+
+// GetFields - crm.<entity>.fields, включая пользовательские поля UF_CRM_*
+func (c *Bitrix24Connector) GetFields(ctx context.Context, conn *models.Connection, entity string) ([]models.FieldDefinition, error) {
+	if !bitrix24Entities[entity] {
+		return nil, domain.NewErrorf("unsupported bitrix24 entity %q", entity)
+	}
+
+	var fields map[string]bitrix24Field
+	if err := c.call(ctx, conn, fmt.Sprintf("crm.%s.fields", entity), nil, &fields); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.FieldDefinition, 0, len(fields))
+	for name, f := range fields {
+		def := models.FieldDefinition{
+			Name:     name,
+			Title:    f.Title,
+			Type:     f.Type,
+			Required: f.IsRequired,
+			ReadOnly: f.IsReadOnly,
+			Multiple: f.IsMultiple,
+			Custom:   strings.HasPrefix(name, "UF_"),
+		}
+
+		// У пользовательских полей title совпадает с кодом, подпись лежит в formLabel/listLabel
+		if def.Custom || def.Title == "" || def.Title == name {
+			if f.FormLabel != "" {
+				def.Title = f.FormLabel
+			} else if f.ListLabel != "" {
+				def.Title = f.ListLabel
+			}
+		}
+
+		for _, item := range f.Items {
+			def.Options = append(def.Options, models.FieldOption{ID: item.ID, Value: item.Value})
+		}
+
+		result = append(result, def)
+	}
+
+	return result, nil
+}
+
+// CreateRecord - crm.<entity>.add. Возвращает ID созданной записи.
+// Множественные поля (PHONE, EMAIL) передаются списком {VALUE, VALUE_TYPE}.
+func (c *Bitrix24Connector) CreateRecord(ctx context.Context, conn *models.Connection, entity string, fields map[string]interface{}) (string, error) {
+	if !bitrix24Entities[entity] {
+		return "", domain.NewErrorf("unsupported bitrix24 entity %q", entity)
+	}
+
+	params := url.Values{}
+	for name, value := range fields {
+		appendBitrix24Param(params, "fields["+name+"]", value)
+	}
+	params.Set("params[REGISTER_SONET_EVENT]", "Y")
+
+	var id json.Number
+	if err := c.call(ctx, conn, "crm."+entity+".add", params, &id); err != nil {
+		return "", err
+	}
+
+	c.logger.Info("Bitrix24: record created", "entity", entity, "id", id.String(), "connection_id", conn.ID)
+	return id.String(), nil
+}
+
+// UpdateRecord - crm.<entity>.update. Передаются только сопоставленные поля,
+// остальные поля записи в Bitrix24 не меняются.
+func (c *Bitrix24Connector) UpdateRecord(ctx context.Context, conn *models.Connection, entity, id string, fields map[string]interface{}) error {
+	if !bitrix24Entities[entity] {
+		return domain.NewErrorf("unsupported bitrix24 entity %q", entity)
+	}
+
+	params := url.Values{}
+	params.Set("id", id)
+	for name, value := range fields {
+		appendBitrix24Param(params, "fields["+name+"]", value)
+	}
+	params.Set("params[REGISTER_SONET_EVENT]", "Y")
+
+	var ok bool
+	if err := c.call(ctx, conn, "crm."+entity+".update", params, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return domain.NewErrorf("bitrix24 crm.%s.update: record %s was not updated", entity, id)
+	}
+
+	c.logger.Info("Bitrix24: record updated", "entity", entity, "id", id, "connection_id", conn.ID)
+	return nil
+}
+
+// GetRecord - crm.<entity>.get; nil, если записи нет
+func (c *Bitrix24Connector) GetRecord(ctx context.Context, conn *models.Connection, entity, id string) (map[string]interface{}, error) {
+	if !bitrix24Entities[entity] {
+		return nil, domain.NewErrorf("unsupported bitrix24 entity %q", entity)
+	}
+
+	params := url.Values{}
+	params.Set("id", id)
+
+	var record map[string]interface{}
+	if err := c.call(ctx, conn, "crm."+entity+".get", params, &record); err != nil {
+		// Для удаленной или несуществующей записи Bitrix24 отвечает ошибкой "Not found"
+		if strings.Contains(err.Error(), "Not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
+const (
+	bitrix24PageSize     = 50
+	bitrix24PollMaxPages = 20
+	bitrix24OAuthURL     = "https://oauth.bitrix.info/oauth/token/"
+)
+
+// PollRecords - записи, измененные после позиции, по возрастанию DATE_MODIFY
+// и ID. Страницы читаются по ключу, а не смещением start: каждая следующая
+// запрашивается заново от последней забранной записи, поэтому запись,
+// измененная во время чтения, не сдвигает список и не теряется, а придет
+// позже с новым DATE_MODIFY. Уже забранные записи с тем же временем
+// отсекаются по ID; если целая страница приходится на одну секунду, она
+// дочитывается фильтром по ID. За один вызов читается не больше
+// bitrix24PollMaxPages страниц, остальное заберет следующий опрос.
+func (c *Bitrix24Connector) PollRecords(ctx context.Context, conn *models.Connection, entity string, cursor models.SyncCursor, until time.Time) ([]map[string]interface{}, models.SyncCursor, error) {
+	if !bitrix24Entities[entity] {
+		return nil, cursor, domain.NewErrorf("unsupported bitrix24 entity %q", entity)
+	}
+
+	next := cursor
+	records := make([]map[string]interface{}, 0)
+	// ">=" - от секунды курсора, "=" - дочитать секунду курсора по ID,
+	// ">" - секунда курсора прочитана целиком
+	op := ">="
+	for page := 0; page < bitrix24PollMaxPages; page++ {
+		params := url.Values{}
+		params.Set("filter["+op+"DATE_MODIFY]", next.ModifiedAt.Format(time.RFC3339))
+		if op == "=" {
+			params.Set("filter[>ID]", next.RecordID)
+		}
+		if !until.IsZero() {
+			params.Set("filter[<=DATE_MODIFY]", until.Format(time.RFC3339))
+		}
+		params.Set("order[DATE_MODIFY]", "ASC")
+		params.Set("order[ID]", "ASC")
+		params.Set("select[0]", "*")
+		params.Set("select[1]", "UF_*")
+		// Телефоны и email не входят в "*"
+		if entity != "deal" {
+			params.Set("select[2]", "PHONE")
+			params.Set("select[3]", "EMAIL")
+		}
+		// Без подсчета общего числа записей: страница всегда первая
+		params.Set("start", "-1")
+
+		var items []map[string]interface{}
+		if err := c.call(ctx, conn, "crm."+entity+".list", params, &items); err != nil {
+			return nil, cursor, err
+		}
+
+		advanced := false
+		for _, item := range items {
+			modified, _ := time.Parse(time.RFC3339, fmt.Sprint(item["DATE_MODIFY"]))
+			id := bitrix24String(item["ID"])
+			if !cursorAfter(next, modified, id) {
+				continue
+			}
+			records = append(records, item)
+			next = models.SyncCursor{ModifiedAt: modified, RecordID: id}
+			advanced = true
+		}
+
+		full := len(items) >= bitrix24PageSize
+		switch {
+		case op == "=" && !full:
+			op = ">"
+		case op == "=":
+		case !full:
+			c.logger.Debug("Bitrix24: records polled", "entity", entity, "count", len(records), "connection_id", conn.ID)
+			return records, next, nil
+		case !advanced:
+			op = "="
+		default:
+			op = ">="
+		}
+	}
+
+	c.logger.Debug("Bitrix24: records polled", "entity", entity, "count", len(records), "connection_id", conn.ID)
+	return records, next, nil
+}
+
+// RefreshToken - обновление OAuth-токена приложения. Нужны client_id и
+// client_secret приложения в метаданных; подключениям через входящий
+// вебхук обновление не требуется.
+func (c *Bitrix24Connector) RefreshToken(ctx context.Context, conn *models.Connection) error {
+	if strings.HasPrefix(conn.AccessToken, "http") {
+		return domain.NewError("bitrix24 webhook connections do not expire")
+	}
+
+	clientID := metadataString(conn, "client_id")
+	clientSecret := metadataString(conn, "client_secret")
+	if clientID == "" || clientSecret == "" || !conn.RefreshToken.Valid {
+		return domain.NewError("bitrix24 token refresh requires client_id, client_secret and refresh token")
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("client_id", clientID)
+	params.Set("client_secret", clientSecret)
+	params.Set("refresh_token", conn.RefreshToken.String)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bitrix24OAuthURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// url.Error содержит полный адрес вместе с client_secret
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return domain.NewTemporaryError(domain.NewErrorf("bitrix24 token refresh failed: %v", err))
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return domain.NewErrorf("bitrix24 token refresh returned invalid response (status %d)", resp.StatusCode)
+	}
+	if body.Error != "" || body.AccessToken == "" {
+		return domain.NewErrorf("bitrix24 token refresh: %s %s", body.Error, body.ErrorDescription)
+	}
+
+	conn.AccessToken = body.AccessToken
+	conn.RefreshToken = sql.NullString{String: body.RefreshToken, Valid: body.RefreshToken != ""}
+	conn.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Duration(body.ExpiresIn) * time.Second), Valid: body.ExpiresIn > 0}
+
+	c.logger.Info("Bitrix24: token refreshed", "connection_id", conn.ID)
+	return nil
+}
+
+// bitrix24CommTypes - поля, дубликаты по которым ищет crm.duplicate.findbycomm
+var bitrix24CommTypes = map[string]bool{
+	"PHONE": true,
+	"EMAIL": true,
+}
+
+// FindRecords - ID записей сущности с одним из значений поля. Телефоны и email
+// лидов, контактов и компаний ищутся через crm.duplicate.findbycomm, который
+// сам нормализует номера, остальные поля - фильтром crm.<entity>.list.
+// Незнакомые ключи фильтра Bitrix24 молча игнорирует и отдает весь список,
+// поэтому найденные записи еще раз сверяются со значением поля.
+func (c *Bitrix24Connector) FindRecords(ctx context.Context, conn *models.Connection, entity, field string, values []string) ([]string, error) {
+	if !bitrix24Entities[entity] {
+		return nil, domain.NewErrorf("unsupported bitrix24 entity %q", entity)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	if bitrix24CommTypes[field] && entity != "deal" {
+		return c.findByComm(ctx, conn, entity, field, values)
+	}
+
+	wanted := make(map[string]bool, len(values))
+	params := url.Values{}
+	for i, value := range values {
+		params.Set(fmt.Sprintf("filter[%s][%d]", field, i), value)
+		wanted[bitrix24MatchValue(field, value)] = true
+	}
+	params.Set("select[0]", "ID")
+	params.Set("select[1]", field)
+	params.Set("order[ID]", "ASC")
+
+	var items []map[string]interface{}
+	if err := c.call(ctx, conn, "crm."+entity+".list", params, &items); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if !bitrix24FieldMatches(field, item[field], wanted) {
+			continue
+		}
+		ids = append(ids, bitrix24String(item["ID"]))
+	}
+
+	if len(ids) < len(items) {
+		c.logger.Warn("Bitrix24: filter returned records without the searched value", "connection_id", conn.ID, "entity", entity, "field", field, "returned", len(items), "matched", len(ids))
+	}
+	return ids, nil
+}
+
+// bitrix24FieldMatches - есть ли среди значений поля записи одно из искомых.
+// Понимает множественные поля [{VALUE: ...}] и списки.
+func bitrix24FieldMatches(field string, value interface{}, wanted map[string]bool) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		for _, item := range v {
+			if bitrix24FieldMatches(field, item, wanted) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		return bitrix24FieldMatches(field, v["VALUE"], wanted)
+	default:
+		s := bitrix24MatchValue(field, bitrix24String(v))
+		return s != "" && wanted[s]
+	}
+}
+
+func bitrix24String(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// bitrix24MatchValue - значение для сравнения: телефон - цифры с 8 -> 7,
+// email и остальные строки - без пробелов по краям и без учета регистра
+func bitrix24MatchValue(field, value string) string {
+	value = strings.TrimSpace(value)
+	if strings.Contains(strings.ToUpper(field), "PHONE") {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, value)
+		if len(digits) == 11 && digits[0] == '8' {
+			digits = "7" + digits[1:]
+		}
+		return digits
+	}
+	return strings.ToLower(value)
+}
+
+func (c *Bitrix24Connector) findByComm(ctx context.Context, conn *models.Connection, entity, field string, values []string) ([]string, error) {
+	entityType := strings.ToUpper(entity)
+
+	params := url.Values{}
+	params.Set("type", field)
+	params.Set("entity_type", entityType)
+	for i, value := range values {
+		params.Set(fmt.Sprintf("values[%d]", i), value)
+	}
+
+	// Без совпадений result - пустой массив, а не объект
+	var raw json.RawMessage
+	if err := c.call(ctx, conn, "crm.duplicate.findbycomm", params, &raw); err != nil {
+		return nil, err
+	}
+
+	var found map[string][]json.Number
+	if err := json.Unmarshal(raw, &found); err != nil {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(found[entityType]))
+	for _, id := range found[entityType] {
+		ids = append(ids, id.String())
+	}
+	return ids, nil
+}
+
+// appendBitrix24Param - вложенные значения в формате PHP: fields[PHONE][0][VALUE]=...
+func appendBitrix24Param(params url.Values, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			appendBitrix24Param(params, key+"["+k+"]", item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			appendBitrix24Param(params, fmt.Sprintf("%s[%d]", key, i), item)
+		}
+	case []map[string]interface{}:
+		for i, item := range v {
+			appendBitrix24Param(params, fmt.Sprintf("%s[%d]", key, i), item)
+		}
+	case nil:
+		params.Add(key, "")
+	case bool:
+		if v {
+			params.Add(key, "Y")
+		} else {
+			params.Add(key, "N")
+		}
+	case float64:
+		params.Add(key, strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		params.Add(key, fmt.Sprint(v))
+	}
+}
+
+// call - вызов REST-метода Bitrix24. AccessToken - либо URL входящего вебхука
+// (https://portal.bitrix24.ru/rest/1/xxxx/), либо OAuth-токен вместе с
+// portal_url в метаданных подключения.
+func (c *Bitrix24Connector) call(ctx context.Context, conn *models.Connection, method string, params url.Values, out interface{}) error {
+	endpoint, err := c.endpoint(conn, method)
+	if err != nil {
+		return err
+	}
+
+	if params == nil {
+		params = url.Values{}
+	}
+	if !strings.HasPrefix(conn.AccessToken, "http") {
+		params.Set("auth", conn.AccessToken)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	c.logger.Debug("Bitrix24: calling method", "method", method, "connection_id", conn.ID)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// url.Error содержит адрес входящего вебхука, то есть его секрет
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return domain.NewTemporaryError(domain.NewErrorf("bitrix24 %s request failed: %v", method, err))
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Result           json.RawMessage `json:"result"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		err = domain.NewErrorf("bitrix24 %s returned invalid response (status %d)", method, resp.StatusCode)
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return domain.NewTemporaryError(err)
+		}
+		return err
+	}
+
+	if body.Error != "" {
+		err := domain.NewErrorf("bitrix24 %s: %s %s", method, body.Error, body.ErrorDescription)
+		// Превышение лимита запросов портала проходит само через несколько секунд
+		if body.Error == "QUERY_LIMIT_EXCEEDED" || resp.StatusCode >= http.StatusInternalServerError {
+			return domain.NewTemporaryError(err)
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(body.Result, out); err != nil {
+		return domain.NewErrorf("bitrix24 %s: unexpected result format", method)
+	}
+
+	return nil
+}
+
+func (c *Bitrix24Connector) endpoint(conn *models.Connection, method string) (string, error) {
+	if strings.HasPrefix(conn.AccessToken, "http") {
+		return strings.TrimRight(conn.AccessToken, "/") + "/" + method + ".json", nil
+	}
+
+	portal := metadataString(conn, "portal_url")
+	if portal == "" {
+		return "", domain.NewError("bitrix24 connection requires webhook URL or portal_url in metadata")
+	}
+
+	return strings.TrimRight(portal, "/") + "/rest/" + method + ".json", nil
+}
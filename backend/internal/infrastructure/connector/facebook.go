@@ -0,0 +1,236 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+const (
+	SystemFacebook = "facebook"
+
+	facebookGraphURL = "https://graph.facebook.com/v19.0"
+)
+
+type FacebookConnector struct {
+	client *http.Client
+	logger domain.Logger
+}
+
+func NewFacebookConnector(client *http.Client, logger domain.Logger) *FacebookConnector {
+	return &FacebookConnector{
+		client: client,
+		logger: logger,
+	}
+}
+
+func (c *FacebookConnector) SystemType() string {
+	return SystemFacebook
+}
+
+func (c *FacebookConnector) DefaultEntity() string {
+	return "lead"
+}
+
+type facebookQuestion struct {
+	Key     string `json:"key"`
+	Label   string `json:"label"`
+	Type    string `json:"type"`
+	Options []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"options"`
+}
+
+// GetFields - вопросы лид-формы. entity - "lead" (форма из metadata.form_id)
+// или непосредственно ID формы.
+func (c *FacebookConnector) GetFields(ctx context.Context, conn *models.Connection, entity string) ([]models.FieldDefinition, error) {
+	formID := entity
+	if entity == "" || entity == "lead" {
+		formID = metadataString(conn, "form_id")
+	}
+	if formID == "" {
+		return nil, domain.NewError("facebook connection requires form_id in metadata")
+	}
+
+	var form struct {
+		Questions []facebookQuestion `json:"questions"`
+	}
+	params := url.Values{"fields": {"questions"}}
+	if err := c.get(ctx, conn, formID, params, &form); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.FieldDefinition, 0, len(form.Questions))
+	for _, q := range form.Questions {
+		def := models.FieldDefinition{
+			Name:   q.Key,
+			Title:  q.Label,
+			Type:   strings.ToLower(q.Type),
+			Custom: strings.EqualFold(q.Type, "CUSTOM"),
+		}
+		if def.Title == "" {
+			def.Title = q.Key
+		}
+
+		for _, opt := range q.Options {
+			def.Options = append(def.Options, models.FieldOption{ID: opt.Key, Value: opt.Value})
+		}
+
+		result = append(result, def)
+	}
+
+	return result, nil
+}
+
+const (
+	facebookPageSize     = 100
+	facebookPollMaxPages = 20
+	// facebookTimeLayout - формат created_time Graph API
+	facebookTimeLayout = "2006-01-02T15:04:05-0700"
+)
+
+// PollRecords - лиды формы, созданные после позиции. Graph API отдает их от
+// новых к старым, поэтому читаются все страницы окна, а результат
+// разворачивается; позиция сдвигается на самый новый лид. Если окно не
+// читается за facebookPollMaxPages страниц, лиды не возвращаются, а в
+// PageToken позиции сохраняется время самого старого прочитанного лида:
+// следующий опрос читает окно только до него, пока оно не уложится в лимит.
+func (c *FacebookConnector) PollRecords(ctx context.Context, conn *models.Connection, entity string, cursor models.SyncCursor, until time.Time) ([]map[string]interface{}, models.SyncCursor, error) {
+	formID := entity
+	if entity == "" || entity == "lead" {
+		formID = metadataString(conn, "form_id")
+	}
+	if formID == "" {
+		return nil, cursor, domain.NewError("facebook connection requires form_id in metadata")
+	}
+
+	if sec, err := strconv.ParseInt(cursor.PageToken, 10, 64); err == nil {
+		if bound := time.Unix(sec, 0); until.IsZero() || bound.Before(until) {
+			until = bound
+		}
+	}
+
+	// Фильтр по секундам строгий, поэтому берется на секунду раньше,
+	// а лиды с временем позиции отсекаются по ID
+	filters := []map[string]interface{}{{
+		"field":    "time_created",
+		"operator": "GREATER_THAN",
+		"value":    cursor.ModifiedAt.Unix() - 1,
+	}}
+	if !until.IsZero() {
+		filters = append(filters, map[string]interface{}{
+			"field":    "time_created",
+			"operator": "LESS_THAN",
+			"value":    until.Unix() + 1,
+		})
+	}
+	filtering, _ := json.Marshal(filters)
+
+	leads := make([]map[string]interface{}, 0)
+	after := ""
+	for page := 1; ; page++ {
+		params := url.Values{
+			"fields":    {"id,created_time,ad_id,form_id,field_data"},
+			"filtering": {string(filtering)},
+			"limit":     {strconv.Itoa(facebookPageSize)},
+		}
+		if after != "" {
+			params.Set("after", after)
+		}
+
+		var result struct {
+			Data   []map[string]interface{} `json:"data"`
+			Paging struct {
+				Cursors struct {
+					After string `json:"after"`
+				} `json:"cursors"`
+				Next string `json:"next"`
+			} `json:"paging"`
+		}
+		if err := c.get(ctx, conn, formID+"/leads", params, &result); err != nil {
+			return nil, cursor, err
+		}
+		leads = append(leads, result.Data...)
+
+		if result.Paging.Next == "" || result.Paging.Cursors.After == "" {
+			break
+		}
+
+		// Если все страницы пришлись на одну секунду, сузить окно нельзя - оно дочитывается
+		if page >= facebookPollMaxPages && len(leads) > 0 {
+			oldest, _ := time.Parse(facebookTimeLayout, fmt.Sprint(leads[len(leads)-1]["created_time"]))
+			if oldest.After(cursor.ModifiedAt) && (until.IsZero() || oldest.Before(until)) {
+				c.logger.Info("Facebook: poll window is too large, narrowing it", "form_id", formID, "until", oldest, "connection_id", conn.ID)
+				cursor.PageToken = strconv.FormatInt(oldest.Unix(), 10)
+				return make([]map[string]interface{}, 0), cursor, nil
+			}
+		}
+		after = result.Paging.Cursors.After
+	}
+
+	next := models.SyncCursor{ModifiedAt: cursor.ModifiedAt, RecordID: cursor.RecordID}
+	records := make([]map[string]interface{}, 0, len(leads))
+	for i := len(leads) - 1; i >= 0; i-- {
+		created, _ := time.Parse(facebookTimeLayout, fmt.Sprint(leads[i]["created_time"]))
+		id := fmt.Sprint(leads[i]["id"])
+		if !cursorAfter(cursor, created, id) || (!until.IsZero() && created.After(until)) {
+			continue
+		}
+		records = append(records, leads[i])
+		next = models.SyncCursor{ModifiedAt: created, RecordID: id}
+	}
+
+	c.logger.Debug("Facebook: leads polled", "form_id", formID, "count", len(records), "connection_id", conn.ID)
+	return records, next, nil
+}
+
+func (c *FacebookConnector) get(ctx context.Context, conn *models.Connection, path string, params url.Values, out interface{}) error {
+	params.Set("access_token", conn.AccessToken)
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	endpoint := facebookGraphURL + "/" + strings.Join(segments, "/") + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Debug("Facebook: graph request", "path", path, "connection_id", conn.ID)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// url.Error содержит полный адрес вместе с access_token
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return domain.NewErrorf("facebook graph request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return domain.NewErrorf("facebook graph error (status %d): %s", resp.StatusCode, body.Error.Message)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return domain.NewError("facebook graph returned invalid response")
+	}
+
+	return nil
+}
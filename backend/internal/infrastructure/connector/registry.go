@@ -0,0 +1,62 @@
+package connector
+
+import (
+	"encoding/json"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+const requestTimeout = 15 * time.Second
+
+type Registry struct {
+	connectors map[string]domain.Connector
+}
+
+func NewRegistry(guard domain.URLGuard, samples domain.InboundSampleRepository, logger domain.Logger) *Registry {
+	client := guard.NewClient(requestTimeout)
+
+	return &Registry{
+		connectors: map[string]domain.Connector{
+			SystemBitrix24:       NewBitrix24Connector(client, logger),
+			SystemFacebook:       NewFacebookConnector(client, logger),
+			domain.SystemWebhook: NewWebhookConnector(samples, logger),
+		},
+	}
+}
+
+func (r *Registry) Get(systemType string) (domain.Connector, error) {
+	c, ok := r.connectors[systemType]
+	if !ok {
+		return nil, domain.NewErrorf("no connector for system type %q", systemType)
+	}
+	return c, nil
+}
+
+// metadataString - читает строковый параметр из Connection.Metadata
+func metadataString(conn *models.Connection, key string) string {
+	if len(conn.Metadata) == 0 {
+		return ""
+	}
+
+	var meta map[string]interface{}
+	if err := json.Unmarshal(conn.Metadata, &meta); err != nil {
+		return ""
+	}
+
+	value, _ := meta[key].(string)
+	return value
+}
+
+// cursorAfter - запись с временем modified и ID id идет после позиции опроса.
+// ID с одинаковым временем сравниваются как числа: сначала по длине.
+func cursorAfter(cursor models.SyncCursor, modified time.Time, id string) bool {
+	if !modified.Equal(cursor.ModifiedAt) {
+		return modified.After(cursor.ModifiedAt)
+	}
+	if len(id) != len(cursor.RecordID) {
+		return len(id) > len(cursor.RecordID)
+	}
+	return id > cursor.RecordID
+}
@@ -0,0 +1,115 @@
+package connector
+
+import (
+	"context"
+	"sort"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// webhookSampleLimit - сколько последних входящих запросов учитывается при выводе схемы
+const webhookSampleLimit = 20
+
+// WebhookConnector - источник, который сам присылает данные на входящий URL
+// подключения. Своего API у него нет, поэтому схема полей выводится из
+// последних принятых запросов.
+type WebhookConnector struct {
+	samples domain.InboundSampleRepository
+	logger  domain.Logger
+}
+
+func NewWebhookConnector(samples domain.InboundSampleRepository, logger domain.Logger) *WebhookConnector {
+	return &WebhookConnector{
+		samples: samples,
+		logger:  logger,
+	}
+}
+
+func (c *WebhookConnector) SystemType() string {
+	return domain.SystemWebhook
+}
+
+func (c *WebhookConnector) DefaultEntity() string {
+	return "payload"
+}
+
+// GetFields - объединение полей последних запросов. Вложенные объекты
+// раскрываются в пути через точку, как их понимает движок сопоставлений.
+func (c *WebhookConnector) GetFields(ctx context.Context, conn *models.Connection, entity string) ([]models.FieldDefinition, error) {
+	samples, err := c.samples.GetLatest(ctx, conn.ID, webhookSampleLimit)
+	if err != nil {
+		c.logger.Error("Webhook: failed to load inbound samples", err, "connection_id", conn.ID)
+		return nil, err
+	}
+
+	fields := make(map[string]*models.FieldDefinition)
+	for _, sample := range samples {
+		collectSampleFields(fields, "", sample.Payload)
+	}
+
+	result := make([]models.FieldDefinition, 0, len(fields))
+	for _, f := range fields {
+		if f.Type == "" {
+			f.Type = "string"
+		}
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+func collectSampleFields(fields map[string]*models.FieldDefinition, prefix string, payload map[string]interface{}) {
+	for key, value := range payload {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			collectSampleFields(fields, name, nested)
+			continue
+		}
+
+		fieldType, multiple := sampleValueType(value)
+		f, ok := fields[name]
+		if !ok {
+			fields[name] = &models.FieldDefinition{
+				Name:     name,
+				Title:    name,
+				Type:     fieldType,
+				Multiple: multiple,
+			}
+			continue
+		}
+
+		// Первый непустой тип выигрывает, расхождения сводим к строке
+		if f.Type == "" {
+			f.Type = fieldType
+		} else if fieldType != "" && f.Type != fieldType {
+			f.Type = "string"
+		}
+		f.Multiple = f.Multiple || multiple
+	}
+}
+
+func sampleValueType(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case bool:
+		return "boolean", false
+	case float64:
+		return "number", false
+	case []interface{}:
+		for _, item := range v {
+			if t, _ := sampleValueType(item); t != "" {
+				return t, true
+			}
+		}
+		return "string", true
+	default:
+		return "string", false
+	}
+}
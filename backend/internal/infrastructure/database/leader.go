@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"integration-app/internal/domain"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/fx"
+)
+
+const (
+	// leaderLockKey - ключ advisory-блокировки лидера; держит ее сессия лидера
+	leaderLockKey int64 = 7_260_001
+	// migrationLockKey - ключ блокировки на время применения миграций
+	migrationLockKey int64 = 7_260_002
+
+	leaderCheckInterval = 5 * time.Second
+	leaderQueryTimeout  = 3 * time.Second
+	applicationPrefix   = "integration-app:"
+)
+
+// LeaderElector - выбор лидера через pg_try_advisory_lock. Блокировка
+// принадлежит сессии отдельного соединения: если лидер падает или теряет
+// соединение, Postgres снимает ее, и лидером становится следующий
+// экземпляр, первым вызвавший pg_try_advisory_lock.
+type LeaderElector struct {
+	db       *bun.DB
+	instance string
+	logger   domain.Logger
+
+	mu     sync.RWMutex
+	conn   *bun.Conn
+	leader bool
+	since  time.Time
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewLeaderElector(lc fx.Lifecycle, db *bun.DB, logger domain.Logger) *LeaderElector {
+	e := &LeaderElector{
+		db:       db,
+		instance: instanceName(),
+		logger:   logger,
+		stop:     make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// Первая попытка сразу, чтобы фоновые задания лидера не ждали интервала
+			e.check(ctx)
+
+			e.wg.Add(1)
+			go e.loop()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(e.stop)
+			e.wg.Wait()
+			e.resign(ctx)
+			return nil
+		},
+	})
+
+	return e
+}
+
+// IsLeader - является ли этот экземпляр лидером
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// Status - роль экземпляра и лидер по данным pg_locks
+func (e *LeaderElector) Status(ctx context.Context) (*domain.LeaderStatus, error) {
+	e.mu.RLock()
+	status := &domain.LeaderStatus{
+		Instance: e.instance,
+		IsLeader: e.leader,
+	}
+	if e.leader {
+		status.Leader = e.instance
+		status.LeaderSince = e.since
+	}
+	e.mu.RUnlock()
+
+	if status.IsLeader {
+		return status, nil
+	}
+
+	leader, err := CurrentLeader(ctx, e.db)
+	if err != nil {
+		return nil, err
+	}
+	status.Leader = leader
+	return status, nil
+}
+
+// CurrentLeader - экземпляр, который держит блокировку лидера; пусто, если никто
+func CurrentLeader(ctx context.Context, db bun.IDB) (string, error) {
+	var name sql.NullString
+	err := db.QueryRowContext(ctx, `
+		SELECT a.application_name
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted
+		  AND l.classid = ? AND l.objid = ? AND l.objsubid = 1
+		LIMIT 1`,
+		uint32(leaderLockKey>>32), uint32(leaderLockKey),
+	).Scan(&name)
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	leader := name.String
+	if len(leader) > len(applicationPrefix) && leader[:len(applicationPrefix)] == applicationPrefix {
+		leader = leader[len(applicationPrefix):]
+	}
+	return leader, nil
+}
+
+func (e *LeaderElector) loop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.check(context.Background())
+		}
+	}
+}
+
+// check - лидер проверяет, что его соединение живо; остальные пытаются
+// захватить блокировку
+func (e *LeaderElector) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, leaderQueryTimeout)
+	defer cancel()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		conn, err := e.db.Conn(ctx)
+		if err != nil {
+			e.logger.Error("Leader election: failed to get connection", err)
+			return
+		}
+		// По application_name другие экземпляры видят, кто лидер
+		if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', ?, false)", applicationPrefix+e.instance); err != nil {
+			e.logger.Error("Leader election: failed to set application name", err)
+			conn.Close()
+			return
+		}
+		e.conn = &conn
+	}
+
+	if e.leader {
+		if err := e.conn.PingContext(ctx); err != nil {
+			e.logger.Error("Leader election: lost leader connection", err, "instance", e.instance)
+			e.dropConn()
+		}
+		return
+	}
+
+	var acquired bool
+	if err := e.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(?)", leaderLockKey).Scan(&acquired); err != nil {
+		e.logger.Error("Leader election: failed to try lock", err)
+		e.dropConn()
+		return
+	}
+
+	if acquired {
+		e.leader = true
+		e.since = time.Now()
+		e.logger.Info("Leader election: this instance is the leader", "instance", e.instance)
+	}
+}
+
+// dropConn - закрыть соединение блокировки; при закрытии Postgres снимает
+// блокировку, если она была у этой сессии
+func (e *LeaderElector) dropConn() {
+	if e.leader {
+		e.logger.Warn("Leader election: leadership lost", "instance", e.instance)
+	}
+	e.leader = false
+	e.since = time.Time{}
+
+	if e.conn != nil {
+		// ErrBadConn - соединение закрывается, а не возвращается в пул
+		e.conn.Raw(func(driverConn interface{}) error { return driver.ErrBadConn })
+		e.conn.Close()
+		e.conn = nil
+	}
+}
+
+// resign - снять блокировку при штатной остановке, чтобы другой экземпляр
+// стал лидером без ожидания
+func (e *LeaderElector) resign(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return
+	}
+	if e.leader {
+		if _, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock(?)", leaderLockKey); err != nil {
+			e.logger.Error("Leader election: failed to release lock", err)
+		}
+		e.logger.Info("Leader election: leadership released", "instance", e.instance)
+	}
+	e.dropConn()
+}
+
+// instanceName - имя экземпляра: хост (ID контейнера) и PID
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_webhooks_connection_id;
+DROP INDEX IF EXISTS idx_sync_logs_status;
+DROP INDEX IF EXISTS idx_sync_logs_created_at;
+DROP INDEX IF EXISTS idx_mappings_source;
+DROP INDEX IF EXISTS idx_connections_is_active;
+DROP TABLE IF EXISTS sync_logs;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS field_mappings;
+DROP TABLE IF EXISTS connections;
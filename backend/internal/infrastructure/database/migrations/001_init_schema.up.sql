@@ -1,4 +1,3 @@
--- +migrate Up
 CREATE TABLE IF NOT EXISTS connections (
     id SERIAL PRIMARY KEY,
     system_type VARCHAR(50) NOT NULL,
@@ -43,19 +42,8 @@ CREATE TABLE IF NOT EXISTS sync_logs (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
     );
 
-CREATE INDEX idx_connections_is_active ON connections(is_active);
-CREATE INDEX idx_mappings_source ON field_mappings(source_connection_id);
-CREATE INDEX idx_sync_logs_created_at ON sync_logs(created_at DESC);
-CREATE INDEX idx_sync_logs_status ON sync_logs(status);
-CREATE INDEX idx_webhooks_connection_id ON webhooks(connection_id);
-
--- +migrate Down
-DROP INDEX IF EXISTS idx_webhooks_connection_id;
-DROP INDEX IF EXISTS idx_sync_logs_status;
-DROP INDEX IF EXISTS idx_sync_logs_created_at;
-DROP INDEX IF EXISTS idx_mappings_source;
-DROP INDEX IF EXISTS idx_connections_is_active;
-DROP TABLE IF EXISTS sync_logs;
-DROP TABLE IF EXISTS webhooks;
-DROP TABLE IF EXISTS field_mappings;
-DROP TABLE IF EXISTS connections;
+CREATE INDEX IF NOT EXISTS idx_connections_is_active ON connections(is_active);
+CREATE INDEX IF NOT EXISTS idx_mappings_source ON field_mappings(source_connection_id);
+CREATE INDEX IF NOT EXISTS idx_sync_logs_created_at ON sync_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sync_logs_status ON sync_logs(status);
+CREATE INDEX IF NOT EXISTS idx_webhooks_connection_id ON webhooks(connection_id);
@@ -0,0 +1,2 @@
+ALTER TABLE connections DROP COLUMN IF EXISTS metadata;
+ALTER TABLE connections DROP COLUMN IF EXISTS bitrix24_webhook_secret;
@@ -0,0 +1,2 @@
+ALTER TABLE connections ADD COLUMN IF NOT EXISTS bitrix24_webhook_secret VARCHAR(255);
+ALTER TABLE connections ADD COLUMN IF NOT EXISTS metadata JSONB;
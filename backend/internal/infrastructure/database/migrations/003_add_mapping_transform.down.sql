@@ -0,0 +1 @@
+ALTER TABLE field_mappings DROP COLUMN IF EXISTS transform;
@@ -0,0 +1 @@
+ALTER TABLE field_mappings ADD COLUMN IF NOT EXISTS transform VARCHAR(255) NOT NULL DEFAULT '';
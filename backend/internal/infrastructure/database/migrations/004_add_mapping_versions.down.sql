@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_mapping_versions_active;
+DROP TABLE IF EXISTS mapping_versions;
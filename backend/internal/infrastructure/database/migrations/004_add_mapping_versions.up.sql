@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS mapping_versions (
+    id SERIAL PRIMARY KEY,
+    source_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    target_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    mappings JSONB NOT NULL,
+    diff JSONB,
+    author VARCHAR(255),
+    comment TEXT,
+    is_active BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_connection_id, target_connection_id, version)
+    );
+
+CREATE UNIQUE INDEX idx_mapping_versions_active
+    ON mapping_versions(source_connection_id, target_connection_id) WHERE is_active;
@@ -0,0 +1,5 @@
+ALTER TABLE webhooks DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS consecutive_failures;
+DROP INDEX IF EXISTS idx_webhook_deliveries_next_retry;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_id;
+DROP TABLE IF EXISTS webhook_deliveries;
@@ -0,0 +1,2 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS previous_secret_key VARCHAR(255);
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS previous_secret_expires_at TIMESTAMP;
@@ -0,0 +1,2 @@
+ALTER TABLE webhooks DROP COLUMN IF EXISTS headers;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS payload_format;
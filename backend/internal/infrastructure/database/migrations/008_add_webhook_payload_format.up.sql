@@ -0,0 +1,2 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS payload_format VARCHAR(50) NOT NULL DEFAULT 'json';
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS headers JSONB;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_connections_webhook_token;
+DROP INDEX IF EXISTS idx_inbound_samples_connection;
+DROP TABLE IF EXISTS inbound_samples;
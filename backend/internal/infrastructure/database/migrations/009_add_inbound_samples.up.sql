@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS inbound_samples (
+    id SERIAL PRIMARY KEY,
+    connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    content_type VARCHAR(100),
+    payload JSONB NOT NULL,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_inbound_samples_connection ON inbound_samples(connection_id, received_at DESC);
+CREATE UNIQUE INDEX idx_connections_webhook_token ON connections(access_token) WHERE system_type = 'webhook';
@@ -0,0 +1,2 @@
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS idempotency_key;
+DROP TABLE IF EXISTS inbound_events;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS inbound_events (
+    id SERIAL PRIMARY KEY,
+    connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(connection_id, idempotency_key)
+    );
+
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
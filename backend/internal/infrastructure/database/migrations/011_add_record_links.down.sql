@@ -0,0 +1,4 @@
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS target_record_id;
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS source_record_id;
+DROP INDEX IF EXISTS idx_record_links_target;
+DROP TABLE IF EXISTS record_links;
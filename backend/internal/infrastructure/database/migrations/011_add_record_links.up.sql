@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS record_links (
+    id SERIAL PRIMARY KEY,
+    source_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    source_record_id VARCHAR(255) NOT NULL,
+    target_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    target_record_id VARCHAR(255) NOT NULL,
+    entity_type VARCHAR(100) NOT NULL,
+    last_synced_hash VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_connection_id, source_record_id, target_connection_id, entity_type)
+    );
+
+CREATE INDEX idx_record_links_target ON record_links(target_connection_id, target_record_id);
+
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS source_record_id VARCHAR(255);
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS target_record_id VARCHAR(255);
@@ -0,0 +1,2 @@
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS action;
+DROP TABLE IF EXISTS sync_pairs;
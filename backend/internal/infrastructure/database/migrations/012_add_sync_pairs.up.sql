@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sync_pairs (
+    id SERIAL PRIMARY KEY,
+    source_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    target_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    match_fields JSONB,
+    on_match VARCHAR(20) NOT NULL DEFAULT 'update',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_connection_id, target_connection_id)
+    );
+
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS action VARCHAR(50);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_sync_conflicts_status;
+DROP TABLE IF EXISTS sync_conflicts;
+ALTER TABLE record_links DROP COLUMN IF EXISTS changed_at;
+ALTER TABLE record_links DROP COLUMN IF EXISTS synced_at;
+ALTER TABLE record_links DROP COLUMN IF EXISTS synced_from;
+ALTER TABLE record_links DROP COLUMN IF EXISTS synced_fields;
+ALTER TABLE record_links DROP COLUMN IF EXISTS synced_data;
+ALTER TABLE sync_pairs DROP COLUMN IF EXISTS field_policies;
+ALTER TABLE sync_pairs DROP COLUMN IF EXISTS conflict_policy;
+ALTER TABLE sync_pairs DROP COLUMN IF EXISTS bidirectional;
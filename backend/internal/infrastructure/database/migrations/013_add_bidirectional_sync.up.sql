@@ -0,0 +1,29 @@
+ALTER TABLE sync_pairs ADD COLUMN IF NOT EXISTS bidirectional BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE sync_pairs ADD COLUMN IF NOT EXISTS conflict_policy VARCHAR(20) NOT NULL DEFAULT 'last_writer_wins';
+ALTER TABLE sync_pairs ADD COLUMN IF NOT EXISTS field_policies JSONB;
+
+ALTER TABLE record_links ADD COLUMN IF NOT EXISTS synced_data JSONB;
+ALTER TABLE record_links ADD COLUMN IF NOT EXISTS synced_fields JSONB;
+ALTER TABLE record_links ADD COLUMN IF NOT EXISTS synced_from VARCHAR(10);
+ALTER TABLE record_links ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP;
+ALTER TABLE record_links ADD COLUMN IF NOT EXISTS changed_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS sync_conflicts (
+    id SERIAL PRIMARY KEY,
+    record_link_id INT REFERENCES record_links(id) ON DELETE CASCADE,
+    source_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    target_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    source_record_id VARCHAR(255) NOT NULL,
+    target_record_id VARCHAR(255) NOT NULL,
+    source_field VARCHAR(255),
+    target_field VARCHAR(255) NOT NULL,
+    source_value JSONB,
+    target_value JSONB,
+    policy VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    resolution VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+    );
+
+CREATE INDEX idx_sync_conflicts_status ON sync_conflicts(status, created_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS flow_step;
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS flow_id;
+DROP INDEX IF EXISTS idx_flows_trigger;
+DROP TABLE IF EXISTS flows;
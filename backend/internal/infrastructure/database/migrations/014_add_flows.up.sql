@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS flows (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    trigger_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    trigger_event_type VARCHAR(100) NOT NULL,
+    steps JSONB NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_flows_trigger ON flows(trigger_connection_id) WHERE is_active;
+
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS flow_id INT REFERENCES flows(id) ON DELETE SET NULL;
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS flow_step VARCHAR(100);
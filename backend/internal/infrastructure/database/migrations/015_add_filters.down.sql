@@ -0,0 +1,2 @@
+ALTER TABLE flows DROP COLUMN IF EXISTS trigger_filter;
+ALTER TABLE sync_pairs DROP COLUMN IF EXISTS filter;
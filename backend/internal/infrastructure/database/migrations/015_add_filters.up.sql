@@ -0,0 +1,2 @@
+ALTER TABLE sync_pairs ADD COLUMN IF NOT EXISTS filter TEXT;
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS trigger_filter TEXT;
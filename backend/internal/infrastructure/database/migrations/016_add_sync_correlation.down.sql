@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sync_logs_correlation;
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS attempts;
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS correlation_id;
@@ -0,0 +1,4 @@
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(64);
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_sync_logs_correlation ON sync_logs(correlation_id);
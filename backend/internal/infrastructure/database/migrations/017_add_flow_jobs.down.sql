@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_flow_jobs_record;
+DROP INDEX IF EXISTS idx_flow_jobs_due;
+DROP TABLE IF EXISTS flow_jobs;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS flow_jobs (
+    id SERIAL PRIMARY KEY,
+    flow_id INT REFERENCES flows(id) ON DELETE CASCADE,
+    step_id VARCHAR(100) NOT NULL,
+    trigger_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    event_type VARCHAR(100),
+    source_record_id VARCHAR(255),
+    idempotency_key VARCHAR(255),
+    correlation_id VARCHAR(64),
+    payload JSONB,
+    data JSONB,
+    run_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_flow_jobs_due ON flow_jobs(run_at) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_flow_jobs_record ON flow_jobs(trigger_connection_id, source_record_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    name VARCHAR(100) PRIMARY KEY,
+    last_run_at TIMESTAMP,
+    last_success_at TIMESTAMP,
+    last_status VARCHAR(20),
+    last_error TEXT,
+    last_duration_ms BIGINT NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sync_queue_pending;
+DROP TABLE IF EXISTS sync_queue;
+DROP TABLE IF EXISTS sync_checkpoints;
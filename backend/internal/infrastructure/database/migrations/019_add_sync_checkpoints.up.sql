@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS sync_checkpoints (
+    connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    entity VARCHAR(100) NOT NULL,
+    modified_at TIMESTAMPTZ,
+    record_id VARCHAR(255),
+    page_token TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (connection_id, entity)
+    );
+
+CREATE TABLE IF NOT EXISTS sync_queue (
+    id SERIAL PRIMARY KEY,
+    connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    entity VARCHAR(100) NOT NULL,
+    source_record_id VARCHAR(255),
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_sync_queue_pending ON sync_queue(id) WHERE status IN ('pending', 'running');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sync_backfill_items_pending;
+DROP TABLE IF EXISTS sync_backfill_items;
+DROP TABLE IF EXISTS sync_backfills;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS sync_backfills (
+    id SERIAL PRIMARY KEY,
+    source_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    target_connection_id INT REFERENCES connections(id) ON DELETE CASCADE,
+    entity VARCHAR(100) NOT NULL,
+    date_from TIMESTAMPTZ NOT NULL,
+    date_to TIMESTAMPTZ NOT NULL,
+    rate_per_minute INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    fetched BOOLEAN NOT NULL DEFAULT FALSE,
+    cursor_modified_at TIMESTAMPTZ,
+    cursor_record_id VARCHAR(255),
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    correlation_id VARCHAR(64) NOT NULL,
+    error TEXT,
+    created_by VARCHAR(255),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS sync_backfill_items (
+    id SERIAL PRIMARY KEY,
+    backfill_id INT REFERENCES sync_backfills(id) ON DELETE CASCADE,
+    source_record_id VARCHAR(255),
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    sync_log_id INT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_sync_backfill_items_pending ON sync_backfill_items(backfill_id, id) WHERE status = 'pending';
@@ -0,0 +1,7 @@
+ALTER TABLE flow_jobs DROP COLUMN IF EXISTS sync_id;
+ALTER TABLE sync_backfills DROP COLUMN IF EXISTS sync_id;
+ALTER TABLE sync_queue DROP COLUMN IF EXISTS sync_id;
+DROP INDEX IF EXISTS idx_sync_logs_sync_id;
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS sync_id;
+DROP INDEX IF EXISTS idx_sync_runs_started;
+DROP TABLE IF EXISTS sync_runs;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id SERIAL PRIMARY KEY,
+    trigger_type VARCHAR(20) NOT NULL,
+    source_connection_id INT REFERENCES connections(id) ON DELETE SET NULL,
+    target_connection_id INT REFERENCES connections(id) ON DELETE SET NULL,
+    flow_id INT REFERENCES flows(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    initiator VARCHAR(255),
+    processed INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    skipped INT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_started ON sync_runs(started_at);
+
+ALTER TABLE sync_logs ADD COLUMN IF NOT EXISTS sync_id INT REFERENCES sync_runs(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_sync_logs_sync_id ON sync_logs(sync_id);
+
+ALTER TABLE sync_queue ADD COLUMN IF NOT EXISTS sync_id INT REFERENCES sync_runs(id) ON DELETE SET NULL;
+ALTER TABLE sync_backfills ADD COLUMN IF NOT EXISTS sync_id INT REFERENCES sync_runs(id) ON DELETE SET NULL;
+ALTER TABLE flow_jobs ADD COLUMN IF NOT EXISTS sync_id INT REFERENCES sync_runs(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE sync_queue DROP COLUMN IF EXISTS next_retry_at;
+ALTER TABLE sync_queue DROP COLUMN IF EXISTS attempts;
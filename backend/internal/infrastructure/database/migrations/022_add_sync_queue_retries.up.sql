@@ -0,0 +1,2 @@
+ALTER TABLE sync_queue ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE sync_queue ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP;
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/uptrace/bun/migrate"
+)
+
+func TestMigrationsDiscovered(t *testing.T) {
+	files, err := fs.Glob(sqlFS, "migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations := migrate.NewMigrations()
+	if err := migrations.Discover(sqlFS); err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+
+	sorted := migrations.Sorted()
+	if len(sorted) == 0 || len(sorted) != len(files) {
+		t.Fatalf("discovered %d migrations, want %d", len(sorted), len(files))
+	}
+	if len(sorted) < 21 {
+		t.Fatalf("discovered %d migrations, want at least 21", len(sorted))
+	}
+
+	for _, m := range sorted {
+		if m.Up == nil || m.Down == nil {
+			t.Errorf("migration %s: up and down are both required", m.Name)
+		}
+	}
+}
+
+func TestMigrationFilesHaveNoDirectives(t *testing.T) {
+	entries, err := fs.ReadDir(sqlFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".up.sql") && !strings.HasSuffix(name, ".down.sql") {
+			t.Errorf("%s: bun only loads *.up.sql and *.down.sql", name)
+			continue
+		}
+
+		data, err := fs.ReadFile(sqlFS, "migrations/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Разметка sql-migrate не поддерживается: Down выполнился бы вместе с Up
+		if strings.Contains(string(data), "+migrate") {
+			t.Errorf("%s: contains a +migrate directive", name)
+		}
+	}
+}
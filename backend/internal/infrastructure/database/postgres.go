@@ -53,12 +53,31 @@ func RunMigrations(lc fx.Lifecycle, db *bun.DB, logger domain.Logger) error {
 		OnStart: func(ctx context.Context) error {
 			logger.Info("Starting database migrations")
 
+			// Реплики, стартующие одновременно, ждут друг друга: миграции
+			// применяет первая, остальные видят, что применять нечего
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return fmt.Errorf("migration lock connection error: %w", err)
+			}
+			defer conn.Close()
+
+			if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", migrationLockKey); err != nil {
+				logger.Error("Failed to acquire migration lock", err)
+				return fmt.Errorf("migration lock error: %w", err)
+			}
+			defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(?)", migrationLockKey)
+
 			migrations := migrate.NewMigrations()
 
 			if err := migrations.Discover(sqlFS); err != nil {
 				logger.Error("Failed to discover migrations", err)
 				return fmt.Errorf("migration discover error: %w", err)
 			}
+			// bun загружает только *.up.sql и *.down.sql: пустой список -
+			// ошибка в именах файлов, а не отсутствие миграций
+			if len(migrations.Sorted()) == 0 {
+				return fmt.Errorf("migration discover error: no *.up.sql files found")
+			}
 
 			migrator := migrate.NewMigrator(db, migrations)
 
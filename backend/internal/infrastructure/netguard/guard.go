@@ -0,0 +1,259 @@
+package netguard
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"integration-app/internal/domain"
+)
+
+const (
+	dialTimeout  = 10 * time.Second
+	maxRedirects = 5
+)
+
+// reservedNets - диапазоны, не покрытые методами netip.Addr, но не являющиеся
+// публичными адресами в интернете
+var reservedNets = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 может вести во внутреннюю сеть
+}
+
+// Guard - защита исходящих запросов от SSRF. Проверяет URL при сохранении
+// и каждый реальный IP при установке соединения, поэтому DNS rebinding
+// и редиректы на внутренние адреса тоже блокируются.
+type Guard struct {
+	allowedHosts map[string]bool
+	allowedNets  []netip.Prefix
+	allowedPorts map[int]bool
+	resolver     *net.Resolver
+}
+
+// NewGuard - allowlist содержит имена хостов, IP или CIDR, для которых
+// проверки адреса и порта не выполняются (внутренние on-prem системы)
+func NewGuard(allowlist []string, ports []int) *Guard {
+	g := &Guard{
+		allowedHosts: make(map[string]bool),
+		allowedPorts: make(map[int]bool),
+		resolver:     net.DefaultResolver,
+	}
+
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			g.allowedNets = append(g.allowedNets, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			g.allowedNets = append(g.allowedNets, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		g.allowedHosts[strings.TrimSuffix(entry, ".")] = true
+	}
+
+	for _, port := range ports {
+		g.allowedPorts[port] = true
+	}
+
+	return g
+}
+
+// ValidateURL - схема http/https, разрешенный порт и только публичные адреса хоста
+func (g *Guard) ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return domain.NewErrorf("invalid URL: %v", err)
+	}
+
+	if err := checkScheme(u); err != nil {
+		return err
+	}
+
+	if u.User != nil {
+		return domain.NewError("credentials in URL are not allowed")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return domain.NewError("URL host is required")
+	}
+
+	if g.isAllowedHost(host) {
+		return nil
+	}
+
+	port, err := urlPort(u)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := g.resolve(ctx, host)
+	if err != nil {
+		return domain.NewErrorf("cannot resolve host %q: %v", host, err)
+	}
+
+	for _, addr := range addrs {
+		if err := g.checkAddr(addr, port); err != nil {
+			return domain.NewErrorf("host %q is not allowed: %v", host, err)
+		}
+	}
+
+	return nil
+}
+
+// DialContext - как net.Dialer.DialContext, но отказывает в соединении
+// с запрещенными IP и портами уже после разрешения имени
+func (g *Guard) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.isAllowedHost(host) {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if err := g.checkAddr(addrPort.Addr(), int(addrPort.Port())); err != nil {
+				return domain.NewErrorf("connection to %s blocked: %v", address, err)
+			}
+			return nil
+		}
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
+
+// NewClient - HTTP-клиент, все соединения которого проходят через DialContext.
+// Прокси из окружения не используется: он обошел бы проверку адресов.
+func (g *Guard) NewClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = g.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return domain.NewErrorf("stopped after %d redirects", maxRedirects)
+			}
+			return checkScheme(req.URL)
+		},
+	}
+}
+
+func (g *Guard) checkAddr(addr netip.Addr, port int) error {
+	addr = addr.Unmap()
+
+	if g.isAllowedIP(addr) {
+		return nil
+	}
+
+	if !g.allowedPorts[port] {
+		return domain.NewErrorf("port %d is not allowed", port)
+	}
+
+	if reason := nonPublicReason(addr); reason != "" {
+		return domain.NewErrorf("%s address %s", reason, addr)
+	}
+
+	return nil
+}
+
+func (g *Guard) isAllowedHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if g.allowedHosts[host] {
+		return true
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.isAllowedIP(addr.Unmap())
+	}
+
+	return false
+}
+
+func (g *Guard) isAllowedIP(addr netip.Addr) bool {
+	for _, prefix := range g.allowedNets {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Guard) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	return g.resolver.LookupNetIP(ctx, "ip", host)
+}
+
+// nonPublicReason - почему адрес не считается публичным, или "" для публичного
+func nonPublicReason(addr netip.Addr) string {
+	switch {
+	case addr.IsUnspecified():
+		return "unspecified"
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.IsPrivate():
+		return "private"
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return "link-local"
+	case addr.IsMulticast(), addr.IsInterfaceLocalMulticast():
+		return "multicast"
+	}
+
+	for _, prefix := range reservedNets {
+		if prefix.Contains(addr) {
+			return "reserved"
+		}
+	}
+
+	return ""
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return domain.NewErrorf("URL scheme %q is not allowed, use http or https", u.Scheme)
+	}
+	return nil
+}
+
+func urlPort(u *url.URL) (int, error) {
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, domain.NewErrorf("invalid port %q", p)
+		}
+		return port, nil
+	}
+
+	if u.Scheme == "https" {
+		return 443, nil
+	}
+	return 80, nil
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"integration-app/internal/domain"
+)
+
+// User - передает имя пользователя из заголовка X-User в контекст запроса
+func User(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := r.Header.Get("X-User"); user != "" {
+			r = r.WithContext(domain.WithUser(r.Context(), user))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,7 +2,7 @@ package repository
 
 import (
 	"context"
-	"integration-app/internal/utils"
+	"time"
 
 	"integration-app/internal/domain/models"
 
@@ -17,13 +17,13 @@ func NewConnectionRepository(db *bun.DB) *ConnectionRepository {
 	return &ConnectionRepository{db: db}
 }
 
-func (r *ConnectionRepository) GetAll(ctx context.Context) ([]*models.Connection, error) {
+func (r *ConnectionRepository) GetAll(ctx context.Context) ([]models.Connection, error) {
 	var connections []models.Connection
 	err := r.db.NewSelect().Model(&connections).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return utils.ToConnectionPointers(connections), nil // ✅
+	return connections, nil
 }
 
 func (r *ConnectionRepository) GetByID(ctx context.Context, id int) (*models.Connection, error) {
@@ -38,6 +38,19 @@ func (r *ConnectionRepository) GetByID(ctx context.Context, id int) (*models.Con
 	return conn, nil
 }
 
+// GetByAccessToken - подключение по секретному токену (входящие вебхуки)
+func (r *ConnectionRepository) GetByAccessToken(ctx context.Context, systemType, token string) (*models.Connection, error) {
+	conn := &models.Connection{}
+	err := r.db.NewSelect().
+		Model(conn).
+		Where("system_type = ? AND access_token = ?", systemType, token).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
 func (r *ConnectionRepository) Create(ctx context.Context, conn *models.Connection) error {
 	_, err := r.db.NewInsert().
 		Model(conn).
@@ -45,6 +58,17 @@ func (r *ConnectionRepository) Create(ctx context.Context, conn *models.Connecti
 	return err
 }
 
+func (r *ConnectionRepository) Update(ctx context.Context, conn *models.Connection) error {
+	conn.UpdatedAt = time.Now()
+
+	_, err := r.db.NewUpdate().
+		Model(conn).
+		ExcludeColumn("created_at").
+		Where("id = ?", conn.ID).
+		Exec(ctx)
+	return err
+}
+
 func (r *ConnectionRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.db.NewDelete().
 		Model((*models.Connection)(nil)).
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type FlowJobRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewFlowJobRepository(db *bun.DB, logger domain.Logger) *FlowJobRepository {
+	return &FlowJobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *FlowJobRepository) GetByID(ctx context.Context, id int) (*models.FlowJob, error) {
+	r.logger.Debug("Getting flow job by id", "id", id)
+
+	job := &models.FlowJob{}
+	err := r.db.NewSelect().
+		Model(job).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get flow job", err, "id", id)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Find - последние отложенные шаги по фильтру
+func (r *FlowJobRepository) Find(ctx context.Context, filter models.FlowJobFilter) ([]models.FlowJob, error) {
+	var jobs []models.FlowJob
+	q := r.db.NewSelect().
+		Model(&jobs).
+		Order("run_at DESC").
+		Limit(100)
+
+	if filter.FlowID > 0 {
+		q = q.Where("flow_id = ?", filter.FlowID)
+	}
+	if filter.ConnectionID > 0 {
+		q = q.Where("trigger_connection_id = ?", filter.ConnectionID)
+	}
+	if filter.SourceRecordID != "" {
+		q = q.Where("source_record_id = ?", filter.SourceRecordID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		r.logger.Error("Failed to find flow jobs", err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *FlowJobRepository) Create(ctx context.Context, job *models.FlowJob) error {
+	r.logger.Debug("Creating flow job", "flow_id", job.FlowID, "step", job.StepID, "run_at", job.RunAt)
+
+	_, err := r.db.NewInsert().
+		Model(job).
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to create flow job", err, "flow_id", job.FlowID)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDue - забирает задания, время которых подошло, и задания, зависшие
+// в running дольше staleAfter (экземпляр упал во время выполнения).
+// Статус меняется в той же команде, поэтому каждое задание выполняет
+// только один экземпляр приложения.
+func (r *FlowJobRepository) ClaimDue(ctx context.Context, limit int, staleAfter time.Duration) ([]models.FlowJob, error) {
+	due := r.db.NewSelect().
+		Model((*models.FlowJob)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("status = 'pending' AND run_at <= current_timestamp").
+				WhereOr("status = 'running' AND updated_at < ?", time.Now().Add(-staleAfter))
+		}).
+		Order("run_at").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	var jobs []models.FlowJob
+	_, err := r.db.NewUpdate().
+		Model((*models.FlowJob)(nil)).
+		Set("status = 'running'").
+		Set("updated_at = current_timestamp").
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx, &jobs)
+
+	if err != nil {
+		r.logger.Error("Failed to claim flow jobs", err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Finish - итог выполнения задания
+func (r *FlowJobRepository) Finish(ctx context.Context, id int, status, errMsg string) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.FlowJob)(nil)).
+		Set("status = ?", status).
+		Set("error = ?", bun.NullZero(errMsg)).
+		Set("updated_at = current_timestamp").
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to finish flow job", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// Cancel - отменить ожидающее задание; false - задание уже выполняется или завершено
+func (r *FlowJobRepository) Cancel(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*models.FlowJob)(nil)).
+		Set("status = 'cancelled'").
+		Set("updated_at = current_timestamp").
+		Where("id = ? AND status = 'pending'", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to cancel flow job", err, "id", id)
+		return false, err
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// CancelByRecord - отменить ожидающие задания записи подключения-триггера,
+// всех потоков или одного (flowID > 0). Возвращает число отмененных.
+func (r *FlowJobRepository) CancelByRecord(ctx context.Context, connectionID int, recordID string, flowID int) (int, error) {
+	q := r.db.NewUpdate().
+		Model((*models.FlowJob)(nil)).
+		Set("status = 'cancelled'").
+		Set("updated_at = current_timestamp").
+		Where("trigger_connection_id = ? AND source_record_id = ?", connectionID, recordID).
+		Where("status = 'pending'")
+
+	if flowID > 0 {
+		q = q.Where("flow_id = ?", flowID)
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		r.logger.Error("Failed to cancel flow jobs", err, "connection_id", connectionID, "record_id", recordID)
+		return 0, err
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// CancelBySyncID - отменить ожидающие задания запуска синхронизации.
+// Возвращает число отмененных.
+func (r *FlowJobRepository) CancelBySyncID(ctx context.Context, syncID int) (int, error) {
+	res, err := r.db.NewUpdate().
+		Model((*models.FlowJob)(nil)).
+		Set("status = 'cancelled'").
+		Set("updated_at = current_timestamp").
+		Where("sync_id = ? AND status = 'pending'", syncID).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to cancel flow jobs", err, "sync_id", syncID)
+		return 0, err
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
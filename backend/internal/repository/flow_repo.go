@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type FlowRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewFlowRepository(db *bun.DB, logger domain.Logger) *FlowRepository {
+	return &FlowRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *FlowRepository) GetAll(ctx context.Context) ([]models.Flow, error) {
+	r.logger.Debug("Getting all flows")
+
+	var flows []models.Flow
+	err := r.db.NewSelect().
+		Model(&flows).
+		Order("id").
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get flows", err)
+		return nil, err
+	}
+
+	return flows, nil
+}
+
+func (r *FlowRepository) GetByID(ctx context.Context, id int) (*models.Flow, error) {
+	r.logger.Debug("Getting flow by id", "id", id)
+
+	flow := &models.Flow{}
+	err := r.db.NewSelect().
+		Model(flow).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get flow", err, "id", id)
+		return nil, err
+	}
+
+	return flow, nil
+}
+
+// GetActiveByTrigger - активные потоки, запускаемые событиями подключения
+func (r *FlowRepository) GetActiveByTrigger(ctx context.Context, connectionID int) ([]models.Flow, error) {
+	var flows []models.Flow
+	err := r.db.NewSelect().
+		Model(&flows).
+		Where("trigger_connection_id = ?", connectionID).
+		Where("is_active").
+		Order("id").
+		Scan(ctx)
+
+	return flows, err
+}
+
+func (r *FlowRepository) Create(ctx context.Context, flow *models.Flow) error {
+	r.logger.Debug("Creating flow", "name", flow.Name)
+
+	_, err := r.db.NewInsert().
+		Model(flow).
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to create flow", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FlowRepository) Update(ctx context.Context, flow *models.Flow) error {
+	r.logger.Debug("Updating flow", "id", flow.ID)
+
+	flow.UpdatedAt = time.Now()
+
+	_, err := r.db.NewUpdate().
+		Model(flow).
+		Column("name", "trigger_connection_id", "trigger_event_type", "trigger_filter", "steps", "is_active", "updated_at").
+		WherePK().
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to update flow", err, "id", flow.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FlowRepository) Delete(ctx context.Context, id int) error {
+	r.logger.Debug("Deleting flow", "id", id)
+
+	_, err := r.db.NewDelete().
+		Model((*models.Flow)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to delete flow", err, "id", id)
+		return err
+	}
+
+	return nil
+}
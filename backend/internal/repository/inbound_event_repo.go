@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type InboundEventRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewInboundEventRepository(db *bun.DB, logger domain.Logger) *InboundEventRepository {
+	return &InboundEventRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Register - атомарно регистрирует ключ события. Возвращает false, если ключ
+// уже встречался позже now-window (повторная доставка). Запись с более старым
+// ключом обновляется, и событие считается новым.
+func (r *InboundEventRepository) Register(ctx context.Context, event *models.InboundEvent, window time.Duration) (bool, error) {
+	now := time.Now()
+	event.ReceivedAt = now
+
+	res, err := r.db.NewInsert().
+		Model(event).
+		On("CONFLICT (connection_id, idempotency_key) DO UPDATE").
+		Set("received_at = EXCLUDED.received_at").
+		Where("inbound_event.received_at < ?", now.Add(-window)).
+		Returning("id").
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	// Без RETURNING-строки конфликт не обновил запись: ключ свежий, это повтор
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		r.logger.Debug("Duplicate inbound event", "connection_id", event.ConnectionID, "key", event.IdempotencyKey)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Release - снять регистрацию ключа, если событие не удалось обработать:
+// повторная доставка провайдера должна обработаться, а не стать duplicate
+func (r *InboundEventRepository) Release(ctx context.Context, connectionID int, idempotencyKey string) error {
+	_, err := r.db.NewDelete().
+		Model((*models.InboundEvent)(nil)).
+		Where("connection_id = ?", connectionID).
+		Where("idempotency_key = ?", idempotencyKey).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to release inbound event", err, "connection_id", connectionID, "key", idempotencyKey)
+		return err
+	}
+
+	return nil
+}
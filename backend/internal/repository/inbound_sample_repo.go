@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type InboundSampleRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewInboundSampleRepository(db *bun.DB, logger domain.Logger) *InboundSampleRepository {
+	return &InboundSampleRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *InboundSampleRepository) GetLatest(ctx context.Context, connectionID int, limit int) ([]models.InboundSample, error) {
+	r.logger.Debug("Getting inbound samples", "connection_id", connectionID)
+
+	samples := make([]models.InboundSample, 0)
+	err := r.db.NewSelect().
+		Model(&samples).
+		Where("connection_id = ?", connectionID).
+		Order("received_at DESC", "id DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	return samples, err
+}
+
+// Add - сохраняет пример и удаляет старые, оставляя keep последних
+func (r *InboundSampleRepository) Add(ctx context.Context, sample *models.InboundSample, keep int) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(sample).Exec(ctx); err != nil {
+			return err
+		}
+
+		latest := tx.NewSelect().
+			Model((*models.InboundSample)(nil)).
+			Column("id").
+			Where("connection_id = ?", sample.ConnectionID).
+			Order("received_at DESC", "id DESC").
+			Limit(keep)
+
+		_, err := tx.NewDelete().
+			Model((*models.InboundSample)(nil)).
+			Where("connection_id = ?", sample.ConnectionID).
+			Where("id NOT IN (?)", latest).
+			Exec(ctx)
+		return err
+	})
+}
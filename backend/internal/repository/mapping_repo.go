@@ -62,6 +62,21 @@ func (r *MappingRepository) GetByConnectionPair(ctx context.Context, sourceID, t
 	return mappings, err
 }
 
+// GetTargetIDs - подключения, в которые настроены сопоставления из источника
+func (r *MappingRepository) GetTargetIDs(ctx context.Context, sourceID int) ([]int, error) {
+	r.logger.Debug("Getting mapping targets", "source_id", sourceID)
+
+	var ids []int
+	err := r.db.NewSelect().
+		Model((*models.FieldMapping)(nil)).
+		ColumnExpr("DISTINCT target_connection_id").
+		Where("source_connection_id = ?", sourceID).
+		OrderExpr("target_connection_id").
+		Scan(ctx, &ids)
+
+	return ids, err
+}
+
 func (r *MappingRepository) GetByConnectionID(ctx context.Context, connectionID int) ([]*models.FieldMapping, error) {
 	var mappings []models.FieldMapping
 	err := r.db.NewSelect().
@@ -98,6 +113,7 @@ func (r *MappingRepository) Create(ctx context.Context, mapping *models.FieldMap
 		Model(mapping).
 		On("CONFLICT (source_connection_id, target_connection_id, source_field) DO UPDATE").
 		Set("target_field = EXCLUDED.target_field").
+		Set("transform = EXCLUDED.transform").
 		Exec(ctx)
 
 	if err != nil {
@@ -115,6 +131,7 @@ func (r *MappingRepository) CreateBatch(ctx context.Context, mappings []models.F
 		Model(&mappings).
 		On("CONFLICT (source_connection_id, target_connection_id, source_field) DO UPDATE").
 		Set("target_field = EXCLUDED.target_field").
+		Set("transform = EXCLUDED.transform").
 		Exec(ctx)
 
 	if err != nil {
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type MappingVersionRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewMappingVersionRepository(db *bun.DB, logger domain.Logger) *MappingVersionRepository {
+	return &MappingVersionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *MappingVersionRepository) GetByConnectionPair(ctx context.Context, sourceID, targetID int) ([]models.MappingVersion, error) {
+	r.logger.Debug("Getting mapping versions by connection pair", "source_id", sourceID, "target_id", targetID)
+
+	var versions []models.MappingVersion
+	err := r.db.NewSelect().
+		Model(&versions).
+		Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+		Order("version DESC").
+		Scan(ctx)
+
+	return versions, err
+}
+
+func (r *MappingVersionRepository) GetByVersion(ctx context.Context, sourceID, targetID, version int) (*models.MappingVersion, error) {
+	r.logger.Debug("Getting mapping version", "source_id", sourceID, "target_id", targetID, "version", version)
+
+	v := &models.MappingVersion{}
+	err := r.db.NewSelect().
+		Model(v).
+		Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+		Where("version = ?", version).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// GetActive - активная версия пары или nil, если версий еще нет
+func (r *MappingVersionRepository) GetActive(ctx context.Context, sourceID, targetID int) (*models.MappingVersion, error) {
+	v := &models.MappingVersion{}
+	err := r.db.NewSelect().
+		Model(v).
+		Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+		Where("is_active").
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Activate - в одной транзакции под блокировкой пары читает текущие
+// сопоставления и активную версию, строит по ним новую версию, присваивает
+// ей номер, делает активной и приводит строки field_mappings пары к ее
+// содержимому. Если build вернул nil, возвращается активная версия и false.
+func (r *MappingVersionRepository) Activate(ctx context.Context, sourceID, targetID int, build domain.MappingVersionBuilder) (*models.MappingVersion, bool, error) {
+	r.logger.Debug("Activating mapping version", "source_id", sourceID, "target_id", targetID)
+
+	var version *models.MappingVersion
+	created := false
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// Сериализуем сохранения одной пары: и номер версии, и ее diff
+		// считаются от состояния, которое никто не изменит до коммита
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, ?)", sourceID, targetID); err != nil {
+			return err
+		}
+
+		var current []models.FieldMapping
+		err := tx.NewSelect().
+			Model(&current).
+			Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		active := &models.MappingVersion{}
+		err = tx.NewSelect().
+			Model(active).
+			Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+			Where("is_active").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			active = nil
+		} else if err != nil {
+			return err
+		}
+
+		next, mappings, err := build(current, active)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			version = active
+			return nil
+		}
+		version = next
+		version.SourceConnectionID = sourceID
+		version.TargetConnectionID = targetID
+
+		err = tx.NewSelect().
+			Model((*models.MappingVersion)(nil)).
+			ColumnExpr("COALESCE(MAX(version), 0) + 1").
+			Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+			Scan(ctx, &version.Version)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.MappingVersion)(nil)).
+			Set("is_active = FALSE").
+			Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+			Where("is_active").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		version.IsActive = true
+		if _, err := tx.NewInsert().Model(version).Exec(ctx); err != nil {
+			return err
+		}
+		created = true
+
+		// Удаляем только исчезнувшие поля, остальные обновляем на месте,
+		// чтобы ID неизменившихся сопоставлений сохранялись между версиями
+		keep := make([]string, 0, len(mappings))
+		for _, m := range mappings {
+			keep = append(keep, m.SourceField)
+		}
+
+		del := tx.NewDelete().
+			Model((*models.FieldMapping)(nil)).
+			Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID)
+		if len(keep) > 0 {
+			del = del.Where("source_field NOT IN (?)", bun.In(keep))
+		}
+		if _, err := del.Exec(ctx); err != nil {
+			return err
+		}
+
+		if len(mappings) == 0 {
+			return nil
+		}
+
+		_, err = tx.NewInsert().
+			Model(&mappings).
+			On("CONFLICT (source_connection_id, target_connection_id, source_field) DO UPDATE").
+			Set("target_field = EXCLUDED.target_field").
+			Set("transform = EXCLUDED.transform").
+			Exec(ctx)
+		return err
+	})
+
+	if err != nil {
+		r.logger.Error("Failed to activate mapping version", err, "source_id", sourceID, "target_id", targetID)
+		return nil, false, err
+	}
+
+	return version, created, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type RecordLinkRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewRecordLinkRepository(db *bun.DB, logger domain.Logger) *RecordLinkRepository {
+	return &RecordLinkRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *RecordLinkRepository) GetByID(ctx context.Context, id int) (*models.RecordLink, error) {
+	r.logger.Debug("Getting record link by id", "id", id)
+
+	link := &models.RecordLink{}
+	err := r.db.NewSelect().
+		Model(link).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get record link", err, "id", id)
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// Find - связь записи источника с целью или nil, если запись еще не синхронизировалась
+func (r *RecordLinkRepository) Find(ctx context.Context, sourceID int, sourceRecordID string, targetID int, entity string) (*models.RecordLink, error) {
+	link := &models.RecordLink{}
+	err := r.db.NewSelect().
+		Model(link).
+		Where("source_connection_id = ? AND source_record_id = ?", sourceID, sourceRecordID).
+		Where("target_connection_id = ? AND entity_type = ?", targetID, entity).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// FindByTarget - связь записи цели с записью источника пары или nil
+func (r *RecordLinkRepository) FindByTarget(ctx context.Context, sourceID, targetID int, targetRecordID, entity string) (*models.RecordLink, error) {
+	link := &models.RecordLink{}
+	err := r.db.NewSelect().
+		Model(link).
+		Where("source_connection_id = ?", sourceID).
+		Where("target_connection_id = ? AND target_record_id = ?", targetID, targetRecordID).
+		Where("entity_type = ?", entity).
+		Order("updated_at DESC").
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// GetByRecord - связи, в которых запись подключения выступает источником или целью
+func (r *RecordLinkRepository) GetByRecord(ctx context.Context, connectionID int, recordID string) ([]models.RecordLink, error) {
+	r.logger.Debug("Getting record links by record", "connection_id", connectionID, "record_id", recordID)
+
+	var links []models.RecordLink
+	err := r.db.NewSelect().
+		Model(&links).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("source_connection_id = ? AND source_record_id = ?", connectionID, recordID).
+				WhereOr("target_connection_id = ? AND target_record_id = ?", connectionID, recordID)
+		}).
+		Order("updated_at DESC").
+		Scan(ctx)
+
+	return links, err
+}
+
+// Save - создать связь или обновить существующую для той же записи источника и цели
+func (r *RecordLinkRepository) Save(ctx context.Context, link *models.RecordLink) error {
+	r.logger.Debug("Saving record link", "source_id", link.SourceConnectionID, "source_record_id", link.SourceRecordID, "target_id", link.TargetConnectionID)
+
+	link.UpdatedAt = time.Now()
+
+	_, err := r.db.NewInsert().
+		Model(link).
+		On("CONFLICT (source_connection_id, source_record_id, target_connection_id, entity_type) DO UPDATE").
+		Set("target_record_id = EXCLUDED.target_record_id").
+		Set("last_synced_hash = EXCLUDED.last_synced_hash").
+		Set("synced_data = EXCLUDED.synced_data").
+		Set("synced_fields = EXCLUDED.synced_fields").
+		Set("synced_from = EXCLUDED.synced_from").
+		Set("synced_at = EXCLUDED.synced_at").
+		Set("changed_at = EXCLUDED.changed_at").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to save record link", err)
+		return err
+	}
+
+	return nil
+}
+
+// Update - ручное исправление связи: ID записи в цели и хэш данных
+func (r *RecordLinkRepository) Update(ctx context.Context, link *models.RecordLink) error {
+	r.logger.Debug("Updating record link", "id", link.ID)
+
+	link.UpdatedAt = time.Now()
+
+	_, err := r.db.NewUpdate().
+		Model(link).
+		Column("target_record_id", "last_synced_hash", "updated_at").
+		WherePK().
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to update record link", err, "id", link.ID)
+		return err
+	}
+
+	return nil
+}
+
+// SaveSyncState - состояние связи после синхронизации двусторонней пары
+func (r *RecordLinkRepository) SaveSyncState(ctx context.Context, link *models.RecordLink) error {
+	link.UpdatedAt = time.Now()
+
+	_, err := r.db.NewUpdate().
+		Model(link).
+		Column("synced_data", "synced_fields", "synced_from", "synced_at", "changed_at", "updated_at").
+		WherePK().
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to save record link state", err, "id", link.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *RecordLinkRepository) Delete(ctx context.Context, id int) error {
+	r.logger.Debug("Deleting record link", "id", id)
+
+	_, err := r.db.NewDelete().
+		Model((*models.RecordLink)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to delete record link", err, "id", id)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type ScheduleRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewScheduleRepository(db *bun.DB, logger domain.Logger) *ScheduleRepository {
+	return &ScheduleRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *ScheduleRepository) GetAll(ctx context.Context) ([]models.ScheduleState, error) {
+	var states []models.ScheduleState
+	err := r.db.NewSelect().
+		Model(&states).
+		Order("name").
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get schedules", err)
+		return nil, err
+	}
+
+	return states, nil
+}
+
+// Save - создать или заменить состояние задания
+func (r *ScheduleRepository) Save(ctx context.Context, state *models.ScheduleState) error {
+	r.logger.Debug("Saving schedule state", "name", state.Name, "status", state.LastStatus)
+
+	state.UpdatedAt = time.Now()
+
+	_, err := r.db.NewInsert().
+		Model(state).
+		On("CONFLICT (name) DO UPDATE").
+		Set("last_run_at = EXCLUDED.last_run_at").
+		Set("last_success_at = EXCLUDED.last_success_at").
+		Set("last_status = EXCLUDED.last_status").
+		Set("last_error = EXCLUDED.last_error").
+		Set("last_duration_ms = EXCLUDED.last_duration_ms").
+		Set("next_run_at = EXCLUDED.next_run_at").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to save schedule state", err, "name", state.Name)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type SyncBackfillRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewSyncBackfillRepository(db *bun.DB, logger domain.Logger) *SyncBackfillRepository {
+	return &SyncBackfillRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetAll - последние выгрузки, новые первыми
+func (r *SyncBackfillRepository) GetAll(ctx context.Context) ([]models.SyncBackfill, error) {
+	var backfills []models.SyncBackfill
+	err := r.db.NewSelect().
+		Model(&backfills).
+		Order("id DESC").
+		Limit(100).
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get sync backfills", err)
+		return nil, err
+	}
+
+	return backfills, nil
+}
+
+// GetByID - выгрузка или nil, если ее нет
+func (r *SyncBackfillRepository) GetByID(ctx context.Context, id int) (*models.SyncBackfill, error) {
+	backfill := &models.SyncBackfill{}
+	err := r.db.NewSelect().
+		Model(backfill).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get sync backfill", err, "id", id)
+		return nil, err
+	}
+
+	return backfill, nil
+}
+
+// GetActive - выгрузки, ожидающие запуска или прерванные во время работы
+func (r *SyncBackfillRepository) GetActive(ctx context.Context) ([]models.SyncBackfill, error) {
+	var backfills []models.SyncBackfill
+	err := r.db.NewSelect().
+		Model(&backfills).
+		Where("status IN ('pending', 'running')").
+		Order("id").
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get active sync backfills", err)
+		return nil, err
+	}
+
+	return backfills, nil
+}
+
+func (r *SyncBackfillRepository) Create(ctx context.Context, backfill *models.SyncBackfill) error {
+	r.logger.Debug("Creating sync backfill", "source_id", backfill.SourceConnectionID, "target_id", backfill.TargetConnectionID)
+
+	_, err := r.db.NewInsert().
+		Model(backfill).
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to create sync backfill", err, "source_id", backfill.SourceConnectionID)
+		return err
+	}
+
+	return nil
+}
+
+// Start - перевести выгрузку в running; false - ее успели приостановить
+func (r *SyncBackfillRepository) Start(ctx context.Context, backfill *models.SyncBackfill) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model(backfill).
+		Set("status = 'running'").
+		Set("started_at = COALESCE(started_at, current_timestamp)").
+		Set("updated_at = current_timestamp").
+		Where("id = ? AND status IN ('pending', 'running')", backfill.ID).
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to start sync backfill", err, "id", backfill.ID)
+		return false, err
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SetStatus - сменить статус выгрузки, если текущий входит в from.
+// false - выгрузка в другом статусе.
+func (r *SyncBackfillRepository) SetStatus(ctx context.Context, id int, status string, from ...string) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*models.SyncBackfill)(nil)).
+		Set("status = ?", status).
+		Set("error = NULL").
+		Set("finished_at = NULL").
+		Set("updated_at = current_timestamp").
+		Where("id = ? AND status IN (?)", id, bun.In(from)).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to set sync backfill status", err, "id", id, "status", status)
+		return false, err
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// AddItems - сохранить забранные записи и сдвинуть позицию чтения в одной
+// транзакции. Актуальные статус и счетчики возвращаются в backfill.
+func (r *SyncBackfillRepository) AddItems(ctx context.Context, backfill *models.SyncBackfill, items []models.SyncBackfillItem, cursor models.SyncCursor, fetched bool) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(items) > 0 {
+			if _, err := tx.NewInsert().Model(&items).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.NewUpdate().
+			Model(backfill).
+			Set("cursor_modified_at = ?", bun.NullZero(cursor.ModifiedAt)).
+			Set("cursor_record_id = ?", bun.NullZero(cursor.RecordID)).
+			Set("total = total + ?", len(items)).
+			Set("fetched = ?", fetched).
+			Set("updated_at = current_timestamp").
+			WherePK().
+			Returning("*").
+			Exec(ctx)
+		return err
+	})
+
+	if err != nil {
+		r.logger.Error("Failed to add sync backfill items", err, "id", backfill.ID, "records", len(items))
+		return err
+	}
+
+	return nil
+}
+
+// NextItems - несинхронизированные записи выгрузки в порядке чтения
+func (r *SyncBackfillRepository) NextItems(ctx context.Context, backfillID, limit int) ([]models.SyncBackfillItem, error) {
+	var items []models.SyncBackfillItem
+	err := r.db.NewSelect().
+		Model(&items).
+		Where("backfill_id = ? AND status = 'pending'", backfillID).
+		Order("id").
+		Limit(limit).
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get sync backfill items", err, "id", backfillID)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FinishItem - итог синхронизации записи и счетчик выгрузки в одной
+// транзакции. Актуальные статус и счетчики возвращаются в backfill.
+func (r *SyncBackfillRepository) FinishItem(ctx context.Context, backfill *models.SyncBackfill, item *models.SyncBackfillItem) error {
+	counter := "processed = processed + 1"
+	if item.Status == "failed" {
+		counter = "failed = failed + 1"
+	}
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		item.UpdatedAt = time.Now()
+		_, err := tx.NewUpdate().
+			Model(item).
+			Column("status", "error", "sync_log_id", "updated_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model(backfill).
+			Set(counter).
+			Set("updated_at = current_timestamp").
+			WherePK().
+			Returning("*").
+			Exec(ctx)
+		return err
+	})
+
+	if err != nil {
+		r.logger.Error("Failed to finish sync backfill item", err, "id", backfill.ID, "item_id", item.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Finish - завершить работающую выгрузку. Приостановленная за это время
+// выгрузка не меняется.
+func (r *SyncBackfillRepository) Finish(ctx context.Context, id int, status, errMsg string) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.SyncBackfill)(nil)).
+		Set("status = ?", status).
+		Set("error = ?", bun.NullZero(errMsg)).
+		Set("finished_at = current_timestamp").
+		Set("updated_at = current_timestamp").
+		Where("id = ? AND status = 'running'", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to finish sync backfill", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// CancelBySyncID - отменить незавершенную выгрузку запуска. Работающая
+// выгрузка остановится после текущей записи.
+func (r *SyncBackfillRepository) CancelBySyncID(ctx context.Context, syncID int) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*models.SyncBackfill)(nil)).
+		Set("status = 'cancelled'").
+		Set("finished_at = current_timestamp").
+		Set("updated_at = current_timestamp").
+		Where("sync_id = ? AND status IN ('pending', 'running', 'paused')", syncID).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to cancel sync backfill", err, "sync_id", syncID)
+		return false, err
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
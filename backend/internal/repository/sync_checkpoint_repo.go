@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type SyncCheckpointRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewSyncCheckpointRepository(db *bun.DB, logger domain.Logger) *SyncCheckpointRepository {
+	return &SyncCheckpointRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *SyncCheckpointRepository) GetAll(ctx context.Context) ([]models.SyncCheckpoint, error) {
+	var checkpoints []models.SyncCheckpoint
+	err := r.db.NewSelect().
+		Model(&checkpoints).
+		Order("connection_id", "entity").
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get sync checkpoints", err)
+		return nil, err
+	}
+
+	return checkpoints, nil
+}
+
+// Get - позиция опроса или nil, если сущность еще не опрашивалась
+func (r *SyncCheckpointRepository) Get(ctx context.Context, connectionID int, entity string) (*models.SyncCheckpoint, error) {
+	checkpoint := &models.SyncCheckpoint{}
+	err := r.db.NewSelect().
+		Model(checkpoint).
+		Where("connection_id = ? AND entity = ?", connectionID, entity).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get sync checkpoint", err, "connection_id", connectionID, "entity", entity)
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// Advance - поставить записи в очередь и сдвинуть позицию в одной транзакции:
+// либо записи в очереди и позиция за ними, либо ни того, ни другого
+func (r *SyncCheckpointRepository) Advance(ctx context.Context, checkpoint *models.SyncCheckpoint, items []models.SyncQueueItem) error {
+	r.logger.Debug("Advancing sync checkpoint", "connection_id", checkpoint.ConnectionID, "entity", checkpoint.Entity, "records", len(items))
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(items) > 0 {
+			if _, err := tx.NewInsert().Model(&items).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return r.save(ctx, tx, checkpoint)
+	})
+
+	if err != nil {
+		r.logger.Error("Failed to advance sync checkpoint", err, "connection_id", checkpoint.ConnectionID, "entity", checkpoint.Entity)
+		return err
+	}
+
+	return nil
+}
+
+// Reset - заменить позицию; следующий опрос начнется с нее
+func (r *SyncCheckpointRepository) Reset(ctx context.Context, checkpoint *models.SyncCheckpoint) error {
+	if err := r.save(ctx, r.db, checkpoint); err != nil {
+		r.logger.Error("Failed to reset sync checkpoint", err, "connection_id", checkpoint.ConnectionID, "entity", checkpoint.Entity)
+		return err
+	}
+	return nil
+}
+
+func (r *SyncCheckpointRepository) save(ctx context.Context, db bun.IDB, checkpoint *models.SyncCheckpoint) error {
+	checkpoint.UpdatedAt = time.Now()
+
+	_, err := db.NewInsert().
+		Model(checkpoint).
+		On("CONFLICT (connection_id, entity) DO UPDATE").
+		Set("modified_at = EXCLUDED.modified_at").
+		Set("record_id = EXCLUDED.record_id").
+		Set("page_token = EXCLUDED.page_token").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
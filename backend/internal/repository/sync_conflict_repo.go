@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type SyncConflictRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewSyncConflictRepository(db *bun.DB, logger domain.Logger) *SyncConflictRepository {
+	return &SyncConflictRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *SyncConflictRepository) GetByID(ctx context.Context, id int) (*models.SyncConflict, error) {
+	r.logger.Debug("Getting sync conflict by id", "id", id)
+
+	conflict := &models.SyncConflict{}
+	err := r.db.NewSelect().
+		Model(conflict).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to get sync conflict", err, "id", id)
+		return nil, err
+	}
+
+	return conflict, nil
+}
+
+// GetByStatus - последние конфликты; пустой статус - все
+func (r *SyncConflictRepository) GetByStatus(ctx context.Context, status string) ([]models.SyncConflict, error) {
+	r.logger.Debug("Getting sync conflicts", "status", status)
+
+	var conflicts []models.SyncConflict
+	q := r.db.NewSelect().
+		Model(&conflicts).
+		Order("created_at DESC").
+		Limit(100)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	err := q.Scan(ctx)
+	return conflicts, err
+}
+
+func (r *SyncConflictRepository) Create(ctx context.Context, conflict *models.SyncConflict) error {
+	r.logger.Debug("Creating sync conflict", "link_id", conflict.RecordLinkID, "field", conflict.TargetField)
+
+	_, err := r.db.NewInsert().
+		Model(conflict).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to create sync conflict", err)
+		return err
+	}
+
+	return nil
+}
+
+// Resolve - закрыть открытый конфликт. Уже решенный конфликт не меняется.
+func (r *SyncConflictRepository) Resolve(ctx context.Context, id int, resolution string) (*models.SyncConflict, error) {
+	conflict := &models.SyncConflict{}
+	res, err := r.db.NewUpdate().
+		Model(conflict).
+		Set("status = ?", "resolved").
+		Set("resolution = ?", resolution).
+		Set("resolved_at = ?", time.Now()).
+		Where("id = ? AND status = ?", id, "open").
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to resolve sync conflict", err, "id", id)
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return nil, domain.NewErrorf("conflict %d is not open", id)
+	}
+
+	return conflict, nil
+}
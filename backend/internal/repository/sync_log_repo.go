@@ -2,7 +2,6 @@ package repository
 
 import (
 	"context"
-	"integration-app/internal/utils"
 	"time"
 
 	"integration-app/internal/domain"
@@ -72,6 +71,20 @@ func (r *SyncLogRepository) GetByConnectionPair(ctx context.Context, sourceID, t
 	return logs, err
 }
 
+// GetByCorrelationID - все записи журнала одного входящего события в порядке создания
+func (r *SyncLogRepository) GetByCorrelationID(ctx context.Context, correlationID string) ([]models.SyncLog, error) {
+	r.logger.Debug("Getting sync logs by correlation id", "correlation_id", correlationID)
+
+	var logs []models.SyncLog
+	err := r.db.NewSelect().
+		Model(&logs).
+		Where("correlation_id = ?", correlationID).
+		Order("created_at", "id").
+		Scan(ctx)
+
+	return logs, err
+}
+
 func (r *SyncLogRepository) GetByStatus(ctx context.Context, status string) ([]models.SyncLog, error) {
 	r.logger.Debug("Getting sync logs by status", "status", status)
 
@@ -160,18 +173,50 @@ func (r *SyncLogRepository) DeleteOldLogs(ctx context.Context, olderThanDays int
 	return nil
 }
 
-func (r *SyncLogRepository) GetBySyncID(ctx context.Context, syncID int) ([]*models.SyncLog, error) {
+// CountBySource - число записей журнала с since по подключению-источнику и статусу
+func (r *SyncLogRepository) CountBySource(ctx context.Context, since time.Time) (map[int]map[string]int, error) {
+	var rows []struct {
+		SourceConnectionID int
+		Status             string
+		Count              int
+	}
+	err := r.db.NewSelect().
+		Model((*models.SyncLog)(nil)).
+		Column("source_connection_id", "status").
+		ColumnExpr("COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("source_connection_id", "status").
+		Scan(ctx, &rows)
+
+	if err != nil {
+		r.logger.Error("Failed to count sync logs", err)
+		return nil, err
+	}
+
+	counts := make(map[int]map[string]int)
+	for _, row := range rows {
+		if counts[row.SourceConnectionID] == nil {
+			counts[row.SourceConnectionID] = make(map[string]int)
+		}
+		counts[row.SourceConnectionID][row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// GetBySyncID - записи журнала запуска синхронизации в порядке создания
+func (r *SyncLogRepository) GetBySyncID(ctx context.Context, syncID int) ([]models.SyncLog, error) {
+	r.logger.Debug("Getting sync logs by sync run", "sync_id", syncID)
+
 	var logs []models.SyncLog
 	err := r.db.NewSelect().
 		Model(&logs).
 		Where("sync_id = ?", syncID).
-		Order("created_at DESC").
+		Order("created_at", "id").
 		Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return utils.ToSyncLogPointers(logs), nil // ✅
+
+	return logs, err
 }
+
 func (r *SyncLogRepository) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	r.logger.Debug("Getting sync logs statistics")
 
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type SyncPairRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewSyncPairRepository(db *bun.DB, logger domain.Logger) *SyncPairRepository {
+	return &SyncPairRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Get - настройки пары или nil, если пара настроек не имеет
+func (r *SyncPairRepository) Get(ctx context.Context, sourceID, targetID int) (*models.SyncPair, error) {
+	pair := &models.SyncPair{}
+	err := r.db.NewSelect().
+		Model(pair).
+		Where("source_connection_id = ? AND target_connection_id = ?", sourceID, targetID).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get sync pair", err, "source_id", sourceID, "target_id", targetID)
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// GetReverse - двусторонние пары, в которых подключение выступает целью
+func (r *SyncPairRepository) GetReverse(ctx context.Context, targetID int) ([]models.SyncPair, error) {
+	var pairs []models.SyncPair
+	err := r.db.NewSelect().
+		Model(&pairs).
+		Where("target_connection_id = ?", targetID).
+		Where("bidirectional").
+		Scan(ctx)
+
+	return pairs, err
+}
+
+// Save - создать или заменить настройки пары
+func (r *SyncPairRepository) Save(ctx context.Context, pair *models.SyncPair) error {
+	r.logger.Debug("Saving sync pair", "source_id", pair.SourceConnectionID, "target_id", pair.TargetConnectionID)
+
+	pair.UpdatedAt = time.Now()
+
+	_, err := r.db.NewInsert().
+		Model(pair).
+		On("CONFLICT (source_connection_id, target_connection_id) DO UPDATE").
+		Set("match_fields = EXCLUDED.match_fields").
+		Set("on_match = EXCLUDED.on_match").
+		Set("bidirectional = EXCLUDED.bidirectional").
+		Set("conflict_policy = EXCLUDED.conflict_policy").
+		Set("field_policies = EXCLUDED.field_policies").
+		Set("filter = EXCLUDED.filter").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to save sync pair", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type SyncQueueRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewSyncQueueRepository(db *bun.DB, logger domain.Logger) *SyncQueueRepository {
+	return &SyncQueueRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ClaimDue - забирает ожидающие записи в порядке постановки (отложенные
+// после ошибки - когда подошло время повтора) и записи, зависшие в running
+// дольше staleAfter
+func (r *SyncQueueRepository) ClaimDue(ctx context.Context, limit int, staleAfter time.Duration) ([]models.SyncQueueItem, error) {
+	due := r.db.NewSelect().
+		Model((*models.SyncQueueItem)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("status = 'pending' AND (next_retry_at IS NULL OR next_retry_at <= current_timestamp)").
+				WhereOr("status = 'running' AND updated_at < ?", time.Now().Add(-staleAfter))
+		}).
+		Order("id").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	var items []models.SyncQueueItem
+	_, err := r.db.NewUpdate().
+		Model((*models.SyncQueueItem)(nil)).
+		Set("status = 'running'").
+		Set("updated_at = current_timestamp").
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx, &items)
+
+	if err != nil {
+		r.logger.Error("Failed to claim sync queue items", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Finish - итог синхронизации записи из очереди
+func (r *SyncQueueRepository) Finish(ctx context.Context, id int, status, errMsg string) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.SyncQueueItem)(nil)).
+		Set("status = ?", status).
+		Set("error = ?", bun.NullZero(errMsg)).
+		Set("updated_at = current_timestamp").
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to finish sync queue item", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// Retry - вернуть запись в очередь после ошибки с повтором не раньше nextRetryAt
+func (r *SyncQueueRepository) Retry(ctx context.Context, id int, errMsg string, nextRetryAt time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.SyncQueueItem)(nil)).
+		Set("status = 'pending'").
+		Set("error = ?", bun.NullZero(errMsg)).
+		Set("attempts = attempts + 1").
+		Set("next_retry_at = ?", nextRetryAt).
+		Set("updated_at = current_timestamp").
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to reschedule sync queue item", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// CountOpen - записи запуска опроса, которые еще ожидают или выполняются
+func (r *SyncQueueRepository) CountOpen(ctx context.Context, syncID int) (int, error) {
+	count, err := r.db.NewSelect().
+		Model((*models.SyncQueueItem)(nil)).
+		Where("sync_id = ? AND status IN ('pending', 'running')", syncID).
+		Count(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to count open sync queue items", err, "sync_id", syncID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CancelBySyncID - отменить ожидающие записи запуска опроса. Возвращает
+// число отмененных.
+func (r *SyncQueueRepository) CancelBySyncID(ctx context.Context, syncID int) (int, error) {
+	res, err := r.db.NewUpdate().
+		Model((*models.SyncQueueItem)(nil)).
+		Set("status = 'cancelled'").
+		Set("updated_at = current_timestamp").
+		Where("sync_id = ? AND status = 'pending'", syncID).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to cancel sync queue items", err, "sync_id", syncID)
+		return 0, err
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
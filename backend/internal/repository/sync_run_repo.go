@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type SyncRunRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewSyncRunRepository(db *bun.DB, logger domain.Logger) *SyncRunRepository {
+	return &SyncRunRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Find - последние запуски по фильтру, новые первыми
+func (r *SyncRunRepository) Find(ctx context.Context, filter models.SyncRunFilter) ([]models.SyncRun, error) {
+	var runs []models.SyncRun
+	q := r.db.NewSelect().
+		Model(&runs).
+		Order("id DESC").
+		Limit(100)
+
+	if filter.TriggerType != "" {
+		q = q.Where("trigger_type = ?", filter.TriggerType)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.ConnectionID > 0 {
+		q = q.Where("source_connection_id = ? OR target_connection_id = ?", filter.ConnectionID, filter.ConnectionID)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		r.logger.Error("Failed to find sync runs", err)
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// GetByID - запуск или nil, если его нет
+func (r *SyncRunRepository) GetByID(ctx context.Context, id int) (*models.SyncRun, error) {
+	run := &models.SyncRun{}
+	err := r.db.NewSelect().
+		Model(run).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get sync run", err, "id", id)
+		return nil, err
+	}
+
+	return run, nil
+}
+
+func (r *SyncRunRepository) Create(ctx context.Context, run *models.SyncRun) error {
+	r.logger.Debug("Creating sync run", "trigger_type", run.TriggerType, "source_id", run.SourceConnectionID)
+
+	_, err := r.db.NewInsert().
+		Model(run).
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to create sync run", err, "trigger_type", run.TriggerType)
+		return err
+	}
+
+	return nil
+}
+
+// countRun - пересчитать счетчики запуска по его записям журнала
+func countRun(q *bun.UpdateQuery, id int) *bun.UpdateQuery {
+	return q.
+		Set("processed = (SELECT COUNT(*) FROM sync_logs WHERE sync_id = ?)", id).
+		Set("succeeded = (SELECT COUNT(*) FROM sync_logs WHERE sync_id = ? AND status = 'success')", id).
+		Set("failed = (SELECT COUNT(*) FROM sync_logs WHERE sync_id = ? AND status = 'error')", id).
+		Set("skipped = (SELECT COUNT(*) FROM sync_logs WHERE sync_id = ? AND status IN ('skipped', 'duplicate'))", id)
+}
+
+// UpdateCounts - пересчитать счетчики выполняющегося запуска
+func (r *SyncRunRepository) UpdateCounts(ctx context.Context, id int) error {
+	_, err := countRun(r.db.NewUpdate().Model((*models.SyncRun)(nil)), id).
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to update sync run counts", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// Finish - завершить запуск: статус error, если передана ошибка или в
+// журнале есть ошибки, иначе success. Отмененный запуск остается
+// отмененным, пересчитываются только счетчики.
+func (r *SyncRunRepository) Finish(ctx context.Context, run *models.SyncRun, errMsg string) error {
+	_, err := countRun(r.db.NewUpdate().Model(run), run.ID).
+		Set(`status = CASE WHEN status <> 'running' THEN status
+			WHEN ? <> '' OR EXISTS (SELECT 1 FROM sync_logs WHERE sync_id = ? AND status = 'error') THEN 'error'
+			ELSE 'success' END`, errMsg, run.ID).
+		Set("error = COALESCE(error, ?)", bun.NullZero(errMsg)).
+		Set("finished_at = COALESCE(finished_at, current_timestamp)").
+		WherePK().
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to finish sync run", err, "id", run.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Cancel - отменить выполняющийся запуск; false - запуск уже завершен
+func (r *SyncRunRepository) Cancel(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*models.SyncRun)(nil)).
+		Set("status = 'cancelled'").
+		Set("finished_at = current_timestamp").
+		Where("id = ? AND status = 'running'", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to cancel sync run", err, "id", id)
+		return false, err
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Reopen - вернуть завершенный с ошибкой запуск в работу (продолжение
+// выгрузки после сбоя)
+func (r *SyncRunRepository) Reopen(ctx context.Context, id int) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.SyncRun)(nil)).
+		Set("status = 'running'").
+		Set("error = NULL").
+		Set("finished_at = NULL").
+		Where("id = ? AND status = 'error'", id).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to reopen sync run", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteOld - удалить завершенные запуски старше olderThanDays дней
+func (r *SyncRunRepository) DeleteOld(ctx context.Context, olderThanDays int) error {
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+
+	res, err := r.db.NewDelete().
+		Model((*models.SyncRun)(nil)).
+		Where("started_at < ? AND status <> 'running'", cutoff).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to delete old sync runs", err)
+		return err
+	}
+
+	n, _ := res.RowsAffected()
+	r.logger.Info("Deleted old sync runs", "count", n, "older_than_days", olderThanDays)
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"github.com/uptrace/bun"
+)
+
+type WebhookDeliveryRepository struct {
+	db     *bun.DB
+	logger domain.Logger
+}
+
+func NewWebhookDeliveryRepository(db *bun.DB, logger domain.Logger) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	r.logger.Debug("Getting webhook delivery by id", "id", id)
+
+	delivery := &models.WebhookDelivery{}
+	err := r.db.NewSelect().
+		Model(delivery).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID int, limit int) ([]models.WebhookDelivery, error) {
+	r.logger.Debug("Getting webhook deliveries", "webhook_id", webhookID)
+
+	var deliveries []models.WebhookDelivery
+	err := r.db.NewSelect().
+		Model(&deliveries).
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC", "id DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	return deliveries, err
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.db.NewInsert().
+		Model(delivery).
+		Exec(ctx)
+
+	if err != nil {
+		r.logger.Error("Failed to create webhook delivery", err, "webhook_id", delivery.WebhookID)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDueRetries - забирает попытки, для которых подошло время повтора.
+// next_retry_at сбрасывается в той же команде, поэтому каждую попытку
+// повторяет только один экземпляр приложения.
+func (r *WebhookDeliveryRepository) ClaimDueRetries(ctx context.Context, limit int) ([]models.WebhookDelivery, error) {
+	due := r.db.NewSelect().
+		Model((*models.WebhookDelivery)(nil)).
+		Column("id").
+		Where("next_retry_at <= current_timestamp").
+		Order("next_retry_at").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	var deliveries []models.WebhookDelivery
+	_, err := r.db.NewUpdate().
+		Model((*models.WebhookDelivery)(nil)).
+		Set("next_retry_at = NULL").
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx, &deliveries)
+
+	if err != nil {
+		r.logger.Error("Failed to claim webhook retries", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"integration-app/internal/domain/models"
 	"integration-app/internal/utils"
@@ -15,7 +16,11 @@ type WebhookRepository interface {
 	GetByConnectionID(ctx context.Context, connectionID int) ([]*models.Webhook, error)
 	GetAll(ctx context.Context) ([]*models.Webhook, error)
 	GetActive(ctx context.Context) ([]*models.Webhook, error)
+	GetActiveByConnectionID(ctx context.Context, connectionID int) ([]*models.Webhook, error)
 	Update(ctx context.Context, webhook *models.Webhook) error
+	SetActive(ctx context.Context, id int, active bool) (*models.Webhook, error)
+	RotateSecret(ctx context.Context, id int, secret string, graceUntil time.Time) (*models.Webhook, error)
+	RecordDeliveryResult(ctx context.Context, id int, success bool, disableAfter int) (*models.Webhook, error)
 	Delete(ctx context.Context, id int) error
 }
 
@@ -80,11 +85,98 @@ func (r *webhookRepository) GetActive(ctx context.Context) ([]*models.Webhook, e
 	return utils.ToWebhookPointers(webhooks), nil
 }
 
+func (r *webhookRepository) GetActiveByConnectionID(ctx context.Context, connectionID int) ([]*models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.NewSelect().
+		Model(&webhooks).
+		Where("connection_id = ?", connectionID).
+		Where("is_active = ?", true).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ToWebhookPointers(webhooks), nil
+}
+
+// Update - изменяет настройки подписки. Состояние (активность, счетчик неудач,
+// секреты) меняется только через SetActive, RecordDeliveryResult и RotateSecret.
 func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
-	_, err := r.db.NewUpdate().Model(webhook).Where("id = ?", webhook.ID).Exec(ctx)
+	_, err := r.db.NewUpdate().
+		Model(webhook).
+		Column("connection_id", "event_type", "filters", "callback_url", "payload_format", "headers").
+		Where("id = ?", webhook.ID).
+		Exec(ctx)
 	return err
 }
 
+// SetActive - включает или выключает вебхук. Включение сбрасывает
+// счетчик неудачных доставок и причину автоматического отключения.
+func (r *webhookRepository) SetActive(ctx context.Context, id int, active bool) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	q := r.db.NewUpdate().
+		Model(webhook).
+		Set("is_active = ?", active).
+		Where("id = ?", id).
+		Returning("*")
+
+	if active {
+		q = q.Set("consecutive_failures = 0").Set("disabled_reason = NULL")
+	} else {
+		q = q.Set("disabled_reason = 'disabled manually'")
+	}
+
+	if _, err := q.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// RotateSecret - текущий секрет становится предыдущим и действует до graceUntil
+func (r *webhookRepository) RotateSecret(ctx context.Context, id int, secret string, graceUntil time.Time) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	_, err := r.db.NewUpdate().
+		Model(webhook).
+		Set("previous_secret_key = secret_key").
+		Set("previous_secret_expires_at = ?", graceUntil).
+		Set("secret_key = ?", secret).
+		Where("id = ?", id).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// RecordDeliveryResult - обновляет счетчик неудачных доставок подряд и отключает
+// вебхук, когда счетчик достигает disableAfter
+func (r *webhookRepository) RecordDeliveryResult(ctx context.Context, id int, success bool, disableAfter int) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	q := r.db.NewUpdate().
+		Model(webhook).
+		Where("id = ?", id).
+		Returning("*")
+
+	if success {
+		q = q.Set("consecutive_failures = 0")
+	} else {
+		q = q.Set("consecutive_failures = consecutive_failures + 1").
+			Set("is_active = CASE WHEN consecutive_failures + 1 >= ? THEN FALSE ELSE is_active END", disableAfter).
+			Set("disabled_reason = CASE WHEN is_active AND consecutive_failures + 1 >= ? "+
+				"THEN 'disabled after ' || (consecutive_failures + 1) || ' consecutive failed deliveries' "+
+				"ELSE disabled_reason END", disableAfter)
+	}
+
+	if _, err := q.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
 func (r *webhookRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.db.NewDelete().Model(&models.Webhook{}).Where("id = ?", id).Exec(ctx)
 	return err
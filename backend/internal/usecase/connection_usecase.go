@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 
 	"integration-app/internal/domain"
 	"integration-app/internal/domain/models"
@@ -9,15 +11,21 @@ import (
 
 type ConnectionUseCase struct {
 	repo   domain.ConnectionRepository
+	events domain.EventPublisher
+	guard  domain.URLGuard
 	logger domain.Logger
 }
 
 func NewConnectionUseCase(
 	repo domain.ConnectionRepository,
+	events domain.EventPublisher,
+	guard domain.URLGuard,
 	logger domain.Logger,
 ) *ConnectionUseCase {
 	return &ConnectionUseCase{
 		repo:   repo,
+		events: events,
+		guard:  guard,
 		logger: logger,
 	}
 }
@@ -32,8 +40,17 @@ func (uc *ConnectionUseCase) GetAllConnections(ctx context.Context) ([]models.Co
 func (uc *ConnectionUseCase) CreateConnection(ctx context.Context, conn *models.Connection) error {
 	uc.logger.Info("UseCase: Creating connection", "name", conn.Name)
 
+	// Токен входящего URL выдает сервер, а не пользователь
+	if conn.SystemType == domain.SystemWebhook {
+		token, err := generateInboundToken()
+		if err != nil {
+			return err
+		}
+		conn.AccessToken = token
+	}
+
 	// Валидация
-	if err := uc.validateConnection(conn); err != nil {
+	if err := uc.validateConnection(ctx, conn); err != nil {
 		uc.logger.Warn("Validation failed", "error", err.Error())
 		return err
 	}
@@ -45,11 +62,46 @@ func (uc *ConnectionUseCase) CreateConnection(ctx context.Context, conn *models.
 func (uc *ConnectionUseCase) UpdateConnection(ctx context.Context, conn *models.Connection) error {
 	uc.logger.Info("UseCase: Updating connection", "id", conn.ID)
 
-	if err := uc.validateConnection(conn); err != nil {
+	existing, err := uc.repo.GetByID(ctx, conn.ID)
+	if err != nil {
+		return domain.NewErrorf("connection %d not found", conn.ID)
+	}
+
+	if conn.SystemType == domain.SystemWebhook {
+		conn.AccessToken = existing.AccessToken
+		if existing.SystemType != domain.SystemWebhook {
+			if conn.AccessToken, err = generateInboundToken(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := uc.validateConnection(ctx, conn); err != nil {
 		return err
 	}
 
-	return uc.repo.Update(ctx, conn)
+	if err := uc.repo.Update(ctx, conn); err != nil {
+		return err
+	}
+
+	if existing.IsActive != conn.IsActive {
+		eventType := domain.EventConnectionDeactivated
+		if conn.IsActive {
+			eventType = domain.EventConnectionActivated
+		}
+
+		uc.events.Publish(ctx, &domain.Event{
+			Type:          eventType,
+			ConnectionIDs: []int{conn.ID},
+			Data: map[string]interface{}{
+				"connection_id": conn.ID,
+				"name":          conn.Name,
+				"system_type":   conn.SystemType,
+			},
+		})
+	}
+
+	return nil
 }
 
 // DeleteConnection - удалить подключение
@@ -71,7 +123,7 @@ func (uc *ConnectionUseCase) DeleteConnection(ctx context.Context, id int) error
 }
 
 // validateConnection - валидация данных подключения
-func (uc *ConnectionUseCase) validateConnection(conn *models.Connection) error {
+func (uc *ConnectionUseCase) validateConnection(ctx context.Context, conn *models.Connection) error {
 	if conn.Name == "" {
 		return domain.NewError("connection name cannot be empty")
 	}
@@ -84,5 +136,64 @@ func (uc *ConnectionUseCase) validateConnection(conn *models.Connection) error {
 		return domain.NewError("access token cannot be empty")
 	}
 
+	// Расписание опроса подключения для планировщика
+	if spec, ok := connectionMetadata(conn)["poll_schedule"].(string); ok && strings.TrimSpace(spec) != "" {
+		if _, err := ParseCron(spec); err != nil {
+			return domain.NewErrorf("invalid poll_schedule: %v", err)
+		}
+	}
+
+	for _, u := range connectionURLs(conn) {
+		if err := uc.guard.ValidateURL(ctx, u); err != nil {
+			return domain.NewErrorf("invalid connection URL: %v", err)
+		}
+	}
+
 	return nil
 }
+
+// connectionURLs - адреса, на которые коннектор будет делать запросы:
+// токен-URL (входящий вебхук Bitrix24) и параметры *_url из метаданных
+func connectionURLs(conn *models.Connection) []string {
+	urls := make([]string, 0)
+	if strings.HasPrefix(conn.AccessToken, "http") {
+		urls = append(urls, conn.AccessToken)
+	}
+
+	for key, value := range connectionMetadata(conn) {
+		if s, ok := value.(string); ok && s != "" && (key == "url" || strings.HasSuffix(key, "_url")) {
+			urls = append(urls, s)
+		}
+	}
+
+	return urls
+}
+
+// connectionMetadata - разобранный Connection.Metadata (пустой, если его нет)
+func connectionMetadata(conn *models.Connection) map[string]interface{} {
+	meta := make(map[string]interface{})
+	if len(conn.Metadata) > 0 {
+		_ = json.Unmarshal(conn.Metadata, &meta)
+	}
+	return meta
+}
+
+// metadataStrings - список строк из метаданных: JSON-массив или "a, b"
+func metadataStrings(conn *models.Connection, key string) []string {
+	result := make([]string, 0)
+	switch value := connectionMetadata(conn)[key].(type) {
+	case string:
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if s := strings.TrimSpace(toString(item)); s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+)
+
+// CronSchedule - разобранное cron-выражение из пяти полей
+// (минута, час, день месяца, месяц, день недели) или @every <интервал>
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	every                         time.Duration
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonths = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronWeekdays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron - разбор выражения: "*/5 * * * *", "0 9 * * mon-fri",
+// "@daily", "@every 10m". Поддерживаются *, списки, диапазоны и шаги.
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, domain.NewError("cron expression is empty")
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Minute {
+			return nil, domain.NewErrorf("invalid @every interval %q, minimum is 1m", rest)
+		}
+		return &CronSchedule{every: every}, nil
+	}
+	if expanded, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, domain.NewErrorf("cron expression %q must have 5 fields", spec)
+	}
+
+	s := &CronSchedule{
+		domAny: fields[2] == "*" || fields[2] == "?",
+		dowAny: fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, domain.NewErrorf("minute: %v", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, domain.NewErrorf("hour: %v", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, domain.NewErrorf("day of month: %v", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return nil, domain.NewErrorf("month: %v", err)
+	}
+	// 7 - тоже воскресенье
+	if s.dow, err = parseCronField(fields[4], 0, 7, cronWeekdays); err != nil {
+		return nil, domain.NewErrorf("day of week: %v", err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+// parseCronField - битовая маска значений поля
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, domain.NewErrorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = cronValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(to, min, max, names); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, domain.NewErrorf("invalid range %q", rangePart)
+			}
+		default:
+			var err error
+			if lo, err = cronValue(rangePart, min, max, names); err != nil {
+				return 0, err
+			}
+			// "5/15" - с 5 до конца с шагом 15
+			if !hasStep {
+				hi = lo
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+func cronValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, domain.NewErrorf("value %q must be between %d and %d", s, min, max)
+	}
+	return v, nil
+}
+
+// allCronHours - маска поля часа "*"
+const allCronHours = 1<<24 - 1
+
+// Next - ближайшее время срабатывания строго после t. Переходы на летнее
+// и зимнее время обрабатываются как в cron: задание, время которого
+// пропущено при переводе часов вперед, срабатывает сразу после перевода,
+// а задание с конкретным часом при переводе назад не срабатывает второй
+// раз в повторившемся часе.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Truncate(time.Minute).Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Совпадение есть не позже чем через несколько лет (29 февраля)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		var next time.Time
+		switch {
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// Сдвиг по реальному времени, а не time.Date: в повторяющемся
+			// часе time.Date выбрал бы второе из двух одинаковых времен
+			next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			next = t.Add(time.Minute)
+		case s.hour != allCronHours && repeatedWallClock(t):
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+
+		if s.matchSkipped(t, next) {
+			return next
+		}
+		t = next
+	}
+
+	return time.Time{}
+}
+
+// repeatedWallClock - такое же время на часах уже было час назад (перевод назад)
+func repeatedWallClock(t time.Time) bool {
+	prev := t.Add(-time.Hour)
+	return prev.Day() == t.Day() && prev.Hour() == t.Hour() && prev.Minute() == t.Minute()
+}
+
+// matchSkipped - попадает ли в расписание время на часах, пропущенное
+// переводом вперед между from и to
+func (s *CronSchedule) matchSkipped(from, to time.Time) bool {
+	if from.Day() != to.Day() {
+		return false
+	}
+
+	elapsed := int(to.Sub(from) / time.Minute)
+	start := from.Hour()*60 + from.Minute() + elapsed
+	end := to.Hour()*60 + to.Minute()
+	for m := start; m < end; m++ {
+		if s.hour&(1<<uint(m/60)) != 0 && s.minute&(1<<uint(m%60)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDay - как в cron: если заданы и день месяца, и день недели,
+// достаточно совпадения любого из них
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
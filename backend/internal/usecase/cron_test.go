@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "cron expression is empty"},
+		{"* * * *", "must have 5 fields"},
+		{"* * * * * *", "must have 5 fields"},
+		{"60 * * * *", `minute: value "60" must be between 0 and 59`},
+		{"* 24 * * *", `hour: value "24" must be between 0 and 23`},
+		{"* * 0 * *", `day of month: value "0" must be between 1 and 31`},
+		{"* * * 13 *", `month: value "13" must be between 1 and 12`},
+		{"* * * * 8", `day of week: value "8" must be between 0 and 7`},
+		{"*/0 * * * *", `minute: invalid step "0"`},
+		{"10-5 * * * *", `minute: invalid range "10-5"`},
+		{"* * * foo *", `month: value "foo" must be between 1 and 12`},
+		{"@every 30s", "minimum is 1m"},
+		{"@every soon", "invalid @every interval"},
+		{"@sometimes", "must have 5 fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseCron(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseCron(%q) error = %v, want %q", tt.spec, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string
+	}{
+		{"strictly after t", "*/5 * * * *", "2026-01-10T10:05:00Z", "2026-01-10T10:10:00Z"},
+		{"seconds are dropped", "*/5 * * * *", "2026-01-10T10:04:59Z", "2026-01-10T10:05:00Z"},
+		{"next hour", "0 * * * *", "2026-01-10T10:00:00Z", "2026-01-10T11:00:00Z"},
+		{"step from offset", "5/20 * * * *", "2026-01-10T10:26:00Z", "2026-01-10T10:45:00Z"},
+		{"list", "0 9,18 * * *", "2026-01-10T09:00:00Z", "2026-01-10T18:00:00Z"},
+		{"end of day", "@daily", "2026-01-10T23:59:00Z", "2026-01-11T00:00:00Z"},
+		{"end of year", "@daily", "2026-12-31T12:00:00Z", "2027-01-01T00:00:00Z"},
+		{"end of 30-day month", "0 0 1 * *", "2026-04-30T12:00:00Z", "2026-05-01T00:00:00Z"},
+		{"31st skips short months", "0 0 31 * *", "2026-04-01T00:00:00Z", "2026-05-31T00:00:00Z"},
+		{"30th skips February", "0 12 30 * *", "2026-01-30T12:00:00Z", "2026-03-30T12:00:00Z"},
+		{"29 February in a leap year", "0 0 29 2 *", "2026-01-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"month names", "0 0 1 jan,jul *", "2026-02-01T00:00:00Z", "2026-07-01T00:00:00Z"},
+		{"weekday names", "0 9 * * mon-fri", "2026-01-09T09:00:00Z", "2026-01-12T09:00:00Z"},
+		{"7 is Sunday", "0 0 * * 7", "2026-01-10T00:00:00Z", "2026-01-11T00:00:00Z"},
+		{"day of month or day of week", "0 0 15 * sun", "2026-01-12T00:00:00Z", "2026-01-15T00:00:00Z"},
+		{"weekly", "@weekly", "2026-01-11T00:00:00Z", "2026-01-18T00:00:00Z"},
+		{"every interval", "@every 90m", "2026-01-10T10:00:30Z", "2026-01-10T11:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertCronNext(t, tt.spec, tt.from, tt.want, time.UTC)
+		})
+	}
+}
+
+// В Europe/Berlin 29.03.2026 в 02:00 часы переводятся на 03:00,
+// 25.10.2026 в 03:00 - обратно на 02:00
+func TestCronNextDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string
+	}{
+		{"spring: skipped time runs right after the change", "30 2 * * *", "2026-03-29T01:00:00+01:00", "2026-03-29T03:00:00+02:00"},
+		{"spring: next day is back to normal", "30 2 * * *", "2026-03-29T03:00:00+02:00", "2026-03-30T02:30:00+02:00"},
+		{"spring: hourly skips the missing hour", "0 * * * *", "2026-03-29T01:30:00+01:00", "2026-03-29T03:00:00+02:00"},
+		{"spring: time after the gap", "0 4 * * *", "2026-03-29T01:00:00+01:00", "2026-03-29T04:00:00+02:00"},
+		{"autumn: first occurrence of repeated time", "30 2 * * *", "2026-10-25T01:00:00+02:00", "2026-10-25T02:30:00+02:00"},
+		{"autumn: fixed time runs once", "30 2 * * *", "2026-10-25T02:30:00+02:00", "2026-10-26T02:30:00+01:00"},
+		{"autumn: hourly runs in both repeated hours", "0 * * * *", "2026-10-25T02:30:00+02:00", "2026-10-25T02:00:00+01:00"},
+		{"autumn: hourly continues after repeated hour", "0 * * * *", "2026-10-25T02:30:00+01:00", "2026-10-25T03:00:00+01:00"},
+		{"autumn: minutes within repeated hour", "*/30 * * * *", "2026-10-25T02:45:00+02:00", "2026-10-25T02:00:00+01:00"},
+		{"autumn: day after the change", "0 3 * * *", "2026-10-25T03:00:00+01:00", "2026-10-26T03:00:00+01:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertCronNext(t, tt.spec, tt.from, tt.want, berlin)
+		})
+	}
+}
+
+func assertCronNext(t *testing.T, spec, from, want string, loc *time.Location) {
+	t.Helper()
+
+	s, err := ParseCron(spec)
+	if err != nil {
+		t.Fatalf("ParseCron(%q): %v", spec, err)
+	}
+
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := time.Parse(time.RFC3339, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.Next(start.In(loc))
+	if !got.Equal(expected) {
+		t.Errorf("%q.Next(%s) = %s, want %s", spec, from, got.Format(time.RFC3339), want)
+	}
+}
@@ -0,0 +1,889 @@
+package usecase
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"integration-app/internal/domain"
+)
+
+// Язык фильтров - выражения над полями входящей записи:
+//
+//	form_id in ["123", "456"] and amount >= 100000
+//	not (email matches "@test\\.") || city == "Москва"
+//	lower(source) contains "facebook"
+//
+// Поля - пути через точку, как в сопоставлениях; имена с пробелами или
+// совпадающие с ключевыми словами доступны через field("имя поля").
+// Выражение не может ничего вызвать, кроме встроенных функций, поэтому
+// безопасно для пользовательского ввода.
+const (
+	maxFilterLength = 2000
+	maxFilterDepth  = 50
+)
+
+// SyncActionFiltered - запись не прошла фильтр и не синхронизировалась
+const SyncActionFiltered = "filtered"
+
+// filterSkippedMessage - причина пропуска записи в sync_logs
+const filterSkippedMessage = "skipped by filter"
+
+// filterExpr - разобранное выражение фильтра
+type filterExpr struct {
+	source string
+	root   exprNode
+}
+
+// compileFilter - разбор выражения; ошибки синтаксиса указывают позицию
+func compileFilter(source string) (*filterExpr, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, domain.NewError("filter expression is empty")
+	}
+	if len(source) > maxFilterLength {
+		return nil, domain.NewErrorf("filter expression is longer than %d characters", maxFilterLength)
+	}
+
+	tokens, err := lexFilter(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, domain.NewErrorf("filter: unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+
+	return &filterExpr{source: source, root: root}, nil
+}
+
+// validateFilter - пустой фильтр допустим и означает "пропускать все"
+func validateFilter(source string) error {
+	if strings.TrimSpace(source) == "" {
+		return nil
+	}
+	_, err := compileFilter(source)
+	return err
+}
+
+// matchFilter - пройдет ли запись фильтр. Пустой фильтр пропускает все.
+func matchFilter(source string, data map[string]interface{}) (bool, error) {
+	if strings.TrimSpace(source) == "" {
+		return true, nil
+	}
+
+	expr, err := compileFilter(source)
+	if err != nil {
+		return false, err
+	}
+	return expr.match(data)
+}
+
+func (f *filterExpr) match(data map[string]interface{}) (bool, error) {
+	value, err := f.root.eval(data)
+	if err != nil {
+		return false, domain.NewErrorf("filter: %v", err)
+	}
+	return truthy(value), nil
+}
+
+// --- Лексер ---
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var filterOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ","}
+
+// filterKeywords - слова-операторы и литералы, не являющиеся именами полей
+var filterKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true,
+	"contains": true, "matches": true, "startswith": true, "endswith": true,
+	"true": true, "false": true, "null": true,
+}
+
+func lexFilter(source string) ([]exprToken, error) {
+	runes := []rune(source)
+	tokens := make([]exprToken, 0)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"' || r == '\'':
+			text, next, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: text, pos: i})
+			i = next
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) {
+				c := runes[i]
+				if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' {
+					i++
+					continue
+				}
+				// Ключи form-urlencoded вида data[FIELDS][ID] - часть имени поля
+				if c == '[' {
+					end := i
+					for end < len(runes) && runes[end] != ']' {
+						end++
+					}
+					if end == len(runes) {
+						return nil, domain.NewErrorf("filter: unclosed [ at position %d", i+1)
+					}
+					i = end + 1
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range filterOperators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, domain.NewErrorf("filter: unexpected character %q at position %d", r, i+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokenOperator, text: op, pos: i})
+			i += len([]rune(op))
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func lexString(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	var b strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				switch runes[i] {
+				case 'n':
+					b.WriteRune('\n')
+				case 't':
+					b.WriteRune('\t')
+				default:
+					b.WriteRune(runes[i])
+				}
+			}
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return "", 0, domain.NewErrorf("filter: unterminated string at position %d", start+1)
+}
+
+// --- Парсер ---
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	depth  int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept - взять токен, если это один из операторов или ключевых слов
+func (p *exprParser) accept(words ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator && tok.kind != tokenIdent {
+		return "", false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.text, w) && (tok.kind == tokenOperator || filterKeywords[w]) {
+			p.next()
+			return w, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return domain.NewErrorf("filter: expected %q at the end of expression", op)
+		}
+		return domain.NewErrorf("filter: expected %q at position %d, got %q", op, tok.pos+1, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) enter() error {
+	p.depth++
+	if p.depth > maxFilterDepth {
+		return domain.NewErrorf("filter: expression is nested deeper than %d levels", maxFilterDepth)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	negate := false
+	if tok := p.peek(); tok.kind == tokenIdent && strings.EqualFold(tok.text, "not") {
+		// "not in" и "not contains" - отрицание следующего оператора
+		p.next()
+		negate = true
+	}
+
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "in", "contains", "matches", "startswith", "endswith")
+	if !ok {
+		if negate {
+			tok := p.peek()
+			return nil, domain.NewErrorf("filter: expected in, contains, matches, startswith or endswith after not at position %d", tok.pos+1)
+		}
+		return left, nil
+	}
+	if negate && (op == "==" || op == "!=" || op == "<" || op == "<=" || op == ">" || op == ">=") {
+		return nil, domain.NewErrorf("filter: not cannot be used before %s", op)
+	}
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &compareNode{op: op, left: left, right: right}
+	if op == "matches" {
+		if lit, ok := right.(*literalNode); ok {
+			pattern, ok := lit.value.(string)
+			if !ok {
+				return nil, domain.NewError("filter: matches requires a string pattern")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, domain.NewErrorf("filter: invalid regular expression %q: %v", pattern, err)
+			}
+			node.re = re
+		}
+	}
+
+	if negate {
+		return &notNode{operand: node}, nil
+	}
+	return node, nil
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept("-"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticNode{op: "-", left: &literalNode{value: float64(0)}, right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenEOF:
+		return nil, domain.NewError("filter: unexpected end of expression")
+
+	case tokenNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, domain.NewErrorf("filter: invalid number %q at position %d", tok.text, tok.pos+1)
+		}
+		return &literalNode{value: n}, nil
+
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if filterKeywords[strings.ToLower(tok.text)] {
+			return nil, domain.NewErrorf("filter: unexpected %q at position %d", tok.text, tok.pos+1)
+		}
+		if p.peek().kind == tokenOperator && p.peek().text == "(" {
+			return p.parseCall(tok)
+		}
+		return &fieldNode{path: tok.text}, nil
+
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			expr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+
+		case "[":
+			items := make([]exprNode, 0)
+			if _, ok := p.accept("]"); ok {
+				return &listNode{items: items}, nil
+			}
+			for {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if _, ok := p.accept(","); ok {
+					continue
+				}
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				return &listNode{items: items}, nil
+			}
+		}
+	}
+
+	return nil, domain.NewErrorf("filter: unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := filterFunctions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, domain.NewErrorf("filter: unknown function %q at position %d", name.text, name.pos+1)
+	}
+	p.next() // (
+
+	args := make([]exprNode, 0)
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if len(args) != 1 {
+		return nil, domain.NewErrorf("filter: %s() takes exactly one argument", strings.ToLower(name.text))
+	}
+
+	// field("имя") - имя поля должно быть известно при разборе
+	if strings.EqualFold(name.text, "field") {
+		path := ""
+		if lit, ok := args[0].(*literalNode); ok {
+			path, _ = lit.value.(string)
+		}
+		if path == "" {
+			return nil, domain.NewError("filter: field() requires a non-empty string literal")
+		}
+		return &fieldNode{path: path}, nil
+	}
+
+	return &callNode{name: strings.ToLower(name.text), fn: fn, arg: args[0]}, nil
+}
+
+// --- Вычисление ---
+
+type exprNode interface {
+	eval(data map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+// fieldNode - значение поля записи; отсутствующее поле равно null
+type fieldNode struct {
+	path string
+}
+
+func (n *fieldNode) eval(data map[string]interface{}) (interface{}, error) {
+	value, _ := lookupField(data, n.path)
+	return value, nil
+}
+
+type listNode struct {
+	items []exprNode
+}
+
+func (n *listNode) eval(data map[string]interface{}) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type logicalNode struct {
+	or          bool
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(data map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(left) == n.or {
+		return n.or, nil
+	}
+	right, err := n.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+type notNode struct {
+	operand exprNode
+}
+
+func (n *notNode) eval(data map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+	re          *regexp.Regexp
+}
+
+func (n *compareNode) eval(data map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return looseEqual(left, right), nil
+	case "!=":
+		return !looseEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compareOrdered(n.op, left, right)
+	case "in":
+		return containsLoose(right, left), nil
+	case "contains":
+		return containsLoose(left, right), nil
+	case "startswith":
+		return left != nil && strings.HasPrefix(toString(left), toString(right)), nil
+	case "endswith":
+		return left != nil && strings.HasSuffix(toString(left), toString(right)), nil
+	case "matches":
+		re := n.re
+		if re == nil {
+			if re, err = regexp.Compile(toString(right)); err != nil {
+				return nil, domain.NewErrorf("invalid regular expression %q: %v", toString(right), err)
+			}
+		}
+		return left != nil && re.MatchString(toString(left)), nil
+	}
+
+	return nil, domain.NewErrorf("unknown operator %s", n.op)
+}
+
+type arithmeticNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *arithmeticNode) eval(data map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	a, okA := toNumber(left)
+	b, okB := toNumber(right)
+	if !okA || !okB {
+		// Сложение строк - склейка
+		if n.op == "+" && left != nil && right != nil {
+			return toString(left) + toString(right), nil
+		}
+		return nil, domain.NewErrorf("operator %s requires numbers, got %s and %s", n.op, describeValue(left), describeValue(right))
+	}
+
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, domain.NewError("division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, domain.NewError("division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+
+	return nil, domain.NewErrorf("unknown operator %s", n.op)
+}
+
+type callNode struct {
+	name string
+	fn   func(interface{}) (interface{}, error)
+	arg  exprNode
+}
+
+func (n *callNode) eval(data map[string]interface{}) (interface{}, error) {
+	arg, err := n.arg.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return n.fn(arg)
+}
+
+// filterFunctions - встроенные функции с одним аргументом
+var filterFunctions = map[string]func(interface{}) (interface{}, error){
+	"field": nil, // разворачивается в fieldNode при разборе
+	"lower": func(v interface{}) (interface{}, error) {
+		return strings.ToLower(toString(v)), nil
+	},
+	"upper": func(v interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(v)), nil
+	},
+	"trim": func(v interface{}) (interface{}, error) {
+		return strings.TrimSpace(toString(v)), nil
+	},
+	"len": func(v interface{}) (interface{}, error) {
+		switch value := v.(type) {
+		case nil:
+			return float64(0), nil
+		case []interface{}:
+			return float64(len(value)), nil
+		case map[string]interface{}:
+			return float64(len(value)), nil
+		default:
+			return float64(len([]rune(toString(value)))), nil
+		}
+	},
+	"number": func(v interface{}) (interface{}, error) {
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, domain.NewErrorf("cannot convert %s to number", describeValue(v))
+		}
+		return n, nil
+	},
+	"string": func(v interface{}) (interface{}, error) {
+		return toString(v), nil
+	},
+}
+
+// truthy - ложны null, false, 0, пустая строка и пустой список
+func truthy(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	}
+	return true
+}
+
+// toNumber - числа и строки с числом ("150000", " 99.5 ")
+func toNumber(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// looseEqual - поля форм приходят строками, поэтому "100" == 100
+func looseEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	if _, ok := b.(bool); ok {
+		return false
+	}
+
+	_, aString := a.(string)
+	_, bString := b.(string)
+	if !aString || !bString {
+		if x, ok := toNumber(a); ok {
+			if y, ok := toNumber(b); ok {
+				return x == y
+			}
+		}
+	}
+
+	return toString(a) == toString(b)
+}
+
+// containsLoose - элемент в списке или подстрока в строке
+func containsLoose(container, item interface{}) bool {
+	switch value := container.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		for _, v := range value {
+			if looseEqual(v, item) {
+				return true
+			}
+		}
+		return false
+	default:
+		return item != nil && strings.Contains(toString(value), toString(item))
+	}
+}
+
+// compareOrdered - числа сравниваются как числа, строки - лексикографически.
+// С пустым полем сравнение ложно, чтобы запись без суммы не проходила "amount > 100".
+func compareOrdered(op string, a, b interface{}) (bool, error) {
+	if a == nil || b == nil {
+		return false, nil
+	}
+
+	var cmp int
+	x, okA := toNumber(a)
+	y, okB := toNumber(b)
+	switch {
+	case okA && okB:
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	default:
+		s, sOK := a.(string)
+		t, tOK := b.(string)
+		if !sOK || !tOK {
+			return false, domain.NewErrorf("cannot compare %s and %s", describeValue(a), describeValue(b))
+		}
+		cmp = strings.Compare(s, t)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func describeValue(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	}
+	return "object"
+}
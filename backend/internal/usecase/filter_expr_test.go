@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchFilter(t *testing.T) {
+	data := map[string]interface{}{
+		"form_id":          "123",
+		"amount":           "150000",
+		"email":            "Ivan@Example.com",
+		"city":             "Москва",
+		"tags":             []interface{}{"vip", "b2b"},
+		"count":            float64(3),
+		"empty":            "",
+		"contact":          map[string]interface{}{"phone": "+79001234567"},
+		"full name":        "Иван Петров",
+		"data[FIELDS][ID]": "42",
+		"pattern":          `^\+7\d{10}$`,
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   bool
+	}{
+		{"empty filter passes", "", true},
+		{"string equals number", `form_id == 123`, true},
+		{"number equals string", `amount >= 100000`, true},
+		{"numeric compare, not lexicographic", `amount > 99999`, true},
+		{"missing field is null", `missing == null`, true},
+		{"missing field fails ordering", `missing > 1`, false},
+		{"in list, loose equality", `form_id in [123, "456"]`, true},
+		{"in list, no match", `form_id in ["456", "789"]`, false},
+		{"not in", `form_id not in ["456"]`, true},
+		{"in field list", `"vip" in tags`, true},
+		{"list contains", `tags contains "b2b"`, true},
+		{"string contains", `email contains "@Example"`, true},
+		{"not contains", `email not contains "test"`, true},
+		{"in empty list", `form_id in []`, false},
+		{"matches literal regex", `email matches "(?i)@example\\.com$"`, true},
+		{"matches is case sensitive", `email matches "@example\\.com$"`, false},
+		{"not matches", `not (email matches "@test\\.")`, true},
+		{"matches pattern from field", `contact.phone matches pattern`, true},
+		{"matches null field", `missing matches ".*"`, false},
+		{"startswith", `email startswith "Ivan"`, true},
+		{"endswith", `email endswith ".ru"`, false},
+		{"lower function", `lower(email) == "ivan@example.com"`, true},
+		{"len of list", `len(tags) == 2`, true},
+		{"nested path", `contact.phone startswith "+7"`, true},
+		{"field() with spaces", `field("full name") contains "Иван"`, true},
+		{"bracketed form key", `data[FIELDS][ID] == 42`, true},
+		{"arithmetic precedence", `count * 2 + 1 == 7`, true},
+		{"unary minus", `-count < 0`, true},
+		{"and binds tighter than or", `false and false or true`, true},
+		{"keyword operators are case insensitive", `form_id IN ["123"] AND city == "Москва"`, true},
+		{"symbolic operators", `!(empty) && count != 0 || false`, true},
+		{"empty string is falsy", `empty`, false},
+		{"unicode string compare", `city == "Москва"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchFilter(tt.filter, data)
+			if err != nil {
+				t.Fatalf("matchFilter(%q): %v", tt.filter, err)
+			}
+			if got != tt.want {
+				t.Errorf("matchFilter(%q) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchFilterRuntimeErrors(t *testing.T) {
+	data := map[string]interface{}{"name": "abc", "count": float64(0)}
+
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{`name * 2 > 1`, "requires numbers"},
+		{`10 / count > 1`, "division by zero"},
+		{`number(name) > 1`, "cannot convert string to number"},
+		{`name matches name + "("`, "invalid regular expression"},
+		{`[1] < 2`, "cannot compare list and number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := matchFilter(tt.filter, data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("matchFilter(%q) error = %v, want %q", tt.filter, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilterSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{`a ==`, "unexpected end of expression"},
+		{`a == == b`, `unexpected "==" at position 6`},
+		{`a @ b`, `unexpected character '@' at position 3`},
+		{`name == 'abc`, "unterminated string at position 9"},
+		{`(a == 1`, `expected ")" at the end of expression`},
+		{`[1, 2 3]`, `expected "]" at position 7, got "3"`},
+		{`a == 1 b`, `unexpected "b" at position 8`},
+		{`data[FIELDS == 1`, "unclosed [ at position 5"},
+		{`foo(a) == 1`, `unknown function "foo" at position 1`},
+		{`lower(a, b) == ""`, "lower() takes exactly one argument"},
+		{`field(name) == 1`, "field() requires a non-empty string literal"},
+		{`a not == 1`, "not cannot be used before =="},
+		{`a not b`, "expected in, contains, matches, startswith or endswith after not at position 7"},
+		{`and == 1`, `unexpected "and" at position 1`},
+		{`a matches "("`, "invalid regular expression"},
+		{`a matches 1`, "matches requires a string pattern"},
+		{`1.2.3 == a`, `invalid number "1.2.3" at position 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			err := validateFilter(tt.filter)
+			if err == nil {
+				t.Fatalf("validateFilter(%q) = nil, want error containing %q", tt.filter, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validateFilter(%q) = %q, want it to contain %q", tt.filter, err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilterLimits(t *testing.T) {
+	nested := func(open, body, close string, n int) string {
+		return strings.Repeat(open, n) + body + strings.Repeat(close, n)
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   string // пусто - выражение допустимо
+	}{
+		{"blank filter", "   ", ""},
+		{"parentheses within limit", nested("(", "a == 1", ")", maxFilterDepth-1), ""},
+		{"parentheses over limit", nested("(", "a == 1", ")", maxFilterDepth), "nested deeper than 50 levels"},
+		{"not chain over limit", strings.Repeat("not ", maxFilterDepth+1) + "a", "nested deeper than 50 levels"},
+		{"unary minus chain over limit", strings.Repeat("-", maxFilterDepth+1) + "1 < 0", "nested deeper than 50 levels"},
+		{"lists over limit", nested("[", "1", "]", maxFilterDepth), "nested deeper than 50 levels"},
+		{"length at limit", "a == \"" + strings.Repeat("x", maxFilterLength-7) + "\"", ""},
+		{"length over limit", "a == \"" + strings.Repeat("x", maxFilterLength) + "\"", "longer than 2000 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFilter(tt.filter)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("validateFilter: unexpected error %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("validateFilter error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
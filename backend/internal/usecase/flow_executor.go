@@ -0,0 +1,538 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/utils"
+
+	"go.uber.org/fx"
+)
+
+// Типы шагов потока
+const (
+	FlowStepFilter    = "filter"
+	FlowStepTransform = "transform"
+	FlowStepLookup    = "lookup"
+	FlowStepAction    = "action"
+	FlowStepRouter    = "router"
+	FlowStepDelay     = "delay"
+	FlowStepRunAt     = "run_at"
+)
+
+// Операции шага action
+const (
+	FlowOperationCreate = "create"
+	FlowOperationUpdate = "update"
+	FlowOperationUpsert = "upsert"
+)
+
+// Статусы выполнения потока и его шагов
+const (
+	FlowStatusSuccess  = "success"
+	FlowStatusError    = "error"
+	FlowStatusFiltered = "filtered"
+	FlowStatusDelayed  = "delayed"
+)
+
+// FlowRun - итог выполнения потока для одной записи
+type FlowRun struct {
+	FlowID        int              `json:"flow_id"`
+	CorrelationID string           `json:"correlation_id"`
+	Status        string           `json:"status"`
+	Steps         []FlowStepResult `json:"steps"`
+	Error         string           `json:"error,omitempty"`
+}
+
+// FlowStepResult - итог шага; вход и выход шага сохраняются в sync_logs.
+// Каждая ветка router - отдельный результат с StepID "<шаг>.<ветка>".
+type FlowStepResult struct {
+	StepID    string                 `json:"step_id"`
+	Type      string                 `json:"type"`
+	Status    string                 `json:"status"`
+	Output    map[string]interface{} `json:"output,omitempty"`
+	SyncLogID int                    `json:"sync_log_id,omitempty"`
+	Attempts  int                    `json:"attempts,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// FlowExecutor - выполняет шаги потоков над записями подключения-триггера.
+// Данные потока - поля записи, к которым шаги добавляют свои результаты
+// под ключом ID шага. Шаги delay и run_at сохраняют продолжение потока
+// в flow_jobs, откуда его забирает фоновый цикл.
+type FlowExecutor struct {
+	maxAttempts int
+	retryDelay  time.Duration
+	flowRepo    domain.FlowRepository
+	jobRepo     domain.FlowJobRepository
+	connRepo    domain.ConnectionRepository
+	connectors  domain.ConnectorRegistry
+	syncUC      *SyncUseCase
+	runs        *SyncRunUseCase
+	logger      domain.Logger
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewFlowExecutor(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	flowRepo domain.FlowRepository,
+	jobRepo domain.FlowJobRepository,
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	syncUC *SyncUseCase,
+	runs *SyncRunUseCase,
+	logger domain.Logger,
+) *FlowExecutor {
+	x := &FlowExecutor{
+		maxAttempts: cfg.SyncMaxAttempts,
+		retryDelay:  syncRetryDelay,
+		flowRepo:    flowRepo,
+		jobRepo:     jobRepo,
+		connRepo:    connRepo,
+		connectors:  connectors,
+		syncUC:      syncUC,
+		runs:        runs,
+		logger:      logger,
+		stop:        make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			x.wg.Add(1)
+			go x.jobLoop()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			x.logger.Info("Waiting for running flow jobs")
+			close(x.stop)
+
+			done := make(chan struct{})
+			go func() {
+				x.wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return x
+}
+
+// Trigger - запустить активные потоки подключения, триггер которых совпал с типом события
+func (x *FlowExecutor) Trigger(ctx context.Context, conn *models.Connection, record *SourceRecord) ([]FlowRun, error) {
+	flows, err := x.flowRepo.GetActiveByTrigger(ctx, conn.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]FlowRun, 0, len(flows))
+	for i := range flows {
+		if !domain.MatchEventType(flows[i].TriggerEventType, record.EventType) {
+			continue
+		}
+		if run := x.filterTrigger(ctx, &flows[i], conn, record); run != nil {
+			runs = append(runs, *run)
+			continue
+		}
+		runs = append(runs, *x.Run(ctx, &flows[i], conn, record))
+	}
+
+	return runs, nil
+}
+
+// filterTrigger - фильтр триггера потока. Для записи, не прошедшей фильтр,
+// возвращает итог запуска и пишет пропуск в журнал; nil - поток нужно выполнить.
+func (x *FlowExecutor) filterTrigger(ctx context.Context, flow *models.Flow, trigger *models.Connection, record *SourceRecord) *FlowRun {
+	passed, err := matchFilter(flow.TriggerFilter, flattenSourcePayload(record.Payload))
+	if err == nil && passed {
+		return nil
+	}
+
+	run := &FlowRun{
+		FlowID:        flow.ID,
+		CorrelationID: record.CorrelationID,
+		Status:        FlowStatusFiltered,
+		Steps:         []FlowStepResult{},
+	}
+	log := &models.SyncLog{
+		SourceConnectionID: trigger.ID,
+		EventType:          record.EventType,
+		Status:             SyncStatusSkipped,
+		Action:             SyncActionFiltered,
+		SourceData:         marshalData(record.Payload),
+		ErrorMessage:       filterSkippedMessage,
+		SourceRecordID:     record.RecordID,
+		IdempotencyKey:     record.IdempotencyKey,
+		CorrelationID:      record.CorrelationID,
+		SyncID:             record.SyncID,
+		FlowID:             flow.ID,
+	}
+	if err != nil {
+		x.logger.Error("FlowExecutor: Trigger filter failed", err, "flow_id", flow.ID)
+		run.Status = FlowStatusError
+		run.Error = err.Error()
+		log.Status = SyncStatusError
+		log.Action = ""
+		log.ErrorMessage = err.Error()
+	}
+	x.syncUC.LogTrace(ctx, log)
+
+	return run
+}
+
+// Run - выполнить поток над записью. Шаг delay откладывает оставшиеся шаги.
+func (x *FlowExecutor) Run(ctx context.Context, flow *models.Flow, trigger *models.Connection, record *SourceRecord) *FlowRun {
+	if record.CorrelationID == "" {
+		record.CorrelationID = utils.GenerateUUID()
+	}
+
+	x.logger.Info("FlowExecutor: Running flow", "flow_id", flow.ID, "trigger_id", trigger.ID, "correlation_id", record.CorrelationID)
+
+	source := flattenSourcePayload(record.Payload)
+	data := make(map[string]interface{}, len(source))
+	for k, v := range source {
+		data[k] = v
+	}
+
+	run := &FlowRun{
+		FlowID:        flow.ID,
+		CorrelationID: record.CorrelationID,
+		Steps:         make([]FlowStepResult, 0, len(flow.Steps)),
+	}
+	x.runSteps(ctx, flow, trigger, record, data, 0, run)
+
+	return run
+}
+
+func (x *FlowExecutor) runSteps(ctx context.Context, flow *models.Flow, trigger *models.Connection, record *SourceRecord, data map[string]interface{}, from int, run *FlowRun) {
+	for i := from; i < len(flow.Steps); i++ {
+		step := &flow.Steps[i]
+		input := marshalData(data)
+
+		// Ветки router выполняются независимо: ошибка одной не останавливает поток
+		if step.Type == FlowStepRouter {
+			output, results := x.route(ctx, flow, trigger, record, step, data, input)
+			run.Steps = append(run.Steps, results...)
+			data[step.ID] = output
+			continue
+		}
+
+		var attempts int
+		output, status, err := x.runStep(ctx, step, data, &attempts)
+		if err == nil && status == FlowStatusDelayed {
+			output["job_id"], err = x.schedule(ctx, flow, trigger, record, step, data, output["run_at"].(time.Time))
+		}
+
+		result := FlowStepResult{
+			StepID:   step.ID,
+			Type:     step.Type,
+			Status:   status,
+			Output:   output,
+			Attempts: attempts,
+		}
+		if err != nil {
+			result.Status = FlowStatusError
+			result.Error = err.Error()
+		}
+		result.SyncLogID = x.logStep(ctx, flow, trigger, record, step, input, &result)
+		run.Steps = append(run.Steps, result)
+
+		switch {
+		case err != nil:
+			x.logger.Error("FlowExecutor: Step failed", err, "flow_id", flow.ID, "step", step.ID)
+			run.Status = FlowStatusError
+			run.Error = err.Error()
+			return
+		case status == FlowStatusFiltered:
+			run.Status = FlowStatusFiltered
+			return
+		case status == FlowStatusDelayed:
+			run.Status = FlowStatusDelayed
+			return
+		}
+
+		if output != nil {
+			data[step.ID] = output
+		}
+	}
+
+	run.Status = FlowStatusSuccess
+	for _, result := range run.Steps {
+		if result.Status == FlowStatusError {
+			run.Status = FlowStatusError
+			run.Error = result.StepID + ": " + result.Error
+			break
+		}
+	}
+}
+
+// route - ветки router выполняются параллельно, у каждой свои повторы и
+// запись журнала. Выход шага - итоги веток по их ID.
+func (x *FlowExecutor) route(ctx context.Context, flow *models.Flow, trigger *models.Connection, record *SourceRecord, step *models.FlowStep, data map[string]interface{}, input json.RawMessage) (map[string]interface{}, []FlowStepResult) {
+	results := make([]FlowStepResult, len(step.Routes))
+
+	var wg sync.WaitGroup
+	for i := range step.Routes {
+		action := routeAction(step, &step.Routes[i])
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := FlowStepResult{StepID: action.ID, Type: FlowStepRouter}
+
+			passed, err := matchFilter(step.Routes[i].Condition, data)
+			if err == nil && passed {
+				result.Output, result.Status, err = x.action(ctx, &action, data, &result.Attempts)
+			} else if err == nil {
+				result.Status = FlowStatusFiltered
+			}
+			if err != nil {
+				x.logger.Error("FlowExecutor: Route failed", err, "flow_id", flow.ID, "route", action.ID)
+				result.Status = FlowStatusError
+				result.Error = err.Error()
+			}
+
+			result.SyncLogID = x.logStep(ctx, flow, trigger, record, &action, input, &result)
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	output := make(map[string]interface{}, len(results))
+	for i, result := range results {
+		routeOutput := map[string]interface{}{"status": result.Status}
+		for k, v := range result.Output {
+			routeOutput[k] = v
+		}
+		if result.Error != "" {
+			routeOutput["error"] = result.Error
+		}
+		output[step.Routes[i].ID] = routeOutput
+	}
+
+	return output, results
+}
+
+// routeAction - ветка router как шаг action с ID "<шаг>.<ветка>"
+func routeAction(step *models.FlowStep, route *models.FlowRoute) models.FlowStep {
+	return models.FlowStep{
+		ID:           step.ID + "." + route.ID,
+		Type:         FlowStepAction,
+		ConnectionID: route.ConnectionID,
+		Entity:       route.Entity,
+		Mappings:     route.Mappings,
+		Operation:    route.Operation,
+		RecordID:     route.RecordID,
+	}
+}
+
+func (x *FlowExecutor) runStep(ctx context.Context, step *models.FlowStep, data map[string]interface{}, attempts *int) (map[string]interface{}, string, error) {
+	switch step.Type {
+	case FlowStepFilter:
+		if !matchFlowFilters(step.Filters, data) {
+			return nil, FlowStatusFiltered, nil
+		}
+		passed, err := matchFilter(step.Expression, data)
+		if err != nil {
+			return nil, "", err
+		}
+		if !passed {
+			return nil, FlowStatusFiltered, nil
+		}
+		return nil, FlowStatusSuccess, nil
+
+	case FlowStepTransform:
+		mapped := applyMappings(rulesToMappings(0, 0, step.Mappings), data)
+		for k, v := range mapped.Payload {
+			data[k] = v
+		}
+		return mapped.Payload, FlowStatusSuccess, nil
+
+	case FlowStepLookup:
+		return x.lookup(ctx, step, data)
+
+	case FlowStepAction:
+		return x.action(ctx, step, data, attempts)
+
+	case FlowStepDelay, FlowStepRunAt:
+		runAt, err := stepRunAt(step, data, time.Now())
+		if err != nil {
+			return nil, "", err
+		}
+		if runAt.After(time.Now()) {
+			return map[string]interface{}{"run_at": runAt}, FlowStatusDelayed, nil
+		}
+		// Время уже прошло - условие проверяется сразу
+		return x.wake(ctx, step, data)
+	}
+
+	return nil, "", domain.NewErrorf("unknown step type %q", step.Type)
+}
+
+// lookup - поиск записи в подключении по значению из данных потока
+func (x *FlowExecutor) lookup(ctx context.Context, step *models.FlowStep, data map[string]interface{}) (map[string]interface{}, string, error) {
+	conn, connector, err := x.connector(ctx, step.ConnectionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	matcher, ok := connector.(domain.RecordMatcher)
+	if !ok {
+		return nil, "", domain.NewErrorf("connector %q cannot search records", conn.SystemType)
+	}
+
+	value, _ := lookupField(data, step.Value)
+	ids := make([]string, 0)
+	if values := matchValues(step.Field, value); len(values) > 0 {
+		if ids, err = matcher.FindRecords(ctx, conn, stepEntity(step, connector), step.Field, values); err != nil {
+			return nil, "", err
+		}
+	}
+
+	output := map[string]interface{}{
+		"found": len(ids) > 0,
+		"ids":   ids,
+	}
+	if len(ids) > 0 {
+		output["id"] = ids[0]
+	}
+
+	return output, FlowStatusSuccess, nil
+}
+
+// action - создание или обновление записи в подключении; временные сбои повторяются
+func (x *FlowExecutor) action(ctx context.Context, step *models.FlowStep, data map[string]interface{}, attempts *int) (map[string]interface{}, string, error) {
+	conn, connector, err := x.connector(ctx, step.ConnectionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	writer, ok := connector.(domain.RecordWriter)
+	if !ok {
+		return nil, "", domain.NewErrorf("connector %q cannot write records", conn.SystemType)
+	}
+
+	mapped := applyMappings(rulesToMappings(0, step.ConnectionID, step.Mappings), data)
+	entity := stepEntity(step, connector)
+
+	recordID := ""
+	if step.Operation != FlowOperationCreate {
+		value, _ := lookupField(data, step.RecordID)
+		recordID = toString(value)
+	}
+	if step.Operation == FlowOperationUpdate && recordID == "" {
+		return nil, "", domain.NewErrorf("record ID %q is empty", step.RecordID)
+	}
+
+	action := SyncActionUpdated
+	if recordID == "" {
+		action = SyncActionCreated
+	}
+	err = retryTemporary(ctx, x.logger, x.maxAttempts, x.retryDelay, attempts, func() (err error) {
+		if action == SyncActionUpdated {
+			return writer.UpdateRecord(ctx, conn, entity, recordID, mapped.Payload)
+		}
+		recordID, err = writer.CreateRecord(ctx, conn, entity, mapped.Payload)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return map[string]interface{}{
+		"id":     recordID,
+		"action": action,
+		"fields": mapped.Payload,
+	}, FlowStatusSuccess, nil
+}
+
+func (x *FlowExecutor) connector(ctx context.Context, connectionID int) (*models.Connection, domain.Connector, error) {
+	conn, err := x.connRepo.GetByID(ctx, connectionID)
+	if err != nil {
+		return nil, nil, domain.NewErrorf("connection %d not found", connectionID)
+	}
+
+	if !conn.IsActive {
+		return nil, nil, domain.NewErrorf("connection %d is inactive", connectionID)
+	}
+
+	connector, err := x.connectors.Get(conn.SystemType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, connector, nil
+}
+
+// logStep - вход и выход шага в sync_logs. Шаги action уведомляют вебхуки
+// как обычная синхронизация, остальные пишутся без уведомлений.
+func (x *FlowExecutor) logStep(ctx context.Context, flow *models.Flow, trigger *models.Connection, record *SourceRecord, step *models.FlowStep, input json.RawMessage, result *FlowStepResult) int {
+	log := &models.SyncLog{
+		SourceConnectionID: trigger.ID,
+		TargetConnectionID: step.ConnectionID,
+		EventType:          record.EventType,
+		Status:             result.Status,
+		Action:             step.Type,
+		SourceData:         input,
+		TargetData:         marshalData(result.Output),
+		ErrorMessage:       result.Error,
+		SourceRecordID:     record.RecordID,
+		IdempotencyKey:     record.IdempotencyKey,
+		CorrelationID:      record.CorrelationID,
+		SyncID:             record.SyncID,
+		Attempts:           result.Attempts,
+		FlowID:             flow.ID,
+		FlowStep:           step.ID,
+	}
+	if result.Status == FlowStatusFiltered {
+		log.ErrorMessage = filterSkippedMessage
+	}
+	if step.Type == FlowStepAction && result.Output != nil {
+		log.TargetRecordID = toString(result.Output["id"])
+	}
+
+	var err error
+	if step.Type == FlowStepAction {
+		err = x.syncUC.LogSync(ctx, log)
+	} else {
+		err = x.syncUC.LogTrace(ctx, log)
+	}
+	if err != nil {
+		return 0
+	}
+	return log.ID
+}
+
+// matchFlowFilters - условия фильтра по путям в данных потока, с той же
+// семантикой, что и фильтры вебхуков: значение или список допустимых значений
+func matchFlowFilters(filters, data map[string]interface{}) bool {
+	resolved := make(map[string]interface{}, len(filters))
+	for path := range filters {
+		if value, ok := lookupField(data, path); ok {
+			resolved[path] = value
+		}
+	}
+	return domain.MatchEventFilters(filters, resolved)
+}
+
+func stepEntity(step *models.FlowStep, connector domain.Connector) string {
+	if step.Entity != "" {
+		return step.Entity
+	}
+	return connector.DefaultEntity()
+}
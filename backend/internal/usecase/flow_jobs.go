@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// Статусы отложенных шагов потоков
+const (
+	FlowJobPending   = "pending"
+	FlowJobRunning   = "running"
+	FlowJobDone      = "done"
+	FlowJobSkipped   = "skipped"
+	FlowJobCancelled = "cancelled"
+	FlowJobFailed    = "failed"
+)
+
+var flowJobStatuses = map[string]bool{
+	FlowJobPending:   true,
+	FlowJobRunning:   true,
+	FlowJobDone:      true,
+	FlowJobSkipped:   true,
+	FlowJobCancelled: true,
+	FlowJobFailed:    true,
+}
+
+const (
+	flowJobPollInterval = 15 * time.Second
+	flowJobBatchSize    = 20
+	// flowJobStaleAfter - задание в running дольше этого времени считается
+	// брошенным упавшим экземпляром и забирается заново
+	flowJobStaleAfter = 10 * time.Minute
+)
+
+// runAtLayouts - форматы времени для шага run_at помимо unix-времени
+var runAtLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// stepRunAt - время срабатывания шага: delay - через DelaySeconds, run_at -
+// время из поля данных потока или само значение run_at, сдвинутое на
+// DelaySeconds (отрицательный сдвиг - "за час до")
+func stepRunAt(step *models.FlowStep, data map[string]interface{}, now time.Time) (time.Time, error) {
+	offset := time.Duration(step.DelaySeconds) * time.Second
+	if step.Type == FlowStepDelay {
+		return now.Add(offset), nil
+	}
+
+	value, ok := lookupField(data, step.RunAt)
+	if !ok {
+		value = step.RunAt
+	}
+
+	runAt, err := parseRunAt(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return runAt.Add(offset), nil
+}
+
+func parseRunAt(value interface{}) (time.Time, error) {
+	if n, ok := value.(float64); ok {
+		return time.Unix(int64(n), 0), nil
+	}
+
+	s := strings.TrimSpace(toString(value))
+	if s == "" {
+		return time.Time{}, domain.NewError("run_at value is empty")
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	for _, layout := range runAtLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, domain.NewErrorf("cannot parse run_at time %q", s)
+}
+
+// schedule - сохранить продолжение потока с шага delay/run_at
+func (x *FlowExecutor) schedule(ctx context.Context, flow *models.Flow, trigger *models.Connection, record *SourceRecord, step *models.FlowStep, data map[string]interface{}, runAt time.Time) (int, error) {
+	job := &models.FlowJob{
+		FlowID:              flow.ID,
+		StepID:              step.ID,
+		TriggerConnectionID: trigger.ID,
+		EventType:           record.EventType,
+		SourceRecordID:      record.RecordID,
+		IdempotencyKey:      record.IdempotencyKey,
+		CorrelationID:       record.CorrelationID,
+		SyncID:              record.SyncID,
+		Payload:             record.Payload,
+		Data:                data,
+		RunAt:               runAt,
+		Status:              FlowJobPending,
+	}
+	if err := x.jobRepo.Create(ctx, job); err != nil {
+		return 0, err
+	}
+
+	x.logger.Info("FlowExecutor: Flow step scheduled", "flow_id", flow.ID, "step", step.ID, "job_id", job.ID, "run_at", runAt)
+	return job.ID, nil
+}
+
+// wake - срабатывание шага delay/run_at: если задана запись подключения, она
+// перечитывается, затем проверяется условие шага. Перечитанная запись
+// доступна условию и следующим шагам по пути "<id шага>.record".
+func (x *FlowExecutor) wake(ctx context.Context, step *models.FlowStep, data map[string]interface{}) (map[string]interface{}, string, error) {
+	output := map[string]interface{}{"fired_at": time.Now()}
+
+	if step.ConnectionID > 0 {
+		conn, connector, err := x.connector(ctx, step.ConnectionID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		reader, ok := connector.(domain.RecordReader)
+		if !ok {
+			return nil, "", domain.NewErrorf("connector %q cannot read records", conn.SystemType)
+		}
+
+		value, _ := lookupField(data, step.RecordID)
+		recordID := toString(value)
+		if recordID == "" {
+			return nil, "", domain.NewErrorf("record ID %q is empty", step.RecordID)
+		}
+
+		var current map[string]interface{}
+		err = retryTemporary(ctx, x.logger, x.maxAttempts, x.retryDelay, new(int), func() (err error) {
+			current, err = reader.GetRecord(ctx, conn, stepEntity(step, connector), recordID)
+			return err
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		output["found"] = current != nil
+		output["id"] = recordID
+		output["record"] = current
+	}
+
+	if step.Expression != "" {
+		scope := make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			scope[k] = v
+		}
+		scope[step.ID] = output
+
+		passed, err := matchFilter(step.Expression, scope)
+		if err != nil {
+			return nil, "", err
+		}
+		if !passed {
+			return output, FlowStatusFiltered, nil
+		}
+	}
+
+	return output, FlowStatusSuccess, nil
+}
+
+// jobLoop - периодически выполняет отложенные шаги, время которых подошло
+func (x *FlowExecutor) jobLoop() {
+	defer x.wg.Done()
+
+	ticker := time.NewTicker(flowJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-x.stop:
+			return
+		case <-ticker.C:
+			x.runDueJobs(context.Background())
+		}
+	}
+}
+
+func (x *FlowExecutor) runDueJobs(ctx context.Context) {
+	jobs, err := x.jobRepo.ClaimDue(ctx, flowJobBatchSize, flowJobStaleAfter)
+	if err != nil {
+		return
+	}
+
+	for i := range jobs {
+		status, errMsg := x.resume(ctx, &jobs[i])
+		x.logger.Info("FlowExecutor: Flow job finished", "job_id", jobs[i].ID, "flow_id", jobs[i].FlowID, "status", status)
+
+		if err := x.jobRepo.Finish(ctx, jobs[i].ID, status, errMsg); err != nil {
+			x.logger.Error("Failed to save flow job status", err, "job_id", jobs[i].ID)
+		}
+		// Шаги пишутся в журнал исходного запуска, его счетчики пересчитываются
+		x.runs.RefreshRun(ctx, jobs[i].SyncID)
+	}
+}
+
+// resume - продолжить поток с шага задания. Поток берется в текущей версии:
+// если шаг удален или поток выключен, задание не выполняется.
+func (x *FlowExecutor) resume(ctx context.Context, job *models.FlowJob) (string, string) {
+	flow, err := x.flowRepo.GetByID(ctx, job.FlowID)
+	if err != nil {
+		return FlowJobFailed, "flow not found"
+	}
+	if !flow.IsActive {
+		return FlowJobCancelled, "flow is inactive"
+	}
+
+	index := -1
+	for i := range flow.Steps {
+		if flow.Steps[i].ID == job.StepID {
+			index = i
+			break
+		}
+	}
+	if index < 0 || (flow.Steps[index].Type != FlowStepDelay && flow.Steps[index].Type != FlowStepRunAt) {
+		return FlowJobFailed, "step " + job.StepID + " no longer exists"
+	}
+
+	trigger, err := x.connRepo.GetByID(ctx, job.TriggerConnectionID)
+	if err != nil {
+		return FlowJobFailed, "trigger connection not found"
+	}
+
+	record := &SourceRecord{
+		EventType:      job.EventType,
+		RecordID:       job.SourceRecordID,
+		IdempotencyKey: job.IdempotencyKey,
+		CorrelationID:  job.CorrelationID,
+		SyncID:         job.SyncID,
+		Payload:        job.Payload,
+	}
+	data := job.Data
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	step := &flow.Steps[index]
+	input := marshalData(data)
+
+	output, status, err := x.wake(ctx, step, data)
+	result := FlowStepResult{
+		StepID: step.ID,
+		Type:   step.Type,
+		Status: status,
+		Output: output,
+	}
+	if err != nil {
+		result.Status = FlowStatusError
+		result.Error = err.Error()
+	}
+	x.logStep(ctx, flow, trigger, record, step, input, &result)
+
+	switch {
+	case err != nil:
+		return FlowJobFailed, err.Error()
+	case status == FlowStatusFiltered:
+		return FlowJobSkipped, filterSkippedMessage
+	}
+
+	data[step.ID] = output
+	run := &FlowRun{FlowID: flow.ID, CorrelationID: record.CorrelationID}
+	x.runSteps(ctx, flow, trigger, record, data, index+1, run)
+
+	if run.Status == FlowStatusError {
+		return FlowJobFailed, run.Error
+	}
+	return FlowJobDone, ""
+}
@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// maxFlowDelaySeconds - отложенные шаги хранятся в flow_jobs и переживают перезапуск,
+// ограничение защищает от опечаток в задержке
+const maxFlowDelaySeconds = 90 * 24 * 60 * 60
+
+var flowOperations = map[string]bool{
+	FlowOperationCreate: true,
+	FlowOperationUpdate: true,
+	FlowOperationUpsert: true,
+}
+
+type FlowUseCase struct {
+	repo       domain.FlowRepository
+	jobRepo    domain.FlowJobRepository
+	connRepo   domain.ConnectionRepository
+	connectors domain.ConnectorRegistry
+	executor   *FlowExecutor
+	logger     domain.Logger
+}
+
+func NewFlowUseCase(
+	repo domain.FlowRepository,
+	jobRepo domain.FlowJobRepository,
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	executor *FlowExecutor,
+	logger domain.Logger,
+) *FlowUseCase {
+	return &FlowUseCase{
+		repo:       repo,
+		jobRepo:    jobRepo,
+		connRepo:   connRepo,
+		connectors: connectors,
+		executor:   executor,
+		logger:     logger,
+	}
+}
+
+func (uc *FlowUseCase) GetAllFlows(ctx context.Context) ([]models.Flow, error) {
+	return uc.repo.GetAll(ctx)
+}
+
+func (uc *FlowUseCase) GetFlowByID(ctx context.Context, id int) (*models.Flow, error) {
+	flow, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, domain.NewErrorf("flow %d not found", id)
+	}
+	return flow, nil
+}
+
+func (uc *FlowUseCase) CreateFlow(ctx context.Context, flow *models.Flow) error {
+	uc.logger.Info("UseCase: Creating flow", "name", flow.Name)
+
+	if err := uc.validateFlow(ctx, flow); err != nil {
+		return err
+	}
+
+	return uc.repo.Create(ctx, flow)
+}
+
+func (uc *FlowUseCase) UpdateFlow(ctx context.Context, flow *models.Flow) error {
+	uc.logger.Info("UseCase: Updating flow", "id", flow.ID)
+
+	current, err := uc.GetFlowByID(ctx, flow.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.validateFlow(ctx, flow); err != nil {
+		return err
+	}
+
+	flow.CreatedAt = current.CreatedAt
+	return uc.repo.Update(ctx, flow)
+}
+
+func (uc *FlowUseCase) DeleteFlow(ctx context.Context, id int) error {
+	uc.logger.Info("UseCase: Deleting flow", "id", id)
+	return uc.repo.Delete(ctx, id)
+}
+
+// RunFlow - ручной запуск потока с переданными данными, например для проверки шагов.
+// Поток запускается, даже если он выключен.
+func (uc *FlowUseCase) RunFlow(ctx context.Context, id int, payload map[string]interface{}) (*FlowRun, error) {
+	flow, err := uc.GetFlowByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	trigger, err := uc.connRepo.GetByID(ctx, flow.TriggerConnectionID)
+	if err != nil {
+		return nil, domain.NewErrorf("connection %d not found", flow.TriggerConnectionID)
+	}
+
+	// Шаблон триггера не является типом события, для него используется тип входящих вебхуков
+	eventType := flow.TriggerEventType
+	if strings.Contains(eventType, "*") {
+		eventType = InboundEventType
+	}
+
+	record := &SourceRecord{
+		EventType: eventType,
+		RecordID:  sourceRecordID(trigger, payload),
+		Payload:   payload,
+	}
+	record.SyncID = uc.executor.runs.StartRun(ctx, &models.SyncRun{
+		TriggerType:        SyncRunManual,
+		SourceConnectionID: trigger.ID,
+		FlowID:             flow.ID,
+	})
+
+	run := uc.executor.filterTrigger(ctx, flow, trigger, record)
+	if run == nil {
+		run = uc.executor.Run(ctx, flow, trigger, record)
+	}
+	uc.executor.runs.FinishRun(ctx, record.SyncID, nil)
+
+	return run, nil
+}
+
+// GetFlowJobs - отложенные шаги потоков по фильтру
+func (uc *FlowUseCase) GetFlowJobs(ctx context.Context, filter models.FlowJobFilter) ([]models.FlowJob, error) {
+	if filter.Status != "" && !flowJobStatuses[filter.Status] {
+		return nil, domain.NewErrorf("unknown job status %q", filter.Status)
+	}
+	return uc.jobRepo.Find(ctx, filter)
+}
+
+// CancelFlowJob - отменить ожидающий шаг. Уже выполненное задание не отменяется.
+func (uc *FlowUseCase) CancelFlowJob(ctx context.Context, id int) error {
+	uc.logger.Info("UseCase: Cancelling flow job", "id", id)
+
+	job, err := uc.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	cancelled, err := uc.jobRepo.Cancel(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return domain.NewErrorf("flow job %d is %s and cannot be cancelled", job.ID, job.Status)
+	}
+	return nil
+}
+
+// CancelRecordJobs - отменить ожидающие шаги по записи-триггеру, например
+// когда сделка закрыта и напоминание больше не нужно. flowID = 0 - во всех потоках.
+func (uc *FlowUseCase) CancelRecordJobs(ctx context.Context, connectionID int, recordID string, flowID int) (int, error) {
+	recordID = strings.TrimSpace(recordID)
+	if connectionID <= 0 || recordID == "" {
+		return 0, domain.NewError("connection_id and record_id are required")
+	}
+
+	uc.logger.Info("UseCase: Cancelling flow jobs for record", "connection_id", connectionID, "record_id", recordID, "flow_id", flowID)
+	return uc.jobRepo.CancelByRecord(ctx, connectionID, recordID, flowID)
+}
+
+// validateFlow - проверка триггера и шагов перед сохранением
+func (uc *FlowUseCase) validateFlow(ctx context.Context, flow *models.Flow) error {
+	flow.Name = strings.TrimSpace(flow.Name)
+	if flow.Name == "" {
+		return domain.NewError("flow name is required")
+	}
+
+	if _, err := uc.connRepo.GetByID(ctx, flow.TriggerConnectionID); err != nil {
+		return domain.NewErrorf("trigger connection %d not found", flow.TriggerConnectionID)
+	}
+
+	flow.TriggerEventType = strings.TrimSpace(flow.TriggerEventType)
+	if flow.TriggerEventType == "" {
+		return domain.NewError("trigger event type is required")
+	}
+
+	flow.TriggerFilter = strings.TrimSpace(flow.TriggerFilter)
+	if err := validateFilter(flow.TriggerFilter); err != nil {
+		return domain.NewErrorf("trigger filter: %v", err)
+	}
+
+	if len(flow.Steps) == 0 {
+		return domain.NewError("flow must have at least one step")
+	}
+
+	seen := make(map[string]bool, len(flow.Steps))
+	for i := range flow.Steps {
+		step := &flow.Steps[i]
+
+		step.ID = strings.TrimSpace(step.ID)
+		if step.ID == "" {
+			return domain.NewErrorf("step %d: ID is required", i+1)
+		}
+		if seen[step.ID] {
+			return domain.NewErrorf("duplicate step ID %q", step.ID)
+		}
+		seen[step.ID] = true
+
+		if err := uc.validateStep(ctx, step); err != nil {
+			return domain.NewErrorf("step %q: %v", step.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (uc *FlowUseCase) validateStep(ctx context.Context, step *models.FlowStep) error {
+	switch step.Type {
+	case FlowStepFilter:
+		step.Expression = strings.TrimSpace(step.Expression)
+		if len(step.Filters) == 0 && step.Expression == "" {
+			return domain.NewError("filters or expression are required")
+		}
+		return validateFilter(step.Expression)
+
+	case FlowStepTransform:
+		return validateStepMappings(step.Mappings)
+
+	case FlowStepLookup:
+		connector, err := uc.stepConnector(ctx, step)
+		if err != nil {
+			return err
+		}
+		if _, ok := connector.(domain.RecordMatcher); !ok {
+			return domain.NewErrorf("connector %q cannot search records", connector.SystemType())
+		}
+		if step.Field == "" || step.Value == "" {
+			return domain.NewError("field and value are required")
+		}
+
+	case FlowStepAction:
+		connector, err := uc.stepConnector(ctx, step)
+		if err != nil {
+			return err
+		}
+		if _, ok := connector.(domain.RecordWriter); !ok {
+			return domain.NewErrorf("connector %q cannot write records", connector.SystemType())
+		}
+		if step.Operation == "" {
+			step.Operation = FlowOperationCreate
+		}
+		if !flowOperations[step.Operation] {
+			return domain.NewErrorf("unknown operation %q", step.Operation)
+		}
+		if step.Operation != FlowOperationCreate && step.RecordID == "" {
+			return domain.NewErrorf("record_id is required for %s", step.Operation)
+		}
+		return validateStepMappings(step.Mappings)
+
+	case FlowStepRouter:
+		if len(step.Routes) == 0 {
+			return domain.NewError("routes are required")
+		}
+		seen := make(map[string]bool, len(step.Routes))
+		for i := range step.Routes {
+			route := &step.Routes[i]
+			route.ID = strings.TrimSpace(route.ID)
+			if route.ID == "" {
+				return domain.NewErrorf("route %d: ID is required", i+1)
+			}
+			if seen[route.ID] {
+				return domain.NewErrorf("duplicate route ID %q", route.ID)
+			}
+			seen[route.ID] = true
+
+			route.Condition = strings.TrimSpace(route.Condition)
+			if err := validateFilter(route.Condition); err != nil {
+				return domain.NewErrorf("route %q: %v", route.ID, err)
+			}
+
+			action := routeAction(step, route)
+			if err := uc.validateStep(ctx, &action); err != nil {
+				return domain.NewErrorf("route %q: %v", route.ID, err)
+			}
+			route.Operation = action.Operation
+		}
+
+	case FlowStepDelay:
+		if step.DelaySeconds <= 0 || step.DelaySeconds > maxFlowDelaySeconds {
+			return domain.NewErrorf("delay_seconds must be between 1 and %d", maxFlowDelaySeconds)
+		}
+		return uc.validateWake(ctx, step)
+
+	case FlowStepRunAt:
+		step.RunAt = strings.TrimSpace(step.RunAt)
+		if step.RunAt == "" {
+			return domain.NewError("run_at is required")
+		}
+		if step.DelaySeconds < -maxFlowDelaySeconds || step.DelaySeconds > maxFlowDelaySeconds {
+			return domain.NewErrorf("delay_seconds must be between %d and %d", -maxFlowDelaySeconds, maxFlowDelaySeconds)
+		}
+		return uc.validateWake(ctx, step)
+
+	default:
+		return domain.NewErrorf("unknown step type %q", step.Type)
+	}
+
+	return nil
+}
+
+// validateWake - условие и перечитываемая запись шагов delay/run_at
+func (uc *FlowUseCase) validateWake(ctx context.Context, step *models.FlowStep) error {
+	step.Expression = strings.TrimSpace(step.Expression)
+	if err := validateFilter(step.Expression); err != nil {
+		return err
+	}
+	if step.ConnectionID == 0 {
+		return nil
+	}
+
+	connector, err := uc.stepConnector(ctx, step)
+	if err != nil {
+		return err
+	}
+	if _, ok := connector.(domain.RecordReader); !ok {
+		return domain.NewErrorf("connector %q cannot read records", connector.SystemType())
+	}
+	if step.RecordID == "" {
+		return domain.NewError("record_id is required to re-read the record")
+	}
+	return nil
+}
+
+func (uc *FlowUseCase) stepConnector(ctx context.Context, step *models.FlowStep) (domain.Connector, error) {
+	conn, err := uc.connRepo.GetByID(ctx, step.ConnectionID)
+	if err != nil {
+		return nil, domain.NewErrorf("connection %d not found", step.ConnectionID)
+	}
+	return uc.connectors.Get(conn.SystemType)
+}
+
+func validateStepMappings(rules []models.MappingRule) error {
+	if len(rules) == 0 {
+		return domain.NewError("mappings are required")
+	}
+	for _, rule := range rules {
+		if rule.SourceField == "" || rule.TargetField == "" {
+			return domain.NewError("mapping source and target fields are required")
+		}
+		if err := validateTransforms(rule.Transform); err != nil {
+			return err
+		}
+	}
+	return nil
+}
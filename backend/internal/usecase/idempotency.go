@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"integration-app/internal/domain/models"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// providerEventIDs - где провайдеры передают собственный ID события
+var providerEventIDs = []struct {
+	prefix string
+	paths  []string
+}{
+	// Facebook Lead Ads: сохраненный лид или сырое уведомление webhooks
+	{"leadgen", []string{"leadgen_id"}},
+	{"leadgen", []string{"entry.0.changes.0.value.leadgen_id"}},
+	{"event", []string{"event_id"}},
+}
+
+// bitrixEventPaths - событие и ID записи исходящего вебхука Bitrix24:
+// event=ONCRMLEADADD&data[FIELDS][ID]=123 или то же в JSON
+var bitrixEventPaths = [][]string{
+	{"event", "data[FIELDS][ID]"},
+	{"event", "data.FIELDS.ID"},
+}
+
+// idempotencyKey - ключ дедупликации входящего события, по приоритету:
+// заголовок Idempotency-Key, хэш полей из metadata.idempotency_fields
+// подключения, ID события провайдера, хэш всего тела
+func idempotencyKey(conn *models.Connection, headerKey string, payload map[string]interface{}) string {
+	if headerKey = strings.TrimSpace(headerKey); headerKey != "" {
+		return limitKey("header:" + headerKey)
+	}
+
+	source := flattenSourcePayload(payload)
+
+	if fields := metadataStrings(conn, "idempotency_fields"); len(fields) > 0 {
+		values := make([]string, 0, len(fields))
+		for _, field := range fields {
+			value, _ := lookupField(source, field)
+			values = append(values, toString(value))
+		}
+		return "fields:" + hashKey(strings.Join(values, "\x1f"))
+	}
+
+	if key, ok := bitrixEventKey(source, payload); ok {
+		return limitKey(key)
+	}
+
+	for _, provider := range providerEventIDs {
+		if values, ok := lookupAll(source, provider.paths); ok {
+			return limitKey(provider.prefix + ":" + strings.Join(values, ":"))
+		}
+	}
+
+	// json.Marshal сортирует ключи, поэтому одинаковые тела дают одинаковый хэш
+	body, _ := json.Marshal(payload)
+	return "payload:" + hashKey(string(body))
+}
+
+// bitrixEventKey - ключ события Bitrix24. ID доставки Bitrix24 не передает,
+// поэтому событие+ID записи однозначно только для *ADD: запись создается
+// один раз. Для остальных событий (изменения, удаления одной и той же
+// записи) в ключ добавляется ts доставки, а без него - хэш тела, чтобы
+// отсекались только повторные доставки, а не следующие изменения.
+func bitrixEventKey(source, payload map[string]interface{}) (string, bool) {
+	for _, paths := range bitrixEventPaths {
+		values, ok := lookupAll(source, paths)
+		if !ok {
+			continue
+		}
+
+		key := "bitrix24:" + strings.Join(values, ":")
+		if strings.HasSuffix(strings.ToUpper(values[0]), "ADD") {
+			return key, true
+		}
+		if ts := toString(source["ts"]); ts != "" {
+			return key + ":" + ts, true
+		}
+		body, _ := json.Marshal(payload)
+		return key + ":" + hashKey(string(body)), true
+	}
+	return "", false
+}
+
+func lookupAll(payload map[string]interface{}, paths []string) ([]string, bool) {
+	values := make([]string, 0, len(paths))
+	for _, path := range paths {
+		value, ok := lookupField(payload, path)
+		if !ok || toString(value) == "" {
+			return nil, false
+		}
+		values = append(values, toString(value))
+	}
+	return values, true
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// limitKey - длинные ключи заменяются хэшем, чтобы поместиться в колонку
+func limitKey(key string) string {
+	if len(key) <= maxIdempotencyKeyLength {
+		return key
+	}
+	return key[:strings.Index(key, ":")+1] + hashKey(key)
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"integration-app/internal/domain/models"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	plain := &models.Connection{ID: 1}
+	withFields := &models.Connection{ID: 2, Metadata: json.RawMessage(`{"idempotency_fields": "email, form_id"}`)}
+
+	bitrixUpdate := map[string]interface{}{
+		"event": "ONCRMDEALUPDATE",
+		"data":  map[string]interface{}{"FIELDS": map[string]interface{}{"ID": "123"}},
+		"ts":    "1760000000",
+	}
+	facebookLead := map[string]interface{}{"leadgen_id": "987", "event_id": "ev-1"}
+	form := map[string]interface{}{"email": "a@b.c", "form_id": "5", "name": "Ivan"}
+
+	tests := []struct {
+		name    string
+		conn    *models.Connection
+		header  string
+		payload map[string]interface{}
+		want    string
+	}{
+		{"header wins over everything", withFields, " req-1 ", bitrixUpdate, "header:req-1"},
+		{"metadata fields win over provider id", withFields, "", map[string]interface{}{"email": "a@b.c", "form_id": "5", "leadgen_id": "987"}, "fields:" + hashKey("a@b.c\x1f5")},
+		{"missing metadata field is empty", withFields, "", map[string]interface{}{"email": "a@b.c"}, "fields:" + hashKey("a@b.c\x1f")},
+		{"bitrix add: event and record id", plain, "", map[string]interface{}{"event": "ONCRMLEADADD", "data[FIELDS][ID]": "55", "ts": "1760000000"}, "bitrix24:ONCRMLEADADD:55"},
+		{"bitrix update includes ts", plain, "", bitrixUpdate, "bitrix24:ONCRMDEALUPDATE:123:1760000000"},
+		{"bitrix form-urlencoded update", plain, "", map[string]interface{}{"event": "ONCRMDEALUPDATE", "data[FIELDS][ID]": "123", "ts": "1760000100"}, "bitrix24:ONCRMDEALUPDATE:123:1760000100"},
+		{"bitrix wins over generic event id", plain, "", map[string]interface{}{"event": "ONCRMLEADADD", "data[FIELDS][ID]": "55", "event_id": "ev-1"}, "bitrix24:ONCRMLEADADD:55"},
+		{"facebook lead id wins over event id", plain, "", facebookLead, "leadgen:987"},
+		{"facebook raw webhook", plain, "", map[string]interface{}{"entry": []interface{}{map[string]interface{}{"changes": []interface{}{map[string]interface{}{"value": map[string]interface{}{"leadgen_id": "654"}}}}}}, "leadgen:654"},
+		{"facebook field_data payload", plain, "", map[string]interface{}{"field_data": []interface{}{map[string]interface{}{"name": "leadgen_id", "values": []interface{}{"321"}}}}, "leadgen:321"},
+		{"generic event id", plain, "", map[string]interface{}{"event_id": "ev-1", "x": "1"}, "event:ev-1"},
+		{"empty provider id falls through", plain, "", map[string]interface{}{"leadgen_id": "", "event_id": "ev-2"}, "event:ev-2"},
+		{"payload hash", plain, "", form, "payload:" + hashKey(`{"email":"a@b.c","form_id":"5","name":"Ivan"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idempotencyKey(tt.conn, tt.header, tt.payload); got != tt.want {
+				t.Errorf("idempotencyKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyBitrixRedeliveries(t *testing.T) {
+	conn := &models.Connection{ID: 1}
+	update := func(ts string, title string) map[string]interface{} {
+		payload := map[string]interface{}{
+			"event":            "ONCRMDEALUPDATE",
+			"data[FIELDS][ID]": "123",
+			"title":            title,
+		}
+		if ts != "" {
+			payload["ts"] = ts
+		}
+		return payload
+	}
+
+	tests := []struct {
+		name      string
+		a, b      map[string]interface{}
+		duplicate bool
+	}{
+		{"redelivery of the same update", update("100", "x"), update("100", "x"), true},
+		{"later update of the same deal", update("100", "x"), update("200", "x"), false},
+		{"no ts: same body is a redelivery", update("", "x"), update("", "x"), true},
+		{"no ts: different body is a new update", update("", "x"), update("", "y"), false},
+		{
+			"add is deduplicated by record id",
+			map[string]interface{}{"event": "ONCRMDEALADD", "data[FIELDS][ID]": "123", "ts": "100"},
+			map[string]interface{}{"event": "ONCRMDEALADD", "data[FIELDS][ID]": "123", "ts": "200"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := idempotencyKey(conn, "", tt.a), idempotencyKey(conn, "", tt.b)
+			if (a == b) != tt.duplicate {
+				t.Errorf("keys %q and %q: duplicate = %v, want %v", a, b, a == b, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyLength(t *testing.T) {
+	conn := &models.Connection{ID: 1}
+
+	long := strings.Repeat("k", maxIdempotencyKeyLength)
+	key := idempotencyKey(conn, long, nil)
+	if len(key) > maxIdempotencyKeyLength {
+		t.Fatalf("key length %d exceeds %d", len(key), maxIdempotencyKeyLength)
+	}
+	if key != "header:"+hashKey("header:"+long) {
+		t.Errorf("long key = %q, want prefix with hash", key)
+	}
+
+	short := idempotencyKey(conn, "abc", nil)
+	if short != "header:abc" {
+		t.Errorf("short key = %q, want it unchanged", short)
+	}
+}
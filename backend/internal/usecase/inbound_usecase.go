@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/utils"
+)
+
+const (
+	// InboundEventType - тип события в sync_logs для данных входящих вебхуков
+	InboundEventType = "webhook"
+
+	inboundSamplesKept = 20
+	inboundTokenBytes  = 24
+)
+
+// InboundUseCase - прием данных на входящие URL подключений типа webhook
+// (формы Tilda, лендинги, скрипты) и передача их в конвейер синхронизации
+type InboundUseCase struct {
+	connRepo      domain.ConnectionRepository
+	samples       domain.InboundSampleRepository
+	events        domain.InboundEventRepository
+	engine        *SyncEngine
+	flows         *FlowExecutor
+	runs          *SyncRunUseCase
+	publicBaseURL string
+	dedupeWindow  time.Duration
+	logger        domain.Logger
+}
+
+func NewInboundUseCase(
+	cfg *config.Config,
+	connRepo domain.ConnectionRepository,
+	samples domain.InboundSampleRepository,
+	events domain.InboundEventRepository,
+	engine *SyncEngine,
+	flows *FlowExecutor,
+	runs *SyncRunUseCase,
+	logger domain.Logger,
+) *InboundUseCase {
+	return &InboundUseCase{
+		connRepo:      connRepo,
+		samples:       samples,
+		events:        events,
+		engine:        engine,
+		flows:         flows,
+		runs:          runs,
+		publicBaseURL: strings.TrimRight(cfg.PublicBaseURL, "/"),
+		dedupeWindow:  time.Duration(cfg.InboundDedupeWindow) * time.Hour,
+		logger:        logger,
+	}
+}
+
+// InboundEndpoint - входящий URL подключения и последние принятые данные
+type InboundEndpoint struct {
+	ConnectionID int                    `json:"connection_id"`
+	URL          string                 `json:"url"`
+	Samples      []models.InboundSample `json:"samples"`
+}
+
+// InboundRecordResult - итог одной записи из массива входящего запроса
+type InboundRecordResult struct {
+	Index   int          `json:"index"`
+	Status  string       `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Results []SyncResult `json:"results"`
+}
+
+// Receive - принять запись по токену входящего URL. Запись сохраняется как
+// пример для вывода схемы и синхронизируется во все настроенные цели.
+// Повторная доставка с тем же ключом идемпотентности в пределах окна
+// не синхронизируется, а попадает в журнал со статусом duplicate.
+// Новые записи также запускают потоки подключения; шаги потоков
+// записываются в журнал синхронизации.
+func (uc *InboundUseCase) Receive(ctx context.Context, token, contentType, headerKey string, payload map[string]interface{}) ([]SyncResult, error) {
+	conn, err := uc.activeConnection(ctx, token, contentType)
+	if err != nil {
+		return nil, err
+	}
+	uc.storeSample(ctx, conn, contentType, payload)
+
+	record := uc.newRecord(conn, headerKey, payload)
+	record.SyncID = uc.runs.StartRun(ctx, &models.SyncRun{
+		TriggerType:        SyncRunWebhook,
+		SourceConnectionID: conn.ID,
+	})
+	results, err := uc.Ingest(ctx, conn, record)
+	uc.runs.FinishRun(ctx, record.SyncID, err)
+
+	return results, err
+}
+
+// ReceiveBatch - принять массив записей одного запроса. Все записи входят
+// в один запуск синхронизации, примером сохраняется первая. Ошибка записи
+// не мешает остальным; ключ из заголовка дополняется номером записи.
+func (uc *InboundUseCase) ReceiveBatch(ctx context.Context, token, contentType, headerKey string, payloads []map[string]interface{}) ([]InboundRecordResult, error) {
+	conn, err := uc.activeConnection(ctx, token, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if len(payloads) > 0 {
+		uc.storeSample(ctx, conn, contentType, payloads[0])
+	}
+
+	syncID := uc.runs.StartRun(ctx, &models.SyncRun{
+		TriggerType:        SyncRunWebhook,
+		SourceConnectionID: conn.ID,
+	})
+
+	results := make([]InboundRecordResult, 0, len(payloads))
+	failed := 0
+	for i, payload := range payloads {
+		recordKey := headerKey
+		if headerKey != "" {
+			recordKey = fmt.Sprintf("%s:%d", headerKey, i)
+		}
+
+		record := uc.newRecord(conn, recordKey, payload)
+		record.SyncID = syncID
+
+		syncResults, err := uc.Ingest(ctx, conn, record)
+		result := InboundRecordResult{Index: i, Status: "accepted", Results: syncResults}
+		if err != nil {
+			uc.logger.Error("Failed to receive inbound record", err, "connection_id", conn.ID, "index", i)
+			result.Status = "error"
+			result.Error = err.Error()
+			failed++
+		}
+		if result.Results == nil {
+			result.Results = []SyncResult{}
+		}
+		results = append(results, result)
+	}
+
+	var cause error
+	if failed > 0 {
+		cause = domain.NewErrorf("%d of %d records failed", failed, len(payloads))
+	}
+	uc.runs.FinishRun(ctx, syncID, cause)
+
+	return results, nil
+}
+
+// activeConnection - подключение входящего URL, принимающее данные
+func (uc *InboundUseCase) activeConnection(ctx context.Context, token, contentType string) (*models.Connection, error) {
+	conn, err := uc.connRepo.GetByAccessToken(ctx, domain.SystemWebhook, token)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	uc.logger.Info("UseCase: Receiving inbound webhook", "connection_id", conn.ID, "content_type", contentType)
+
+	// Отдельная ошибка, чтобы провайдер получил 4xx и не повторял доставку
+	if !conn.IsActive {
+		uc.logger.Warn("UseCase: Inbound webhook for inactive connection", "connection_id", conn.ID)
+		return nil, domain.ErrInactive
+	}
+
+	return conn, nil
+}
+
+func (uc *InboundUseCase) storeSample(ctx context.Context, conn *models.Connection, contentType string, payload map[string]interface{}) {
+	sample := &models.InboundSample{
+		ConnectionID: conn.ID,
+		ContentType:  contentType,
+		Payload:      payload,
+	}
+	if err := uc.samples.Add(ctx, sample, inboundSamplesKept); err != nil {
+		uc.logger.Error("Failed to store inbound sample", err, "connection_id", conn.ID)
+	}
+}
+
+func (uc *InboundUseCase) newRecord(conn *models.Connection, headerKey string, payload map[string]interface{}) *SourceRecord {
+	return &SourceRecord{
+		EventType:      InboundEventType,
+		RecordID:       sourceRecordID(conn, payload),
+		IdempotencyKey: idempotencyKey(conn, headerKey, payload),
+		CorrelationID:  utils.GenerateUUID(),
+		ChangedAt:      recordChangedAt(conn, payload),
+		Payload:        payload,
+	}
+}
+
+// Ingest - передать запись подключения в синхронизацию и потоки. Общий путь
+// для входящих вебхуков и записей, забранных опросом. Если запись не удалось
+// синхронизировать хотя бы в одну цель, возвращаются результаты и ошибка,
+// а ключ идемпотентности освобождается для повторной доставки.
+func (uc *InboundUseCase) Ingest(ctx context.Context, conn *models.Connection, record *SourceRecord) ([]SyncResult, error) {
+	isNew, err := uc.events.Register(ctx, &models.InboundEvent{
+		ConnectionID:   conn.ID,
+		IdempotencyKey: record.IdempotencyKey,
+	}, uc.dedupeWindow)
+	if err != nil {
+		return nil, err
+	}
+	if !isNew {
+		return uc.engine.LogDuplicate(ctx, conn, record)
+	}
+
+	results, err := uc.engine.Process(ctx, conn, record)
+	if err == nil {
+		err = failedTargets(results)
+	}
+	if err != nil {
+		// Ключ остается занятым только у обработанного события: иначе повторная
+		// доставка после ошибки попала бы в журнал как duplicate и потерялась.
+		// Цели, в которые запись уже попала, при повторе обновят связанную
+		// запись, а не создадут новую.
+		if releaseErr := uc.events.Release(context.Background(), conn.ID, record.IdempotencyKey); releaseErr != nil {
+			uc.logger.Error("Failed to release idempotency key", releaseErr, "connection_id", conn.ID)
+		}
+		return results, err
+	}
+
+	runs, err := uc.flows.Trigger(ctx, conn, record)
+	if err != nil {
+		uc.logger.Error("Failed to run flows", err, "connection_id", conn.ID)
+	}
+	for _, run := range runs {
+		uc.logger.Info("UseCase: Flow finished", "flow_id", run.FlowID, "status", run.Status)
+	}
+
+	return results, nil
+}
+
+// failedTargets - ошибка, если запись не синхронизирована хотя бы в одну цель
+func failedTargets(results []SyncResult) error {
+	var failed []string
+	for _, result := range results {
+		if result.Status == SyncStatusError {
+			failed = append(failed, fmt.Sprintf("target %d: %s", result.TargetConnectionID, result.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return domain.NewErrorf("sync failed for %d of %d targets: %s", len(failed), len(results), strings.Join(failed, "; "))
+}
+
+// GetEndpoint - входящий URL подключения типа webhook
+func (uc *InboundUseCase) GetEndpoint(ctx context.Context, connectionID int) (*InboundEndpoint, error) {
+	conn, err := uc.connRepo.GetByID(ctx, connectionID)
+	if err != nil {
+		return nil, domain.NewErrorf("connection %d not found", connectionID)
+	}
+
+	if conn.SystemType != domain.SystemWebhook {
+		return nil, domain.NewErrorf("connection %d is not a webhook connection", connectionID)
+	}
+
+	samples, err := uc.samples.GetLatest(ctx, conn.ID, inboundSamplesKept)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InboundEndpoint{
+		ConnectionID: conn.ID,
+		URL:          uc.publicBaseURL + "/hooks/" + conn.AccessToken,
+		Samples:      samples,
+	}, nil
+}
+
+// generateInboundToken - случайный токен входящего URL, который нельзя подобрать
+func generateInboundToken() (string, error) {
+	b := make([]byte, inboundTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
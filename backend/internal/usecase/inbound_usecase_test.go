@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{})        {}
+func (nopLogger) Info(string, ...interface{})         {}
+func (nopLogger) Warn(string, ...interface{})         {}
+func (nopLogger) Error(string, error, ...interface{}) {}
+
+// memoryInboundEvents - ключи идемпотентности в памяти, без окна
+type memoryInboundEvents struct {
+	keys map[string]bool
+}
+
+func (r *memoryInboundEvents) Register(_ context.Context, event *models.InboundEvent, _ time.Duration) (bool, error) {
+	if r.keys[event.IdempotencyKey] {
+		return false, nil
+	}
+	r.keys[event.IdempotencyKey] = true
+	return true, nil
+}
+
+func (r *memoryInboundEvents) Release(_ context.Context, _ int, key string) error {
+	delete(r.keys, key)
+	return nil
+}
+
+// Цель 2 не найдена - синхронизация в нее всегда завершается ошибкой
+type missingTargetConnections struct{ domain.ConnectionRepository }
+
+func (missingTargetConnections) GetByID(context.Context, int) (*models.Connection, error) {
+	return nil, errors.New("not found")
+}
+
+type staticTargets struct {
+	domain.MappingRepository
+	calls int
+}
+
+func (r *staticTargets) GetTargetIDs(context.Context, int) ([]int, error) {
+	r.calls++
+	return []int{2}, nil
+}
+
+type noReversePairs struct{ domain.SyncPairRepository }
+
+func (noReversePairs) GetReverse(context.Context, int) ([]models.SyncPair, error) {
+	return nil, nil
+}
+
+type discardSyncLogs struct{ domain.SyncLogRepository }
+
+func (discardSyncLogs) Create(context.Context, *models.SyncLog) error {
+	return errors.New("not stored")
+}
+
+func TestIngestReleasesKeyWhenTargetFails(t *testing.T) {
+	events := &memoryInboundEvents{keys: make(map[string]bool)}
+	targets := &staticTargets{}
+	uc := &InboundUseCase{
+		events: events,
+		engine: &SyncEngine{
+			maxAttempts: 1,
+			connRepo:    missingTargetConnections{},
+			mappingRepo: targets,
+			pairRepo:    noReversePairs{},
+			syncUC:      &SyncUseCase{repo: discardSyncLogs{}, logger: nopLogger{}},
+			logger:      nopLogger{},
+		},
+		logger: nopLogger{},
+	}
+
+	conn := &models.Connection{ID: 1, IsActive: true}
+	deliver := func() ([]SyncResult, error) {
+		return uc.Ingest(context.Background(), conn, &SourceRecord{
+			EventType:      InboundEventType,
+			IdempotencyKey: "event:1",
+			Payload:        map[string]interface{}{"name": "Ivan"},
+		})
+	}
+
+	results, err := deliver()
+	if err == nil {
+		t.Fatal("Ingest() error = nil, want error for the failed target")
+	}
+	if len(results) != 1 || results[0].Status != SyncStatusError {
+		t.Fatalf("Ingest() results = %+v, want one error result", results)
+	}
+	if events.keys["event:1"] {
+		t.Error("idempotency key is still registered after the failed sync")
+	}
+
+	// Повторная доставка снова синхронизируется, а не попадает в журнал как duplicate
+	results, err = deliver()
+	if err == nil {
+		t.Fatal("redelivery: Ingest() error = nil, want error for the failed target")
+	}
+	if len(results) != 1 || results[0].Status != SyncStatusError {
+		t.Errorf("redelivery: results = %+v, want the record to be synced again", results)
+	}
+	if targets.calls != 2 {
+		t.Errorf("targets resolved %d times, want 2", targets.calls)
+	}
+}
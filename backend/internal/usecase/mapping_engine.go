@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// FieldTrace - как было получено значение целевого поля
+type FieldTrace struct {
+	MappingID   int         `json:"mapping_id"`
+	SourceField string      `json:"source_field"`
+	TargetField string      `json:"target_field"`
+	Transforms  []string    `json:"transforms"`
+	SourceValue interface{} `json:"source_value"`
+	Value       interface{} `json:"value"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// mappingResult - результат применения набора сопоставлений к данным источника
+type mappingResult struct {
+	Payload  map[string]interface{}
+	Trace    []FieldTrace
+	Warnings []string
+}
+
+type transformFunc func(value interface{}) (interface{}, error)
+
+var transforms = map[string]transformFunc{
+	"trim": func(v interface{}) (interface{}, error) {
+		return strings.TrimSpace(toString(v)), nil
+	},
+	"lower": func(v interface{}) (interface{}, error) {
+		return strings.ToLower(toString(v)), nil
+	},
+	"upper": func(v interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(v)), nil
+	},
+	"string": func(v interface{}) (interface{}, error) {
+		return toString(v), nil
+	},
+	"number": func(v interface{}) (interface{}, error) {
+		s := strings.ReplaceAll(strings.TrimSpace(toString(v)), ",", ".")
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, domain.NewErrorf("value %q is not a number", s)
+		}
+		return n, nil
+	},
+	"digits": func(v interface{}) (interface{}, error) {
+		return onlyDigits(toString(v)), nil
+	},
+	"email": func(v interface{}) (interface{}, error) {
+		return normalizeEmail(toString(v)), nil
+	},
+	"phone": func(v interface{}) (interface{}, error) {
+		phone := normalizePhone(toString(v))
+		if phone == "" {
+			return nil, domain.NewErrorf("value %q is not a phone number", toString(v))
+		}
+		return phone, nil
+	},
+	// multifield - формат множественных полей Bitrix24 (PHONE, EMAIL, WEB)
+	"multifield": func(v interface{}) (interface{}, error) {
+		return []map[string]interface{}{
+			{"VALUE": v, "VALUE_TYPE": "WORK"},
+		}, nil
+	},
+}
+
+// parseTransforms - разбирает цепочку преобразований вида "trim|lower"
+func parseTransforms(chain string) []string {
+	var names []string
+	for _, name := range strings.Split(chain, "|") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateTransforms - проверяет, что все преобразования в цепочке известны
+func validateTransforms(chain string) error {
+	for _, name := range parseTransforms(chain) {
+		if _, ok := transforms[name]; !ok {
+			return domain.NewErrorf("unknown transform %q", name)
+		}
+	}
+	return nil
+}
+
+// applyTransforms - последовательно применяет цепочку преобразований
+func applyTransforms(value interface{}, names []string) (interface{}, error) {
+	for _, name := range names {
+		fn, ok := transforms[name]
+		if !ok {
+			return nil, domain.NewErrorf("unknown transform %q", name)
+		}
+
+		var err error
+		if value, err = fn(value); err != nil {
+			return nil, domain.NewErrorf("transform %s: %v", name, err)
+		}
+	}
+	return value, nil
+}
+
+// applyMappings - строит данные для целевой системы по сопоставлениям полей.
+// Сопоставления применяются по возрастанию ID, при совпадении целевого поля
+// побеждает последнее.
+func applyMappings(mappings []models.FieldMapping, payload map[string]interface{}) *mappingResult {
+	sorted := make([]models.FieldMapping, len(mappings))
+	copy(sorted, mappings)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	source := flattenSourcePayload(payload)
+	result := &mappingResult{
+		Payload:  make(map[string]interface{}),
+		Trace:    make([]FieldTrace, 0, len(sorted)),
+		Warnings: make([]string, 0),
+	}
+	producedBy := make(map[string]string)
+
+	for _, m := range sorted {
+		trace := FieldTrace{
+			MappingID:   m.ID,
+			SourceField: m.SourceField,
+			TargetField: m.TargetField,
+			Transforms:  parseTransforms(m.Transform),
+		}
+
+		value, found := lookupField(source, m.SourceField)
+		if !found {
+			trace.Error = "source field not found in payload"
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("source field %q is missing, target field %q is not set", m.SourceField, m.TargetField))
+			result.Trace = append(result.Trace, trace)
+			continue
+		}
+		trace.SourceValue = value
+
+		value, err := applyTransforms(value, trace.Transforms)
+		if err != nil {
+			trace.Error = err.Error()
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("target field %q is not set: %v", m.TargetField, err))
+			result.Trace = append(result.Trace, trace)
+			continue
+		}
+		trace.Value = value
+
+		if prev, ok := producedBy[m.TargetField]; ok {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("target field %q is mapped from both %q and %q, the latter wins", m.TargetField, prev, m.SourceField))
+		}
+		producedBy[m.TargetField] = m.SourceField
+		result.Payload[m.TargetField] = value
+		result.Trace = append(result.Trace, trace)
+	}
+
+	return result
+}
+
+// missingFields - возвращает обязательные поля, отсутствующие в данных
+func missingFields(payload map[string]interface{}, required []string) []string {
+	missing := make([]string, 0)
+	for _, field := range required {
+		value, ok := payload[field]
+		if !ok || value == nil || toString(value) == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// flattenSourcePayload - раскрывает field_data лид-форм Facebook
+// ([{"name": "email", "values": ["a@b.c"]}]) в поля верхнего уровня
+func flattenSourcePayload(payload map[string]interface{}) map[string]interface{} {
+	fieldData, ok := payload["field_data"].([]interface{})
+	if !ok {
+		return payload
+	}
+
+	flat := make(map[string]interface{}, len(payload)+len(fieldData))
+	for k, v := range payload {
+		flat[k] = v
+	}
+
+	for _, item := range fieldData {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		if name == "" {
+			continue
+		}
+		if _, exists := flat[name]; exists {
+			continue
+		}
+
+		values, _ := entry["values"].([]interface{})
+		switch len(values) {
+		case 0:
+			flat[name] = nil
+		case 1:
+			flat[name] = values[0]
+		default:
+			flat[name] = values
+		}
+	}
+
+	return flat
+}
+
+// lookupField - ищет значение по имени поля или по пути через точку (contact.phones.0)
+func lookupField(payload map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := payload[path]; ok {
+		return value, true
+	}
+
+	var current interface{} = payload
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		if len(value) > 0 {
+			return toString(value[0])
+		}
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// normalizePhone - приводит телефон к виду +79991234567
+func normalizePhone(s string) string {
+	digits := onlyDigits(s)
+	if len(digits) < 7 {
+		return ""
+	}
+	if len(digits) == 11 && digits[0] == '8' {
+		digits = "7" + digits[1:]
+	}
+	return "+" + digits
+}
+
+func normalizeEmail(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"integration-app/internal/domain/models"
+)
+
+// MappingSuggestion - предлагаемое сопоставление с оценкой уверенности 0..1
+type MappingSuggestion struct {
+	SourceField string  `json:"source_field"`
+	SourceTitle string  `json:"source_title"`
+	TargetField string  `json:"target_field"`
+	TargetTitle string  `json:"target_title"`
+	Transform   string  `json:"transform"`
+	Confidence  float64 `json:"confidence"`
+	Reason      string  `json:"reason"`
+}
+
+// fieldSynonyms - группы названий одного и того же понятия в разных системах
+var fieldSynonyms = map[string][]string{
+	"phone":      {"phone", "phone_number", "phonenumber", "tel", "telephone", "mobile", "mobile_phone", "телефон", "тел", "мобильный"},
+	"email":      {"email", "e-mail", "mail", "email_address", "почта", "электронная почта"},
+	"full_name":  {"full_name", "fullname", "fio", "фио", "контактное лицо", "contact_name"},
+	"first_name": {"first_name", "firstname", "name", "имя"},
+	"last_name":  {"last_name", "lastname", "surname", "фамилия"},
+	"patronymic": {"second_name", "middle_name", "patronymic", "отчество"},
+	"company":    {"company", "company_name", "company_title", "организация", "компания"},
+	"job_title":  {"job_title", "post", "position", "должность"},
+	"city":       {"city", "address_city", "город"},
+	"zip":        {"zip", "zip_code", "post_code", "postal_code", "address_postal_code", "индекс"},
+	"address":    {"address", "street_address", "адрес"},
+	"comment":    {"comment", "comments", "message", "комментарий", "сообщение"},
+	"website":    {"web", "website", "site", "url", "сайт"},
+	"birthday":   {"birthdate", "date_of_birth", "birthday", "дата рождения"},
+}
+
+// synonymIndex - нормализованное название -> понятие
+var synonymIndex = func() map[string]string {
+	index := make(map[string]string)
+	for concept, names := range fieldSynonyms {
+		for _, name := range names {
+			index[normalizeFieldName(name)] = concept
+		}
+	}
+	return index
+}()
+
+// suggestInput - данные для подбора сопоставлений
+type suggestInput struct {
+	Source   []models.FieldDefinition
+	Target   []models.FieldDefinition
+	Existing []models.FieldMapping // уже сохраненные для этой пары
+	History  []models.FieldMapping // сохраненные для других пар
+}
+
+// suggestMappings - подбирает пары полей по имени, синонимам, типам и истории.
+// Каждое поле источника и цели используется не более одного раза.
+func suggestMappings(in suggestInput, minConfidence float64) []MappingSuggestion {
+	usedSource := make(map[string]bool)
+	usedTarget := make(map[string]bool)
+	for _, m := range in.Existing {
+		usedSource[m.SourceField] = true
+		usedTarget[m.TargetField] = true
+	}
+
+	history := make(map[[2]string]int)
+	for _, m := range in.History {
+		history[[2]string{m.SourceField, m.TargetField}]++
+	}
+
+	candidates := make([]MappingSuggestion, 0)
+	for _, src := range in.Source {
+		if usedSource[src.Name] {
+			continue
+		}
+
+		for _, dst := range in.Target {
+			if usedTarget[dst.Name] || dst.ReadOnly {
+				continue
+			}
+
+			score, reason := matchScore(src, dst)
+			if n := history[[2]string{src.Name, dst.Name}]; n > 0 {
+				boosted := 0.8 + 0.05*float64(n)
+				if boosted > 0.98 {
+					boosted = 0.98
+				}
+				if boosted > score {
+					score, reason = boosted, "previously mapped in other connection pairs"
+				}
+			}
+
+			if score < minConfidence {
+				continue
+			}
+
+			candidates = append(candidates, MappingSuggestion{
+				SourceField: src.Name,
+				SourceTitle: src.Title,
+				TargetField: dst.Name,
+				TargetTitle: dst.Title,
+				Transform:   suggestTransform(src, dst),
+				Confidence:  float64(int(score*100)) / 100,
+				Reason:      reason,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	result := make([]MappingSuggestion, 0)
+	for _, c := range candidates {
+		if usedSource[c.SourceField] || usedTarget[c.TargetField] {
+			continue
+		}
+		usedSource[c.SourceField] = true
+		usedTarget[c.TargetField] = true
+		result = append(result, c)
+	}
+
+	return result
+}
+
+// matchScore - оценка похожести двух полей с учетом совместимости типов
+func matchScore(src, dst models.FieldDefinition) (float64, string) {
+	var score float64
+	var reason string
+
+	srcName, dstName := normalizeFieldName(src.Name), normalizeFieldName(dst.Name)
+	srcTitle, dstTitle := normalizeFieldName(src.Title), normalizeFieldName(dst.Title)
+
+	switch {
+	case srcName != "" && srcName == dstName:
+		score, reason = 1.0, "identical field names"
+	case sameConcept(src, dst):
+		score, reason = 0.9, "synonymous field names"
+	case srcTitle != "" && (srcTitle == dstTitle || srcTitle == dstName || srcName == dstTitle):
+		score, reason = 0.85, "identical field titles"
+	default:
+		overlap := tokenOverlap(src.Name+" "+src.Title, dst.Name+" "+dst.Title)
+		score, reason = 0.7*overlap, "similar field names"
+	}
+
+	compat := typeCompatibility(fieldKind(src), fieldKind(dst))
+	if compat < 1 {
+		reason += ", types differ"
+	}
+
+	return score * compat, reason
+}
+
+func sameConcept(src, dst models.FieldDefinition) bool {
+	srcConcepts := fieldConcepts(src)
+	for concept := range fieldConcepts(dst) {
+		if srcConcepts[concept] {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldConcepts - понятия, к которым относится поле по имени, подписи или типу
+// (типы вопросов Facebook совпадают с понятиями: EMAIL, PHONE, FULL_NAME)
+func fieldConcepts(f models.FieldDefinition) map[string]bool {
+	concepts := make(map[string]bool)
+	for _, key := range []string{f.Name, f.Title, f.Type} {
+		if concept, ok := synonymIndex[normalizeFieldName(key)]; ok {
+			concepts[concept] = true
+		}
+	}
+	return concepts
+}
+
+// suggestTransform - преобразование, без которого значение не запишется в цель
+func suggestTransform(src, dst models.FieldDefinition) string {
+	concepts := fieldConcepts(src)
+	for c := range fieldConcepts(dst) {
+		concepts[c] = true
+	}
+
+	var chain []string
+	switch {
+	case concepts["phone"]:
+		chain = append(chain, "phone")
+	case concepts["email"]:
+		chain = append(chain, "email")
+	case fieldKind(dst) == "number" && fieldKind(src) != "number":
+		chain = append(chain, "number")
+	case fieldKind(dst) == "text":
+		chain = append(chain, "trim")
+	}
+
+	if dst.Type == "crm_multifield" {
+		chain = append(chain, "multifield")
+	}
+
+	return strings.Join(chain, "|")
+}
+
+func fieldKind(f models.FieldDefinition) string {
+	switch strings.ToLower(f.Type) {
+	case "integer", "double", "money", "number":
+		return "number"
+	case "date", "datetime", "date_time":
+		return "date"
+	case "boolean", "bool", "checkbox":
+		return "bool"
+	case "enumeration", "crm_status", "list", "select":
+		return "enum"
+	case "file", "user", "employee", "crm", "crm_entity":
+		return "reference"
+	default:
+		return "text"
+	}
+}
+
+// typeCompatibility - множитель оценки в зависимости от совместимости типов
+func typeCompatibility(src, dst string) float64 {
+	if src == dst {
+		return 1
+	}
+
+	switch dst {
+	case "text":
+		return 1
+	case "enum":
+		return 0.8
+	case "number", "date", "bool":
+		return 0.6
+	default:
+		return 0.3
+	}
+}
+
+// normalizeFieldName - "UF_CRM_Phone-Number" -> "phonenumber"
+func normalizeFieldName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "uf_crm_")
+
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tokenOverlap - коэффициент Жаккара по словам
+func tokenOverlap(a, b string) float64 {
+	ta, tb := fieldTokens(a), fieldTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func fieldTokens(s string) map[string]bool {
+	tokens := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if w == "uf" || w == "crm" {
+			continue
+		}
+		tokens[w] = true
+	}
+	return tokens
+}
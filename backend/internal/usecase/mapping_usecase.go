@@ -2,33 +2,75 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 
 	"integration-app/internal/domain"
 	"integration-app/internal/domain/models"
 )
 
 type MappingUseCase struct {
-	repo   domain.MappingRepository
-	logger domain.Logger
+	repo        domain.MappingRepository
+	versionRepo domain.MappingVersionRepository
+	connRepo    domain.ConnectionRepository
+	syncLogRepo domain.SyncLogRepository
+	schemaUC    *SchemaUseCase
+	events      domain.EventPublisher
+	logger      domain.Logger
 }
 
 func NewMappingUseCase(
 	repo domain.MappingRepository,
+	versionRepo domain.MappingVersionRepository,
+	connRepo domain.ConnectionRepository,
+	syncLogRepo domain.SyncLogRepository,
+	schemaUC *SchemaUseCase,
+	events domain.EventPublisher,
 	logger domain.Logger,
 ) *MappingUseCase {
 	return &MappingUseCase{
-		repo:   repo,
-		logger: logger,
+		repo:        repo,
+		versionRepo: versionRepo,
+		connRepo:    connRepo,
+		syncLogRepo: syncLogRepo,
+		schemaUC:    schemaUC,
+		events:      events,
+		logger:      logger,
 	}
 }
 
+// PreviewRequest - параметры предпросмотра сопоставлений.
+// Данные источника берутся из Payload либо из сохраненного SyncLog,
+// сопоставления - из запроса либо сохраненные для пары подключений.
+// Если RequiredFields не заданы, они берутся из схемы TargetEntity.
+type PreviewRequest struct {
+	SourceConnectionID int                    `json:"source_connection_id"`
+	TargetConnectionID int                    `json:"target_connection_id"`
+	Payload            map[string]interface{} `json:"payload"`
+	SyncLogID          int                    `json:"sync_log_id"`
+	Mappings           []models.FieldMapping  `json:"mappings"`
+	RequiredFields     []string               `json:"required_fields"`
+	TargetEntity       string                 `json:"target_entity"`
+}
+
+// MappingPreview - результат предпросмотра без обращения к целевой системе
+type MappingPreview struct {
+	SourceConnectionID int                    `json:"source_connection_id"`
+	TargetConnectionID int                    `json:"target_connection_id"`
+	SourcePayload      map[string]interface{} `json:"source_payload"`
+	TargetPayload      map[string]interface{} `json:"target_payload"`
+	Trace              []FieldTrace           `json:"trace"`
+	MissingRequired    []string               `json:"missing_required"`
+	Warnings           []string               `json:"warnings"`
+	MappingVersion     int                    `json:"mapping_version,omitempty"`
+}
+
 // GetAllMappings - получить все сопоставления
 func (uc *MappingUseCase) GetAllMappings(ctx context.Context) ([]models.FieldMapping, error) {
 	uc.logger.Info("UseCase: Getting all field mappings")
 	return uc.repo.GetAll(ctx)
 }
 
-// GetMappingsByPair - получить сопоставления для пары подключений
+// GetMappingsByPair - получить сопоставления активной версии для пары подключений
 func (uc *MappingUseCase) GetMappingsByPair(ctx context.Context, sourceID, targetID int) ([]models.FieldMapping, error) {
 	uc.logger.Info("UseCase: Getting mappings by pair", "source_id", sourceID, "target_id", targetID)
 
@@ -55,13 +97,338 @@ func (uc *MappingUseCase) SaveMappings(ctx context.Context, mappings []models.Fi
 		}
 	}
 
-	return uc.repo.CreateBatch(ctx, mappings)
+	// Каждое сохранение пары создает новую версию ее набора сопоставлений
+	type pair struct{ source, target int }
+	var order []pair
+	byPair := make(map[pair][]models.FieldMapping)
+	for _, m := range mappings {
+		p := pair{m.SourceConnectionID, m.TargetConnectionID}
+		if _, ok := byPair[p]; !ok {
+			order = append(order, p)
+		}
+		byPair[p] = append(byPair[p], m)
+	}
+
+	for _, p := range order {
+		incoming := byPair[p]
+		merge := func(current []models.FieldMapping) ([]models.FieldMapping, error) {
+			return mergeMappings(current, incoming), nil
+		}
+		if _, err := uc.saveVersion(ctx, p.source, p.target, merge, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PreviewMappings - рассчитать данные для целевой системы, ничего не отправляя
+func (uc *MappingUseCase) PreviewMappings(ctx context.Context, req *PreviewRequest) (*MappingPreview, error) {
+	uc.logger.Info("UseCase: Previewing mappings", "source_id", req.SourceConnectionID, "target_id", req.TargetConnectionID)
+
+	payload := req.Payload
+	if req.SyncLogID != 0 {
+		log, err := uc.syncLogRepo.GetByID(ctx, req.SyncLogID)
+		if err != nil {
+			return nil, domain.NewErrorf("sync log %d not found", req.SyncLogID)
+		}
+
+		if err := json.Unmarshal(log.SourceData, &payload); err != nil {
+			return nil, domain.NewErrorf("sync log %d has no usable source data", req.SyncLogID)
+		}
+
+		if req.SourceConnectionID == 0 {
+			req.SourceConnectionID = log.SourceConnectionID
+		}
+		if req.TargetConnectionID == 0 {
+			req.TargetConnectionID = log.TargetConnectionID
+		}
+	}
+
+	if payload == nil {
+		return nil, domain.NewError("payload or sync log id is required")
+	}
+
+	if req.SourceConnectionID == 0 || req.TargetConnectionID == 0 {
+		return nil, domain.NewError("source and target connection ids are required")
+	}
+
+	if _, err := uc.connRepo.GetByID(ctx, req.TargetConnectionID); err != nil {
+		return nil, domain.NewErrorf("target connection %d not found", req.TargetConnectionID)
+	}
+
+	mappings := req.Mappings
+	mappingVersion := 0
+	if len(mappings) == 0 {
+		stored, err := uc.GetMappingsByPair(ctx, req.SourceConnectionID, req.TargetConnectionID)
+		if err != nil {
+			return nil, err
+		}
+		mappings = stored
+
+		active, err := uc.versionRepo.GetActive(ctx, req.SourceConnectionID, req.TargetConnectionID)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			mappingVersion = active.Version
+		}
+	}
+
+	for i := range mappings {
+		mappings[i].SourceConnectionID = req.SourceConnectionID
+		mappings[i].TargetConnectionID = req.TargetConnectionID
+		if err := uc.validateMapping(&mappings[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	result := applyMappings(mappings, payload)
+	if len(mappings) == 0 {
+		result.Warnings = append(result.Warnings, "no mappings configured for this connection pair")
+	}
+
+	required := req.RequiredFields
+	if len(required) == 0 {
+		fields, err := uc.schemaUC.RequiredFields(ctx, req.TargetConnectionID, req.TargetEntity)
+		if err != nil {
+			result.Warnings = append(result.Warnings, "required fields are not checked: "+err.Error())
+		}
+		required = fields
+	}
+
+	return &MappingPreview{
+		SourceConnectionID: req.SourceConnectionID,
+		TargetConnectionID: req.TargetConnectionID,
+		SourcePayload:      payload,
+		TargetPayload:      result.Payload,
+		Trace:              result.Trace,
+		MissingRequired:    missingFields(result.Payload, required),
+		Warnings:           result.Warnings,
+		MappingVersion:     mappingVersion,
+	}, nil
+}
+
+// SuggestRequest - параметры подбора сопоставлений для пары подключений
+type SuggestRequest struct {
+	SourceConnectionID int     `json:"source_connection_id"`
+	TargetConnectionID int     `json:"target_connection_id"`
+	SourceEntity       string  `json:"source_entity"`
+	TargetEntity       string  `json:"target_entity"`
+	MinConfidence      float64 `json:"min_confidence"`
+}
+
+// MappingSuggestions - предложения и готовые к сохранению через SaveMappings сопоставления
+type MappingSuggestions struct {
+	SourceConnectionID int                   `json:"source_connection_id"`
+	TargetConnectionID int                   `json:"target_connection_id"`
+	Suggestions        []MappingSuggestion   `json:"suggestions"`
+	Mappings           []models.FieldMapping `json:"mappings"`
+}
+
+const defaultMinConfidence = 0.5
+
+// SuggestMappings - предложить сопоставления по схемам полей обоих подключений
+func (uc *MappingUseCase) SuggestMappings(ctx context.Context, req *SuggestRequest) (*MappingSuggestions, error) {
+	uc.logger.Info("UseCase: Suggesting mappings", "source_id", req.SourceConnectionID, "target_id", req.TargetConnectionID)
+
+	if req.SourceConnectionID == 0 || req.TargetConnectionID == 0 {
+		return nil, domain.NewError("source and target connection ids are required")
+	}
+
+	existing, err := uc.GetMappingsByPair(ctx, req.SourceConnectionID, req.TargetConnectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := uc.schemaUC.GetFields(ctx, req.SourceConnectionID, req.SourceEntity, false)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := uc.schemaUC.GetFields(ctx, req.TargetConnectionID, req.TargetEntity, false)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := uc.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.FieldMapping, 0, len(all))
+	for _, m := range all {
+		if m.SourceConnectionID == req.SourceConnectionID && m.TargetConnectionID == req.TargetConnectionID {
+			continue
+		}
+		history = append(history, m)
+	}
+
+	minConfidence := req.MinConfidence
+	if minConfidence <= 0 {
+		minConfidence = defaultMinConfidence
+	}
+
+	suggestions := suggestMappings(suggestInput{
+		Source:   source.Fields,
+		Target:   target.Fields,
+		Existing: existing,
+		History:  history,
+	}, minConfidence)
+
+	mappings := make([]models.FieldMapping, 0, len(suggestions))
+	for _, s := range suggestions {
+		mappings = append(mappings, models.FieldMapping{
+			SourceConnectionID: req.SourceConnectionID,
+			TargetConnectionID: req.TargetConnectionID,
+			SourceField:        s.SourceField,
+			TargetField:        s.TargetField,
+			Transform:          s.Transform,
+		})
+	}
+
+	return &MappingSuggestions{
+		SourceConnectionID: req.SourceConnectionID,
+		TargetConnectionID: req.TargetConnectionID,
+		Suggestions:        suggestions,
+		Mappings:           mappings,
+	}, nil
+}
+
+// MappingPatch - частичное изменение одного сопоставления
+type MappingPatch struct {
+	SourceField *string `json:"source_field"`
+	TargetField *string `json:"target_field"`
+	Transform   *string `json:"transform"`
+}
+
+// ReplaceMappings - атомарно заменить весь набор сопоставлений пары.
+// Поля источника, отсутствующие в новом наборе, удаляются.
+func (uc *MappingUseCase) ReplaceMappings(ctx context.Context, sourceID, targetID int, mappings []models.FieldMapping) (*models.MappingVersion, error) {
+	uc.logger.Info("UseCase: Replacing mappings", "source_id", sourceID, "target_id", targetID, "count", len(mappings))
+
+	if sourceID == targetID {
+		return nil, domain.NewError("source and target cannot be the same")
+	}
+
+	seen := make(map[string]bool, len(mappings))
+	for i := range mappings {
+		mappings[i].SourceConnectionID = sourceID
+		mappings[i].TargetConnectionID = targetID
+		if err := uc.validateMapping(&mappings[i]); err != nil {
+			uc.logger.Warn("Validation failed for mapping", "index", i, "error", err.Error())
+			return nil, err
+		}
+
+		if seen[mappings[i].SourceField] {
+			return nil, domain.NewErrorf("source field %q is mapped more than once", mappings[i].SourceField)
+		}
+		seen[mappings[i].SourceField] = true
+	}
+
+	return uc.saveVersion(ctx, sourceID, targetID, replaceWith(mappings), "")
+}
+
+// UpdateMapping - изменить одно сопоставление пары (создает новую версию)
+func (uc *MappingUseCase) UpdateMapping(ctx context.Context, sourceID, targetID, id int, patch *MappingPatch) (*models.FieldMapping, error) {
+	uc.logger.Info("UseCase: Updating mapping", "source_id", sourceID, "target_id", targetID, "id", id)
+
+	var updated models.FieldMapping
+	update := func(current []models.FieldMapping) ([]models.FieldMapping, error) {
+		index := findMapping(current, id)
+		if index < 0 {
+			return nil, domain.NewErrorf("mapping %d not found for this connection pair", id)
+		}
+
+		updated = current[index]
+		if patch.SourceField != nil {
+			updated.SourceField = *patch.SourceField
+		}
+		if patch.TargetField != nil {
+			updated.TargetField = *patch.TargetField
+		}
+		if patch.Transform != nil {
+			updated.Transform = *patch.Transform
+		}
+
+		if err := uc.validateMapping(&updated); err != nil {
+			return nil, err
+		}
+
+		for i, m := range current {
+			if i != index && m.SourceField == updated.SourceField {
+				return nil, domain.NewErrorf("source field %q is already mapped", updated.SourceField)
+			}
+		}
+
+		current[index] = updated
+		return current, nil
+	}
+	if _, err := uc.saveVersion(ctx, sourceID, targetID, update, ""); err != nil {
+		return nil, err
+	}
+
+	// При смене поля источника строка пересоздается и получает новый ID
+	saved, err := uc.repo.GetByConnectionPair(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range saved {
+		if m.SourceField == updated.SourceField {
+			return &m, nil
+		}
+	}
+
+	return &updated, nil
 }
 
 // DeleteMapping - удалить сопоставление
 func (uc *MappingUseCase) DeleteMapping(ctx context.Context, id int) error {
 	uc.logger.Info("UseCase: Deleting mapping", "id", id)
-	return uc.repo.Delete(ctx, id)
+
+	mapping, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return domain.NewErrorf("mapping %d not found", id)
+	}
+
+	return uc.DeletePairMapping(ctx, mapping.SourceConnectionID, mapping.TargetConnectionID, id)
+}
+
+// DeletePairMapping - удалить одно сопоставление пары (создает новую версию)
+func (uc *MappingUseCase) DeletePairMapping(ctx context.Context, sourceID, targetID, id int) error {
+	uc.logger.Info("UseCase: Deleting pair mapping", "source_id", sourceID, "target_id", targetID, "id", id)
+
+	remove := func(current []models.FieldMapping) ([]models.FieldMapping, error) {
+		index := findMapping(current, id)
+		if index < 0 {
+			return nil, domain.NewErrorf("mapping %d not found for this connection pair", id)
+		}
+		return append(current[:index:index], current[index+1:]...), nil
+	}
+
+	_, err := uc.saveVersion(ctx, sourceID, targetID, remove, "")
+	return err
+}
+
+// DeleteMappingsByPair - удалить все сопоставления пары (создает пустую версию)
+func (uc *MappingUseCase) DeleteMappingsByPair(ctx context.Context, sourceID, targetID int) error {
+	uc.logger.Info("UseCase: Deleting all pair mappings", "source_id", sourceID, "target_id", targetID)
+
+	if sourceID == targetID {
+		return domain.NewError("source and target cannot be the same")
+	}
+
+	_, err := uc.saveVersion(ctx, sourceID, targetID, replaceWith(nil), "")
+	return err
+}
+
+func findMapping(mappings []models.FieldMapping, id int) int {
+	for i, m := range mappings {
+		if m.ID == id {
+			return i
+		}
+	}
+	return -1
 }
 
 // validateMapping - валидация сопоставления
@@ -82,5 +449,9 @@ func (uc *MappingUseCase) validateMapping(mapping *models.FieldMapping) error {
 		return domain.NewError("target field cannot be empty")
 	}
 
+	if err := validateTransforms(mapping.Transform); err != nil {
+		return err
+	}
+
 	return nil
 }
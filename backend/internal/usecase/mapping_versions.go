@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// ListVersions - история версий сопоставлений пары, новые первыми
+func (uc *MappingUseCase) ListVersions(ctx context.Context, sourceID, targetID int) ([]models.MappingVersion, error) {
+	uc.logger.Info("UseCase: Listing mapping versions", "source_id", sourceID, "target_id", targetID)
+	return uc.versionRepo.GetByConnectionPair(ctx, sourceID, targetID)
+}
+
+// GetVersion - получить версию сопоставлений пары по номеру
+func (uc *MappingUseCase) GetVersion(ctx context.Context, sourceID, targetID, version int) (*models.MappingVersion, error) {
+	v, err := uc.versionRepo.GetByVersion(ctx, sourceID, targetID, version)
+	if err != nil {
+		return nil, domain.NewErrorf("mapping version %d not found", version)
+	}
+	return v, nil
+}
+
+// DiffVersions - разница между двумя версиями сопоставлений пары
+func (uc *MappingUseCase) DiffVersions(ctx context.Context, sourceID, targetID, from, to int) (*models.MappingDiff, error) {
+	uc.logger.Info("UseCase: Diffing mapping versions", "source_id", sourceID, "target_id", targetID, "from", from, "to", to)
+
+	fromVersion, err := uc.GetVersion(ctx, sourceID, targetID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toVersion, err := uc.GetVersion(ctx, sourceID, targetID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffMappingRules(fromVersion.Mappings, toVersion.Mappings), nil
+}
+
+// RollbackToVersion - делает активным содержимое старой версии.
+// Откат не переписывает историю, а создает новую версию с тем же набором.
+func (uc *MappingUseCase) RollbackToVersion(ctx context.Context, sourceID, targetID, version int) (*models.MappingVersion, error) {
+	uc.logger.Info("UseCase: Rolling back mappings", "source_id", sourceID, "target_id", targetID, "version", version)
+
+	target, err := uc.GetVersion(ctx, sourceID, targetID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := rulesToMappings(sourceID, targetID, target.Mappings)
+	return uc.saveVersion(ctx, sourceID, targetID, replaceWith(mappings), fmt.Sprintf("rollback to version %d", version))
+}
+
+// saveVersion - сохраняет набор сопоставлений пары как новую активную версию.
+// change получает текущие сопоставления пары, прочитанные под блокировкой
+// пары, и возвращает полный новый набор: так одновременные сохранения не
+// теряют изменения друг друга и diff считается от действительно прежнего
+// набора. Если набор не изменился, возвращается текущая активная версия.
+func (uc *MappingUseCase) saveVersion(ctx context.Context, sourceID, targetID int, change func(current []models.FieldMapping) ([]models.FieldMapping, error), comment string) (*models.MappingVersion, error) {
+	author := domain.UserFromContext(ctx)
+
+	version, created, err := uc.versionRepo.Activate(ctx, sourceID, targetID, func(current []models.FieldMapping, active *models.MappingVersion) (*models.MappingVersion, []models.FieldMapping, error) {
+		// До change: изменение может править переданный срез на месте
+		before := mappingsToRules(current)
+		mappings, err := change(current)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rules := mappingsToRules(mappings)
+		diff := diffMappingRules(before, rules)
+		if active != nil && isEmptyDiff(diff) {
+			return nil, nil, nil
+		}
+
+		return &models.MappingVersion{
+			Mappings: rules,
+			Diff:     diff,
+			Author:   author,
+			Comment:  comment,
+		}, rulesToMappings(sourceID, targetID, rules), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return version, nil
+	}
+
+	uc.logger.Info("UseCase: Mapping version activated", "source_id", sourceID, "target_id", targetID,
+		"version", version.Version, "author", version.Author)
+
+	uc.events.Publish(ctx, &domain.Event{
+		Type:          domain.EventMappingUpdated,
+		ConnectionIDs: []int{sourceID, targetID},
+		Data: map[string]interface{}{
+			"source_connection_id": sourceID,
+			"target_connection_id": targetID,
+			"version":              version.Version,
+			"author":               version.Author,
+			"diff":                 version.Diff,
+		},
+	})
+
+	return version, nil
+}
+
+// replaceWith - изменение, которое заменяет набор пары целиком
+func replaceWith(mappings []models.FieldMapping) func([]models.FieldMapping) ([]models.FieldMapping, error) {
+	return func([]models.FieldMapping) ([]models.FieldMapping, error) {
+		return mappings, nil
+	}
+}
+
+// mergeMappings - upsert по полю источника: новые сопоставления заменяют
+// существующие с тем же SourceField, остальные сохраняются
+func mergeMappings(current, incoming []models.FieldMapping) []models.FieldMapping {
+	merged := make([]models.FieldMapping, len(current))
+	copy(merged, current)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].ID < merged[j].ID })
+
+	index := make(map[string]int, len(merged))
+	for i, m := range merged {
+		index[m.SourceField] = i
+	}
+
+	for _, m := range incoming {
+		if i, ok := index[m.SourceField]; ok {
+			merged[i].TargetField = m.TargetField
+			merged[i].Transform = m.Transform
+			continue
+		}
+		index[m.SourceField] = len(merged)
+		merged = append(merged, m)
+	}
+
+	return merged
+}
+
+func mappingsToRules(mappings []models.FieldMapping) []models.MappingRule {
+	sorted := make([]models.FieldMapping, len(mappings))
+	copy(sorted, mappings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		// Новые сопоставления без ID идут после сохраненных
+		if sorted[i].ID == 0 || sorted[j].ID == 0 {
+			return sorted[j].ID == 0 && sorted[i].ID != 0
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	rules := make([]models.MappingRule, 0, len(sorted))
+	for _, m := range sorted {
+		rules = append(rules, models.MappingRule{
+			SourceField: m.SourceField,
+			TargetField: m.TargetField,
+			Transform:   m.Transform,
+		})
+	}
+	return rules
+}
+
+func rulesToMappings(sourceID, targetID int, rules []models.MappingRule) []models.FieldMapping {
+	mappings := make([]models.FieldMapping, 0, len(rules))
+	for _, r := range rules {
+		mappings = append(mappings, models.FieldMapping{
+			SourceConnectionID: sourceID,
+			TargetConnectionID: targetID,
+			SourceField:        r.SourceField,
+			TargetField:        r.TargetField,
+			Transform:          r.Transform,
+		})
+	}
+	return mappings
+}
+
+// diffMappingRules - изменения от набора before к набору after по полю источника
+func diffMappingRules(before, after []models.MappingRule) *models.MappingDiff {
+	diff := &models.MappingDiff{
+		Added:   make([]models.MappingRule, 0),
+		Removed: make([]models.MappingRule, 0),
+		Changed: make([]models.MappingChange, 0),
+	}
+
+	old := make(map[string]models.MappingRule, len(before))
+	for _, r := range before {
+		old[r.SourceField] = r
+	}
+
+	seen := make(map[string]bool, len(after))
+	for _, r := range after {
+		seen[r.SourceField] = true
+
+		prev, ok := old[r.SourceField]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, r)
+		case prev != r:
+			diff.Changed = append(diff.Changed, models.MappingChange{
+				SourceField: r.SourceField,
+				Before:      prev,
+				After:       r,
+			})
+		}
+	}
+
+	for _, r := range before {
+		if !seen[r.SourceField] {
+			diff.Removed = append(diff.Removed, r)
+		}
+	}
+
+	return diff
+}
+
+func isEmptyDiff(diff *models.MappingDiff) bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// sourceRecordIDFields - где источники передают ID записи
+var sourceRecordIDFields = [][]string{
+	{"leadgen_id"},
+	{"entry.0.changes.0.value.leadgen_id"},
+	{"data[FIELDS][ID]"},
+	{"data.FIELDS.ID"},
+	{"id"},
+	{"ID"},
+}
+
+// sourceRecordID - ID записи источника: поля из metadata.record_id_field
+// подключения или известные поля провайдеров. Пустая строка - запись без
+// ID, она всегда создается в цели заново.
+func sourceRecordID(conn *models.Connection, payload map[string]interface{}) string {
+	source := flattenSourcePayload(payload)
+
+	if fields := metadataStrings(conn, "record_id_field"); len(fields) > 0 {
+		values, _ := lookupAll(source, fields)
+		return limitKey(strings.Join(values, ":"))
+	}
+
+	for _, paths := range sourceRecordIDFields {
+		if values, ok := lookupAll(source, paths); ok {
+			return limitKey(strings.Join(values, ":"))
+		}
+	}
+
+	return ""
+}
+
+// recordChangedAt - время изменения записи из поля metadata.modified_at_field
+// подключения (RFC 3339 или unix-время). Нулевое время - поле не настроено
+// или не разобрано, тогда движок считает временем изменения момент приема.
+func recordChangedAt(conn *models.Connection, payload map[string]interface{}) time.Time {
+	fields := metadataStrings(conn, "modified_at_field")
+	if len(fields) == 0 {
+		return time.Time{}
+	}
+
+	value, ok := lookupField(flattenSourcePayload(payload), fields[0])
+	if !ok {
+		return time.Time{}
+	}
+
+	s := strings.TrimSpace(toString(value))
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0)
+	}
+	return time.Time{}
+}
+
+type RecordLinkUseCase struct {
+	repo       domain.RecordLinkRepository
+	connRepo   domain.ConnectionRepository
+	connectors domain.ConnectorRegistry
+	logger     domain.Logger
+}
+
+func NewRecordLinkUseCase(
+	repo domain.RecordLinkRepository,
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	logger domain.Logger,
+) *RecordLinkUseCase {
+	return &RecordLinkUseCase{
+		repo:       repo,
+		connRepo:   connRepo,
+		connectors: connectors,
+		logger:     logger,
+	}
+}
+
+// RecordLinkPatch - исправление связи записи
+type RecordLinkPatch struct {
+	TargetRecordID *string `json:"target_record_id"`
+}
+
+// GetLinks - связи записи подключения, где она источник или цель
+func (uc *RecordLinkUseCase) GetLinks(ctx context.Context, connectionID int, recordID string) ([]models.RecordLink, error) {
+	uc.logger.Info("UseCase: Getting record links", "connection_id", connectionID, "record_id", recordID)
+
+	if connectionID == 0 || recordID == "" {
+		return nil, domain.NewError("connection ID and record ID are required")
+	}
+
+	return uc.repo.GetByRecord(ctx, connectionID, recordID)
+}
+
+func (uc *RecordLinkUseCase) GetLinkByID(ctx context.Context, id int) (*models.RecordLink, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+// CreateLink - связать записи вручную, например уже существующие в цели
+func (uc *RecordLinkUseCase) CreateLink(ctx context.Context, link *models.RecordLink) error {
+	uc.logger.Info("UseCase: Creating record link", "source_id", link.SourceConnectionID, "target_id", link.TargetConnectionID)
+
+	if link.SourceRecordID == "" || link.TargetRecordID == "" {
+		return domain.NewError("source and target record IDs are required")
+	}
+
+	if _, err := uc.connRepo.GetByID(ctx, link.SourceConnectionID); err != nil {
+		return domain.NewErrorf("source connection %d not found", link.SourceConnectionID)
+	}
+
+	target, err := uc.connRepo.GetByID(ctx, link.TargetConnectionID)
+	if err != nil {
+		return domain.NewErrorf("target connection %d not found", link.TargetConnectionID)
+	}
+
+	if link.EntityType == "" {
+		connector, err := uc.connectors.Get(target.SystemType)
+		if err != nil {
+			return err
+		}
+		link.EntityType = connector.DefaultEntity()
+	}
+
+	// Хэша нет, поэтому следующая синхронизация отправит в цель все поля
+	link.LastSyncedHash = ""
+
+	return uc.repo.Save(ctx, link)
+}
+
+// RepairLink - указать правильную запись цели. Хэш сбрасывается, и следующая
+// синхронизация обновит запись полностью.
+func (uc *RecordLinkUseCase) RepairLink(ctx context.Context, id int, patch *RecordLinkPatch) (*models.RecordLink, error) {
+	uc.logger.Info("UseCase: Repairing record link", "id", id)
+
+	link, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, domain.NewErrorf("record link %d not found", id)
+	}
+
+	if patch.TargetRecordID != nil {
+		if *patch.TargetRecordID == "" {
+			return nil, domain.NewError("target record ID cannot be empty")
+		}
+		link.TargetRecordID = *patch.TargetRecordID
+	}
+	link.LastSyncedHash = ""
+
+	if err := uc.repo.Update(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// DeleteLink - удалить связь: следующая синхронизация создаст запись в цели заново
+func (uc *RecordLinkUseCase) DeleteLink(ctx context.Context, id int) error {
+	uc.logger.Info("UseCase: Deleting record link", "id", id)
+	return uc.repo.Delete(ctx, id)
+}
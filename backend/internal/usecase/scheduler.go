@@ -0,0 +1,549 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"go.uber.org/fx"
+)
+
+// Типы заданий планировщика
+const (
+	ScheduleTypePoll         = "poll"
+	ScheduleTypeLogRetention = "log_retention"
+	ScheduleTypeTokenRefresh = "token_refresh"
+	ScheduleTypeSyncReport   = "sync_report"
+)
+
+const (
+	// PollEventType - тип события в sync_logs для записей, забранных опросом
+	PollEventType = "poll"
+
+	// scheduleOff - значение расписания, выключающее задание
+	scheduleOff = "off"
+
+	scheduleTickInterval = 30 * time.Second
+	// pollFirstWindow - за какой период забираются записи при первом опросе
+	pollFirstWindow = time.Hour
+	// tokenRefreshAhead - токен обновляется, если истекает раньше этого срока
+	tokenRefreshAhead = 15 * time.Minute
+	// tokenExpiryWarning - о токенах без обновления предупреждаем заранее
+	tokenExpiryWarning = 72 * time.Hour
+	syncReportPeriod   = 24 * time.Hour
+)
+
+// scheduleJob - задание планировщика. Задания собираются заново на каждом
+// шаге: обслуживающие - из конфигурации, опрос - из метаданных подключений
+// (poll_schedule, poll_entity).
+type scheduleJob struct {
+	name         string
+	jobType      string
+	connectionID int
+	spec         string
+	cron         *CronSchedule
+	err          error // неверное расписание
+	run          func(ctx context.Context, prev *models.ScheduleState) error
+}
+
+// ScheduleStatus - задание планировщика с последним и следующим запуском
+type ScheduleStatus struct {
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	ConnectionID   int        `json:"connection_id,omitempty"`
+	Cron           string     `json:"cron"`
+	Running        bool       `json:"running"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastSuccessAt  *time.Time `json:"last_success_at"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	NextRunAt      *time.Time `json:"next_run_at"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// scheduledRun - следующий запуск задания по его текущему расписанию
+type scheduledRun struct {
+	spec string
+	at   time.Time
+}
+
+// Scheduler - запускает фоновые задания по cron-выражениям: опрос подключений
+// без вебхуков, очистку журнала синхронизации, обновление токенов и отчеты.
+// Одно задание не запускается повторно, пока не завершился предыдущий запуск.
+// При нескольких репликах задания запускает только лидер.
+type Scheduler struct {
+	retentionDays int
+	maintenance   map[string]string // тип задания -> расписание
+	connRepo      domain.ConnectionRepository
+	connectors    domain.ConnectorRegistry
+	logRepo       domain.SyncLogRepository
+	stateRepo     domain.ScheduleRepository
+	checkpoints   domain.SyncCheckpointRepository
+	runs          *SyncRunUseCase
+	events        domain.EventPublisher
+	leader        domain.LeaderElector
+	logger        domain.Logger
+
+	mu      sync.Mutex
+	next    map[string]scheduledRun
+	running map[string]bool
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewScheduler(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	logRepo domain.SyncLogRepository,
+	stateRepo domain.ScheduleRepository,
+	checkpoints domain.SyncCheckpointRepository,
+	runs *SyncRunUseCase,
+	events domain.EventPublisher,
+	leader domain.LeaderElector,
+	logger domain.Logger,
+) *Scheduler {
+	s := &Scheduler{
+		retentionDays: cfg.LogRetentionDays,
+		maintenance: map[string]string{
+			ScheduleTypeLogRetention: cfg.LogRetentionSchedule,
+			ScheduleTypeTokenRefresh: cfg.TokenRefreshSchedule,
+			ScheduleTypeSyncReport:   cfg.SyncReportSchedule,
+		},
+		connRepo:    connRepo,
+		connectors:  connectors,
+		logRepo:     logRepo,
+		stateRepo:   stateRepo,
+		checkpoints: checkpoints,
+		runs:        runs,
+		events:      events,
+		leader:      leader,
+		logger:      logger,
+		next:        make(map[string]scheduledRun),
+		running:     make(map[string]bool),
+		stop:        make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			s.wg.Add(1)
+			go s.loop()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			s.logger.Info("Waiting for running scheduled jobs")
+			close(s.stop)
+
+			done := make(chan struct{})
+			go func() {
+				s.wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return s
+}
+
+// GetSchedules - все задания с состоянием последнего запуска
+func (s *Scheduler) GetSchedules(ctx context.Context) ([]ScheduleStatus, error) {
+	jobs, err := s.jobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.states(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]ScheduleStatus, 0, len(jobs))
+	for _, job := range jobs {
+		status := ScheduleStatus{
+			Name:         job.name,
+			Type:         job.jobType,
+			ConnectionID: job.connectionID,
+			Cron:         job.spec,
+			Running:      s.running[job.name],
+		}
+		if job.err != nil {
+			status.Error = job.err.Error()
+		}
+
+		if state, ok := states[job.name]; ok {
+			status.LastRunAt = timePtr(state.LastRunAt)
+			status.LastSuccessAt = timePtr(state.LastSuccessAt)
+			status.LastStatus = state.LastStatus
+			status.LastError = state.LastError
+			status.LastDurationMs = state.LastDurationMs
+		}
+
+		if next, ok := s.next[job.name]; ok && next.spec == job.spec {
+			status.NextRunAt = timePtr(next.at)
+		} else if job.cron != nil {
+			status.NextRunAt = timePtr(job.cron.Next(time.Now()))
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(scheduleTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.tick(context.Background(), now)
+		}
+	}
+}
+
+// tick - запустить задания, время которых подошло. Время следующего запуска
+// считается от момента, когда задание впервые встретилось или завершилось,
+// поэтому пропущенные за время остановки запуски не догоняются.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	if !s.leader.IsLeader() {
+		// Расписание пересчитается, когда экземпляр станет лидером
+		s.mu.Lock()
+		s.next = make(map[string]scheduledRun)
+		s.mu.Unlock()
+		return
+	}
+
+	jobs, err := s.jobs(ctx)
+	if err != nil {
+		return
+	}
+
+	var states map[string]models.ScheduleState
+	for _, job := range jobs {
+		if job.cron == nil {
+			continue
+		}
+
+		s.mu.Lock()
+		next, ok := s.next[job.name]
+		if !ok || next.spec != job.spec {
+			s.next[job.name] = scheduledRun{spec: job.spec, at: job.cron.Next(now)}
+			s.mu.Unlock()
+			continue
+		}
+		if now.Before(next.at) || s.running[job.name] {
+			s.mu.Unlock()
+			continue
+		}
+		s.running[job.name] = true
+		s.mu.Unlock()
+
+		if states == nil {
+			if states, err = s.states(ctx); err != nil {
+				s.mu.Lock()
+				delete(s.running, job.name)
+				s.mu.Unlock()
+				return
+			}
+		}
+
+		prev := states[job.name]
+		prev.Name = job.name
+
+		s.wg.Add(1)
+		go s.run(ctx, job, prev)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job scheduleJob, prev models.ScheduleState) {
+	defer s.wg.Done()
+
+	s.logger.Info("Scheduler: Running job", "name", job.name)
+
+	start := time.Now()
+	err := job.run(ctx, &prev)
+	finished := time.Now()
+
+	state := prev
+	state.LastRunAt = start
+	state.LastDurationMs = finished.Sub(start).Milliseconds()
+	state.NextRunAt = job.cron.Next(finished)
+	state.LastStatus = SyncStatusSuccess
+	state.LastError = ""
+	if err != nil {
+		state.LastStatus = SyncStatusError
+		state.LastError = err.Error()
+		s.logger.Error("Scheduled job failed", err, "name", job.name)
+	} else {
+		state.LastSuccessAt = start
+	}
+
+	if err := s.stateRepo.Save(ctx, &state); err != nil {
+		s.logger.Error("Failed to save schedule state", err, "name", job.name)
+	}
+
+	s.mu.Lock()
+	s.next[job.name] = scheduledRun{spec: job.spec, at: state.NextRunAt}
+	delete(s.running, job.name)
+	s.mu.Unlock()
+}
+
+// jobs - текущий набор заданий
+func (s *Scheduler) jobs(ctx context.Context) ([]scheduleJob, error) {
+	jobs := make([]scheduleJob, 0)
+
+	handlers := map[string]func(ctx context.Context, prev *models.ScheduleState) error{
+		ScheduleTypeLogRetention: s.deleteOldLogs,
+		ScheduleTypeTokenRefresh: s.refreshTokens,
+		ScheduleTypeSyncReport:   s.publishReports,
+	}
+	for jobType, spec := range s.maintenance {
+		if strings.EqualFold(spec, scheduleOff) {
+			continue
+		}
+		jobs = append(jobs, newScheduleJob(jobType, jobType, 0, spec, handlers[jobType]))
+	}
+
+	conns, err := s.connRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get connections for scheduler", err)
+		return nil, err
+	}
+	for i := range conns {
+		conn := &conns[i]
+		meta := connectionMetadata(conn)
+		spec, _ := meta["poll_schedule"].(string)
+		if !conn.IsActive || strings.TrimSpace(spec) == "" {
+			continue
+		}
+		entity, _ := meta["poll_entity"].(string)
+
+		name := ScheduleTypePoll + ":" + strconv.Itoa(conn.ID)
+		jobs = append(jobs, newScheduleJob(name, ScheduleTypePoll, conn.ID, spec, func(ctx context.Context, prev *models.ScheduleState) error {
+			return s.poll(ctx, conn, entity, prev)
+		}))
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].name < jobs[j].name })
+	return jobs, nil
+}
+
+func newScheduleJob(name, jobType string, connectionID int, spec string, run func(ctx context.Context, prev *models.ScheduleState) error) scheduleJob {
+	job := scheduleJob{
+		name:         name,
+		jobType:      jobType,
+		connectionID: connectionID,
+		spec:         strings.TrimSpace(spec),
+		run:          run,
+	}
+	job.cron, job.err = ParseCron(job.spec)
+	return job
+}
+
+func (s *Scheduler) states(ctx context.Context) (map[string]models.ScheduleState, error) {
+	list, err := s.stateRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]models.ScheduleState, len(list))
+	for _, state := range list {
+		states[state.Name] = state
+	}
+	return states, nil
+}
+
+// poll - забрать записи после сохраненной позиции опроса и поставить их
+// в очередь синхронизации. Позиция сдвигается в той же транзакции, поэтому
+// записи не теряются и не ставятся в очередь повторно.
+func (s *Scheduler) poll(ctx context.Context, conn *models.Connection, entity string, prev *models.ScheduleState) error {
+	connector, err := s.connectors.Get(conn.SystemType)
+	if err != nil {
+		return err
+	}
+
+	poller, ok := connector.(domain.RecordPoller)
+	if !ok {
+		return domain.NewErrorf("connector %q cannot poll records", conn.SystemType)
+	}
+	if entity == "" {
+		entity = connector.DefaultEntity()
+	}
+
+	checkpoint, err := s.checkpoints.Get(ctx, conn.ID, entity)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		checkpoint = &models.SyncCheckpoint{
+			ConnectionID: conn.ID,
+			Entity:       entity,
+			ModifiedAt:   time.Now().Add(-pollFirstWindow),
+		}
+	}
+
+	records, next, err := poller.PollRecords(ctx, conn, entity, checkpoint.Cursor(), time.Time{})
+	if err != nil {
+		return err
+	}
+
+	// Запуск опроса завершит воркер очереди, когда синхронизирует все его записи
+	syncID := 0
+	if len(records) > 0 {
+		syncID = s.runs.StartRun(ctx, &models.SyncRun{
+			TriggerType:        SyncRunPoll,
+			SourceConnectionID: conn.ID,
+		})
+	}
+
+	items := make([]models.SyncQueueItem, 0, len(records))
+	for _, payload := range records {
+		items = append(items, models.SyncQueueItem{
+			ConnectionID:   conn.ID,
+			Entity:         entity,
+			SourceRecordID: sourceRecordID(conn, payload),
+			Payload:        payload,
+			Status:         SyncQueuePending,
+			SyncID:         syncID,
+		})
+	}
+
+	checkpoint.ModifiedAt = next.ModifiedAt
+	checkpoint.RecordID = next.RecordID
+	checkpoint.PageToken = next.PageToken
+	if err := s.checkpoints.Advance(ctx, checkpoint, items); err != nil {
+		s.runs.FinishRun(ctx, syncID, err)
+		return err
+	}
+
+	s.logger.Info("Scheduler: Connection polled", "connection_id", conn.ID, "entity", entity, "records", len(records))
+	return nil
+}
+
+// deleteOldLogs - очистка журнала синхронизации и завершенных запусков
+// старше LOG_RETENTION_DAYS
+func (s *Scheduler) deleteOldLogs(ctx context.Context, prev *models.ScheduleState) error {
+	if err := s.logRepo.DeleteOldLogs(ctx, s.retentionDays); err != nil {
+		return err
+	}
+	return s.runs.DeleteOldRuns(ctx, s.retentionDays)
+}
+
+// refreshTokens - обновить истекающие токены подключений. О токенах, которые
+// коннектор обновить не может, пишется предупреждение.
+func (s *Scheduler) refreshTokens(ctx context.Context, prev *models.ScheduleState) error {
+	conns, err := s.connRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range conns {
+		conn := &conns[i]
+		if !conn.IsActive || !conn.ExpiresAt.Valid {
+			continue
+		}
+
+		left := time.Until(conn.ExpiresAt.Time)
+		if left > tokenExpiryWarning {
+			continue
+		}
+
+		connector, err := s.connectors.Get(conn.SystemType)
+		if err != nil {
+			continue
+		}
+
+		refresher, ok := connector.(domain.TokenRefresher)
+		if !ok || !conn.RefreshToken.Valid {
+			s.logger.Warn("Connection token expires soon", "connection_id", conn.ID, "expires_at", conn.ExpiresAt.Time)
+			continue
+		}
+		if left > tokenRefreshAhead {
+			continue
+		}
+
+		if err := refresher.RefreshToken(ctx, conn); err != nil {
+			s.logger.Error("Failed to refresh connection token", err, "connection_id", conn.ID)
+			failed++
+			continue
+		}
+		if err := s.connRepo.Update(ctx, conn); err != nil {
+			s.logger.Error("Failed to save refreshed token", err, "connection_id", conn.ID)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return domain.NewErrorf("%d connection tokens were not refreshed", failed)
+	}
+	return nil
+}
+
+// publishReports - событие sync.report по каждому подключению-источнику
+// с числом записей журнала по статусам с прошлого отчета
+func (s *Scheduler) publishReports(ctx context.Context, prev *models.ScheduleState) error {
+	end := time.Now().UTC()
+	start := prev.LastSuccessAt.UTC()
+	if prev.LastSuccessAt.IsZero() {
+		start = end.Add(-syncReportPeriod)
+	}
+
+	counts, err := s.logRepo.CountBySource(ctx, start)
+	if err != nil {
+		return err
+	}
+
+	for connectionID, statuses := range counts {
+		total := 0
+		for _, n := range statuses {
+			total += n
+		}
+
+		s.events.Publish(ctx, &domain.Event{
+			Type:          domain.EventSyncReport,
+			ConnectionIDs: []int{connectionID},
+			Data: map[string]interface{}{
+				"connection_id": connectionID,
+				"period_start":  start,
+				"period_end":    end,
+				"total":         total,
+				"statuses":      statuses,
+			},
+		})
+	}
+
+	return nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+type SchemaUseCase struct {
+	connRepo   domain.ConnectionRepository
+	connectors domain.ConnectorRegistry
+	cache      domain.CacheService
+	logger     domain.Logger
+}
+
+func NewSchemaUseCase(
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	cache domain.CacheService,
+	logger domain.Logger,
+) *SchemaUseCase {
+	return &SchemaUseCase{
+		connRepo:   connRepo,
+		connectors: connectors,
+		cache:      cache,
+		logger:     logger,
+	}
+}
+
+// EntitySchema - схема полей сущности подключения
+type EntitySchema struct {
+	ConnectionID int                      `json:"connection_id"`
+	SystemType   string                   `json:"system_type"`
+	Entity       string                   `json:"entity"`
+	Fields       []models.FieldDefinition `json:"fields"`
+	FetchedAt    time.Time                `json:"fetched_at"`
+	Cached       bool                     `json:"cached"`
+}
+
+// GetFields - получить схему полей через коннектор подключения.
+// Результат кэшируется, refresh принудительно запрашивает схему заново.
+func (uc *SchemaUseCase) GetFields(ctx context.Context, connectionID int, entity string, refresh bool) (*EntitySchema, error) {
+	uc.logger.Info("UseCase: Getting connection fields", "connection_id", connectionID, "entity", entity, "refresh", refresh)
+
+	conn, err := uc.connRepo.GetByID(ctx, connectionID)
+	if err != nil {
+		return nil, domain.NewErrorf("connection %d not found", connectionID)
+	}
+
+	connector, err := uc.connectors.Get(conn.SystemType)
+	if err != nil {
+		return nil, err
+	}
+
+	if entity == "" {
+		entity = connector.DefaultEntity()
+	}
+
+	key := schemaCacheKey(conn.ID, entity)
+	if !refresh {
+		if schema := uc.fromCache(key); schema != nil {
+			return schema, nil
+		}
+	}
+
+	fields, err := connector.GetFields(ctx, conn, entity)
+	if err != nil {
+		uc.logger.Error("Failed to fetch connection fields", err, "connection_id", conn.ID, "entity", entity)
+		return nil, err
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	schema := &EntitySchema{
+		ConnectionID: conn.ID,
+		SystemType:   conn.SystemType,
+		Entity:       entity,
+		Fields:       fields,
+		FetchedAt:    time.Now(),
+	}
+
+	if data, err := json.Marshal(schema); err == nil {
+		err := uc.cache.Set(key, data)
+		// Схема не помещается в кэш - ошибка конфигурации CACHE_SIZE, а не сбой
+		if errors.Is(err, domain.ErrCacheTooLarge) {
+			uc.logger.Error("Connection fields do not fit into cache, increase CACHE_SIZE", err, "connection_id", conn.ID, "entity", entity, "size", len(data))
+		} else if err != nil {
+			uc.logger.Warn("Failed to cache connection fields", "connection_id", conn.ID, "error", err.Error())
+		}
+	}
+
+	return schema, nil
+}
+
+// RequiredFields - имена обязательных и доступных для записи полей сущности
+func (uc *SchemaUseCase) RequiredFields(ctx context.Context, connectionID int, entity string) ([]string, error) {
+	schema, err := uc.GetFields(ctx, connectionID, entity, false)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make([]string, 0)
+	for _, f := range schema.Fields {
+		if f.Required && !f.ReadOnly {
+			required = append(required, f.Name)
+		}
+	}
+
+	return required, nil
+}
+
+func (uc *SchemaUseCase) fromCache(key string) *EntitySchema {
+	data, err := uc.cache.Get(key)
+	if err != nil || data == nil {
+		return nil
+	}
+
+	var schema EntitySchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil
+	}
+
+	schema.Cached = true
+	return &schema
+}
+
+func schemaCacheKey(connectionID int, entity string) string {
+	return fmt.Sprintf("schema:%d:%s", connectionID, entity)
+}
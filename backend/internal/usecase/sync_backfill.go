@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+
+	"go.uber.org/fx"
+)
+
+// Статусы выгрузок исторических записей
+const (
+	BackfillPending = "pending"
+	BackfillRunning = "running"
+	BackfillPaused  = "paused"
+	BackfillDone    = "done"
+	BackfillFailed  = "failed"
+	// BackfillCancelled - запуск выгрузки отменен, продолжить ее нельзя
+	BackfillCancelled = "cancelled"
+)
+
+const (
+	// BackfillEventType - тип события в sync_logs для записей выгрузки
+	BackfillEventType = "backfill"
+
+	backfillPollInterval = 5 * time.Second
+	backfillBatchSize    = 50
+)
+
+// BackfillRunner - выполняет выгрузки исторических записей. Работает только
+// на лидере: каждая выгрузка идет в своей горутине, сначала забирает записи
+// периода из источника, затем синхронизирует их в цель пары не быстрее
+// RatePerMinute. Пауза и отмена запуска выставляются через API и замечаются
+// после текущей записи; при потере лидерства выгрузки останавливаются и продолжатся на
+// новом лидере с сохраненного места.
+type BackfillRunner struct {
+	maxAttempts int
+	repo        domain.SyncBackfillRepository
+	connRepo    domain.ConnectionRepository
+	connectors  domain.ConnectorRegistry
+	engine      *SyncEngine
+	runs        *SyncRunUseCase
+	leader      domain.LeaderElector
+	logger      domain.Logger
+
+	mu      sync.Mutex
+	running map[int]context.CancelFunc
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewBackfillRunner(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	repo domain.SyncBackfillRepository,
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	engine *SyncEngine,
+	runs *SyncRunUseCase,
+	leader domain.LeaderElector,
+	logger domain.Logger,
+) *BackfillRunner {
+	b := &BackfillRunner{
+		maxAttempts: cfg.SyncMaxAttempts,
+		repo:        repo,
+		connRepo:    connRepo,
+		connectors:  connectors,
+		engine:      engine,
+		runs:        runs,
+		leader:      leader,
+		logger:      logger,
+		running:     make(map[int]context.CancelFunc),
+		stop:        make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			b.wg.Add(1)
+			go b.loop()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			b.logger.Info("Waiting for running backfills")
+			close(b.stop)
+
+			done := make(chan struct{})
+			go func() {
+				b.wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return b
+}
+
+func (b *BackfillRunner) loop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(backfillPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			b.cancelAll()
+			return
+		case <-ticker.C:
+			b.tick(context.Background())
+		}
+	}
+}
+
+// tick - запустить выгрузки, которые еще не выполняются на этом экземпляре
+func (b *BackfillRunner) tick(ctx context.Context) {
+	if !b.leader.IsLeader() {
+		b.cancelAll()
+		return
+	}
+
+	active, err := b.repo.GetActive(ctx)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := range active {
+		backfill := active[i]
+		if _, ok := b.running[backfill.ID]; ok {
+			continue
+		}
+
+		runCtx, cancel := context.WithCancel(context.Background())
+		b.running[backfill.ID] = cancel
+
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			defer b.release(backfill.ID)
+			b.run(runCtx, &backfill)
+		}()
+	}
+}
+
+func (b *BackfillRunner) release(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if cancel, ok := b.running[id]; ok {
+		cancel()
+		delete(b.running, id)
+	}
+}
+
+func (b *BackfillRunner) cancelAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, cancel := range b.running {
+		cancel()
+	}
+}
+
+// run - выполнить выгрузку до конца, паузы или отмены ctx. ctx только
+// останавливает выгрузку между записями: начатая запись дописывается.
+func (b *BackfillRunner) run(ctx context.Context, backfill *models.SyncBackfill) {
+	started, err := b.repo.Start(context.Background(), backfill)
+	if err != nil || !started {
+		return
+	}
+
+	b.logger.Info("BackfillRunner: Backfill started", "id", backfill.ID, "source_id", backfill.SourceConnectionID, "target_id", backfill.TargetConnectionID, "fetched", backfill.Fetched)
+
+	source, err := b.connRepo.GetByID(context.Background(), backfill.SourceConnectionID)
+	if err != nil {
+		b.fail(backfill, domain.NewErrorf("source connection %d not found", backfill.SourceConnectionID))
+		return
+	}
+	target, err := b.connRepo.GetByID(context.Background(), backfill.TargetConnectionID)
+	if err != nil {
+		b.fail(backfill, domain.NewErrorf("target connection %d not found", backfill.TargetConnectionID))
+		return
+	}
+	if !source.IsActive || !target.IsActive {
+		b.fail(backfill, domain.NewError("source or target connection is inactive"))
+		return
+	}
+
+	if !backfill.Fetched {
+		if err := b.fetch(ctx, source, backfill); err != nil {
+			b.fail(backfill, err)
+			return
+		}
+		if !backfill.Fetched || backfill.Status != BackfillRunning {
+			return
+		}
+	}
+
+	if done := b.sync(ctx, source, backfill); !done {
+		return
+	}
+
+	if err := b.repo.Finish(context.Background(), backfill.ID, BackfillDone, ""); err != nil {
+		return
+	}
+	b.runs.FinishRun(context.Background(), backfill.SyncID, nil)
+	b.logger.Info("BackfillRunner: Backfill finished", "id", backfill.ID, "processed", backfill.Processed, "failed", backfill.Failed)
+}
+
+// fetch - забрать из источника все записи периода. Каждая порция
+// сохраняется вместе с позицией чтения, поэтому после паузы или перезапуска
+// чтение продолжается с нее.
+func (b *BackfillRunner) fetch(ctx context.Context, source *models.Connection, backfill *models.SyncBackfill) error {
+	connector, err := b.connectors.Get(source.SystemType)
+	if err != nil {
+		return err
+	}
+	poller, ok := connector.(domain.RecordPoller)
+	if !ok {
+		return domain.NewErrorf("connector %q cannot poll records", source.SystemType)
+	}
+
+	pageToken := ""
+	for !backfill.Fetched {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		cursor := backfill.Cursor()
+		cursor.PageToken = pageToken
+		var records []map[string]interface{}
+		var next models.SyncCursor
+		err := retryTemporary(ctx, b.logger, b.maxAttempts, syncRetryDelay, new(int), func() (err error) {
+			records, next, err = poller.PollRecords(context.Background(), source, backfill.Entity, cursor, backfill.DateTo)
+			return err
+		})
+		// Повтор прерван остановкой - это не ошибка выгрузки
+		if err != nil && ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Источник сузил слишком большое окно и дочитает его следующим вызовом
+		pageToken = next.PageToken
+		if len(records) == 0 && pageToken != "" {
+			continue
+		}
+
+		items := make([]models.SyncBackfillItem, 0, len(records))
+		for _, payload := range records {
+			items = append(items, models.SyncBackfillItem{
+				BackfillID:     backfill.ID,
+				SourceRecordID: sourceRecordID(source, payload),
+				Payload:        payload,
+				Status:         SyncQueuePending,
+			})
+		}
+
+		// Источник больше ничего не отдает - период прочитан
+		fetched := len(records) == 0 || next == cursor
+		if err := b.repo.AddItems(context.Background(), backfill, items, next, fetched); err != nil {
+			return err
+		}
+
+		b.logger.Info("BackfillRunner: Records fetched", "id", backfill.ID, "records", len(records), "total", backfill.Total)
+
+		if backfill.Status != BackfillRunning {
+			b.logger.Info("BackfillRunner: Backfill stopped", "id", backfill.ID, "status", backfill.Status)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// sync - синхронизировать забранные записи в цель пары не быстрее
+// RatePerMinute. true - записей не осталось.
+func (b *BackfillRunner) sync(ctx context.Context, source *models.Connection, backfill *models.SyncBackfill) bool {
+	ticker := time.NewTicker(time.Minute / time.Duration(backfill.RatePerMinute))
+	defer ticker.Stop()
+
+	// Счетчики запуска пересчитываются после каждой пачки записей
+	defer b.runs.RefreshRun(context.Background(), backfill.SyncID)
+
+	for {
+		b.runs.RefreshRun(context.Background(), backfill.SyncID)
+
+		items, err := b.repo.NextItems(context.Background(), backfill.ID, backfillBatchSize)
+		if err != nil {
+			return false
+		}
+		if len(items) == 0 {
+			return true
+		}
+
+		for i := range items {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-ticker.C:
+			}
+
+			item := &items[i]
+			record := &SourceRecord{
+				EventType:      BackfillEventType,
+				RecordID:       item.SourceRecordID,
+				IdempotencyKey: idempotencyKey(source, "", item.Payload),
+				CorrelationID:  backfill.CorrelationID,
+				SyncID:         backfill.SyncID,
+				ChangedAt:      recordChangedAt(source, item.Payload),
+				Payload:        item.Payload,
+			}
+			result := b.engine.ProcessTarget(context.Background(), source, backfill.TargetConnectionID, record)
+
+			item.Status = SyncQueueDone
+			item.Error = result.Error
+			item.SyncLogID = result.SyncLogID
+			if result.Status == SyncStatusError {
+				item.Status = SyncQueueFailed
+			}
+
+			if err := b.repo.FinishItem(context.Background(), backfill, item); err != nil {
+				return false
+			}
+			if backfill.Status != BackfillRunning {
+				b.logger.Info("BackfillRunner: Backfill stopped", "id", backfill.ID, "status", backfill.Status, "processed", backfill.Processed, "remaining", backfill.Remaining())
+				return false
+			}
+		}
+	}
+}
+
+func (b *BackfillRunner) fail(backfill *models.SyncBackfill, cause error) {
+	b.logger.Error("BackfillRunner: Backfill failed", cause, "id", backfill.ID)
+
+	if err := b.repo.Finish(context.Background(), backfill.ID, BackfillFailed, cause.Error()); err != nil {
+		b.logger.Error("Failed to save sync backfill status", err, "id", backfill.ID)
+	}
+	b.runs.FinishRun(context.Background(), backfill.SyncID, cause)
+}
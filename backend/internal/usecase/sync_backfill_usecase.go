@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/utils"
+)
+
+const (
+	backfillDefaultRate = 60
+	backfillMaxRate     = 600
+)
+
+// BackfillParams - параметры новой выгрузки. Пустая сущность - poll_entity
+// источника или сущность коннектора по умолчанию, пустой To - текущее время.
+type BackfillParams struct {
+	SourceConnectionID int       `json:"source_connection_id"`
+	TargetConnectionID int       `json:"target_connection_id"`
+	Entity             string    `json:"entity"`
+	From               time.Time `json:"from"`
+	To                 time.Time `json:"to"`
+	RatePerMinute      int       `json:"rate_per_minute"`
+}
+
+// BackfillProgress - выгрузка с ходом выполнения
+type BackfillProgress struct {
+	ID                 int        `json:"id"`
+	SourceConnectionID int        `json:"source_connection_id"`
+	TargetConnectionID int        `json:"target_connection_id"`
+	Entity             string     `json:"entity"`
+	From               time.Time  `json:"from"`
+	To                 time.Time  `json:"to"`
+	RatePerMinute      int        `json:"rate_per_minute"`
+	Status             string     `json:"status"`
+	Fetched            bool       `json:"fetched"`
+	Total              int        `json:"total"`
+	Processed          int        `json:"processed"`
+	Failed             int        `json:"failed"`
+	Remaining          int        `json:"remaining"`
+	CorrelationID      string     `json:"correlation_id"`
+	SyncID             int        `json:"sync_id,omitempty"`
+	Error              string     `json:"error,omitempty"`
+	CreatedBy          string     `json:"created_by"`
+	StartedAt          *time.Time `json:"started_at"`
+	FinishedAt         *time.Time `json:"finished_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+func backfillProgress(b *models.SyncBackfill) BackfillProgress {
+	return BackfillProgress{
+		ID:                 b.ID,
+		SourceConnectionID: b.SourceConnectionID,
+		TargetConnectionID: b.TargetConnectionID,
+		Entity:             b.Entity,
+		From:               b.DateFrom,
+		To:                 b.DateTo,
+		RatePerMinute:      b.RatePerMinute,
+		Status:             b.Status,
+		Fetched:            b.Fetched,
+		Total:              b.Total,
+		Processed:          b.Processed,
+		Failed:             b.Failed,
+		Remaining:          b.Remaining(),
+		CorrelationID:      b.CorrelationID,
+		SyncID:             b.SyncID,
+		Error:              b.Error,
+		CreatedBy:          b.CreatedBy,
+		StartedAt:          timePtr(b.StartedAt),
+		FinishedAt:         timePtr(b.FinishedAt),
+		CreatedAt:          b.CreatedAt,
+	}
+}
+
+type SyncBackfillUseCase struct {
+	repo        domain.SyncBackfillRepository
+	connRepo    domain.ConnectionRepository
+	mappingRepo domain.MappingRepository
+	logRepo     domain.SyncLogRepository
+	connectors  domain.ConnectorRegistry
+	runs        *SyncRunUseCase
+	logger      domain.Logger
+}
+
+func NewSyncBackfillUseCase(
+	repo domain.SyncBackfillRepository,
+	connRepo domain.ConnectionRepository,
+	mappingRepo domain.MappingRepository,
+	logRepo domain.SyncLogRepository,
+	connectors domain.ConnectorRegistry,
+	runs *SyncRunUseCase,
+	logger domain.Logger,
+) *SyncBackfillUseCase {
+	return &SyncBackfillUseCase{
+		repo:        repo,
+		connRepo:    connRepo,
+		mappingRepo: mappingRepo,
+		logRepo:     logRepo,
+		connectors:  connectors,
+		runs:        runs,
+		logger:      logger,
+	}
+}
+
+// CreateBackfill - поставить выгрузку записей источника за период в цель
+// пары. Выгрузку запускает BackfillRunner на лидере.
+func (uc *SyncBackfillUseCase) CreateBackfill(ctx context.Context, params BackfillParams) (*BackfillProgress, error) {
+	if params.SourceConnectionID == params.TargetConnectionID {
+		return nil, domain.NewError("source and target connections must differ")
+	}
+
+	source, err := uc.connRepo.GetByID(ctx, params.SourceConnectionID)
+	if err != nil {
+		return nil, domain.NewErrorf("source connection %d not found", params.SourceConnectionID)
+	}
+	if _, err := uc.connRepo.GetByID(ctx, params.TargetConnectionID); err != nil {
+		return nil, domain.NewErrorf("target connection %d not found", params.TargetConnectionID)
+	}
+
+	connector, err := uc.connectors.Get(source.SystemType)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := connector.(domain.RecordPoller); !ok {
+		return nil, domain.NewErrorf("connector %q cannot read historical records", source.SystemType)
+	}
+
+	mappings, err := uc.mappingRepo.GetByConnectionPair(ctx, source.ID, params.TargetConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		return nil, domain.NewErrorf("no mappings configured for connections %d -> %d", source.ID, params.TargetConnectionID)
+	}
+
+	entity := strings.TrimSpace(params.Entity)
+	if entity == "" {
+		entity, _ = connectionMetadata(source)["poll_entity"].(string)
+	}
+	if entity == "" {
+		entity = connector.DefaultEntity()
+	}
+
+	now := time.Now()
+	to := params.To
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if params.From.IsZero() {
+		return nil, domain.NewError("from is required")
+	}
+	if !params.From.Before(to) {
+		return nil, domain.NewError("from must be before to")
+	}
+
+	rate := params.RatePerMinute
+	if rate == 0 {
+		rate = backfillDefaultRate
+	}
+	if rate < 1 || rate > backfillMaxRate {
+		return nil, domain.NewErrorf("rate_per_minute must be between 1 and %d", backfillMaxRate)
+	}
+
+	active, err := uc.repo.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range active {
+		if b.SourceConnectionID == source.ID && b.TargetConnectionID == params.TargetConnectionID {
+			return nil, domain.NewErrorf("backfill %d for this pair is already in progress", b.ID)
+		}
+	}
+
+	backfill := &models.SyncBackfill{
+		SourceConnectionID: source.ID,
+		TargetConnectionID: params.TargetConnectionID,
+		Entity:             entity,
+		DateFrom:           params.From,
+		DateTo:             to,
+		RatePerMinute:      rate,
+		Status:             BackfillPending,
+		CursorModifiedAt:   params.From,
+		CorrelationID:      utils.GenerateUUID(),
+		CreatedBy:          domain.UserFromContext(ctx),
+	}
+
+	uc.logger.Info("UseCase: Creating sync backfill", "source_id", source.ID, "target_id", backfill.TargetConnectionID, "entity", entity, "from", backfill.DateFrom, "to", backfill.DateTo)
+
+	backfill.SyncID = uc.runs.StartRun(ctx, &models.SyncRun{
+		TriggerType:        SyncRunBackfill,
+		SourceConnectionID: backfill.SourceConnectionID,
+		TargetConnectionID: backfill.TargetConnectionID,
+	})
+	if err := uc.repo.Create(ctx, backfill); err != nil {
+		uc.runs.FinishRun(ctx, backfill.SyncID, err)
+		return nil, err
+	}
+
+	progress := backfillProgress(backfill)
+	return &progress, nil
+}
+
+// GetBackfills - последние выгрузки
+func (uc *SyncBackfillUseCase) GetBackfills(ctx context.Context) ([]BackfillProgress, error) {
+	backfills, err := uc.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BackfillProgress, 0, len(backfills))
+	for i := range backfills {
+		result = append(result, backfillProgress(&backfills[i]))
+	}
+	return result, nil
+}
+
+func (uc *SyncBackfillUseCase) GetBackfill(ctx context.Context, id int) (*BackfillProgress, error) {
+	backfill, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if backfill == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	progress := backfillProgress(backfill)
+	return &progress, nil
+}
+
+// GetBackfillLogs - журнал синхронизации выгрузки: записи ее запуска или,
+// для выгрузок без запуска, записи с общим correlation_id
+func (uc *SyncBackfillUseCase) GetBackfillLogs(ctx context.Context, id int) ([]models.SyncLog, error) {
+	backfill, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if backfill == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	if backfill.SyncID > 0 {
+		return uc.logRepo.GetBySyncID(ctx, backfill.SyncID)
+	}
+	return uc.logRepo.GetByCorrelationID(ctx, backfill.CorrelationID)
+}
+
+// PauseBackfill - приостановить выгрузку; запись, которая синхронизируется
+// в этот момент, будет дописана
+func (uc *SyncBackfillUseCase) PauseBackfill(ctx context.Context, id int) (*BackfillProgress, error) {
+	uc.logger.Info("UseCase: Pausing sync backfill", "id", id)
+	return uc.setStatus(ctx, id, "paused", BackfillPaused, BackfillPending, BackfillRunning)
+}
+
+// ResumeBackfill - продолжить приостановленную или упавшую выгрузку с места
+// остановки
+func (uc *SyncBackfillUseCase) ResumeBackfill(ctx context.Context, id int) (*BackfillProgress, error) {
+	uc.logger.Info("UseCase: Resuming sync backfill", "id", id)
+
+	progress, err := uc.setStatus(ctx, id, "resumed", BackfillPending, BackfillPaused, BackfillFailed)
+	if err != nil {
+		return nil, err
+	}
+	// Запуск упавшей выгрузки был завершен ошибкой
+	uc.runs.ReopenRun(ctx, progress.SyncID)
+
+	return progress, nil
+}
+
+func (uc *SyncBackfillUseCase) setStatus(ctx context.Context, id int, action, status string, from ...string) (*BackfillProgress, error) {
+	backfill, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if backfill == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	changed, err := uc.repo.SetStatus(ctx, id, status, from...)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, domain.NewErrorf("backfill %d is %s and cannot be %s", id, backfill.Status, action)
+	}
+
+	return uc.GetBackfill(ctx, id)
+}
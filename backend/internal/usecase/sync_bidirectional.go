@@ -0,0 +1,495 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// Стороны пары подключений
+const (
+	SideSource = "source"
+	SideTarget = "target"
+)
+
+// Политики разрешения конфликтов двусторонней пары
+const (
+	ConflictSourceWins     = "source_wins"
+	ConflictTargetWins     = "target_wins"
+	ConflictLastWriterWins = "last_writer_wins"
+	ConflictManual         = "manual"
+)
+
+const (
+	ConflictStatusOpen     = "open"
+	ConflictStatusResolved = "resolved"
+)
+
+const (
+	// SyncActionEcho - событие вернуло данные, которые движок сам записал
+	SyncActionEcho = "echo"
+	// SyncActionConflict - все изменения ждут ручного разрешения конфликта
+	SyncActionConflict = "conflict"
+)
+
+var conflictPolicies = map[string]bool{
+	ConflictSourceWins:     true,
+	ConflictTargetWins:     true,
+	ConflictLastWriterWins: true,
+	ConflictManual:         true,
+}
+
+// linkedSides - обе стороны двусторонней пары
+type linkedSides struct {
+	pair     *models.SyncPair
+	mappings []models.FieldMapping
+	conns    map[string]*models.Connection
+	writers  map[string]domain.RecordWriter
+	entities map[string]string
+}
+
+// fieldConflict - поле, измененное на обеих сторонах
+type fieldConflict struct {
+	field    string
+	incoming interface{} // значение стороны события
+	current  interface{} // значение другой стороны
+	policy   string
+	winner   string // пусто для manual
+}
+
+// mergeResult - итог сведения изменений стороны события с состоянием связи.
+// Все поля в терминах цели пары.
+type mergeResult struct {
+	apply     map[string]interface{} // записать на другую сторону
+	revert    map[string]interface{} // вернуть стороне события значение победителя
+	conflicts []fieldConflict
+}
+
+// mergeChanges - поле изменено стороной, если его значение отличается от
+// synced_data связи. Конфликт - сторона изменила поле раньше, чем в нее были
+// записаны изменения другой стороны, затронувшие это же поле.
+func mergeChanges(pair *models.SyncPair, link *models.RecordLink, side string, incoming map[string]interface{}, changedAt time.Time) *mergeResult {
+	result := &mergeResult{
+		apply:  make(map[string]interface{}),
+		revert: make(map[string]interface{}),
+	}
+
+	lastFields := make(map[string]bool, len(link.SyncedFields))
+	for _, field := range link.SyncedFields {
+		lastFields[field] = true
+	}
+	concurrent := link.SyncedFrom != "" && link.SyncedFrom != side && changedAt.Before(link.SyncedAt)
+
+	for _, field := range fieldNames(incoming) {
+		value := incoming[field]
+		current, known := link.SyncedData[field]
+		if known && sameJSON(value, current) || !known && value == nil {
+			continue
+		}
+
+		if !concurrent || !lastFields[field] {
+			result.apply[field] = value
+			continue
+		}
+
+		conflict := fieldConflict{
+			field:    field,
+			incoming: value,
+			current:  current,
+			policy:   conflictPolicy(pair, field),
+		}
+		switch conflict.policy {
+		case ConflictSourceWins:
+			conflict.winner = SideSource
+		case ConflictTargetWins:
+			conflict.winner = SideTarget
+		case ConflictLastWriterWins:
+			conflict.winner = otherSide(side)
+			if changedAt.After(link.ChangedAt) {
+				conflict.winner = side
+			}
+		}
+
+		switch conflict.winner {
+		case "":
+		case side:
+			result.apply[field] = value
+		default:
+			result.revert[field] = current
+		}
+		result.conflicts = append(result.conflicts, conflict)
+	}
+
+	return result
+}
+
+// conflictPolicy - политика поля цели, иначе политика пары
+func conflictPolicy(pair *models.SyncPair, field string) string {
+	if policy := pair.FieldPolicies[field]; policy != "" {
+		return policy
+	}
+	if pair.ConflictPolicy != "" {
+		return pair.ConflictPolicy
+	}
+	return ConflictLastWriterWins
+}
+
+func otherSide(side string) string {
+	if side == SideSource {
+		return SideTarget
+	}
+	return SideSource
+}
+
+// loadSides - коннекторы обеих сторон пары; обе должны уметь записывать данные
+func (e *SyncEngine) loadSides(ctx context.Context, pair *models.SyncPair, source, target *models.Connection, mappings []models.FieldMapping) (*linkedSides, error) {
+	sides := &linkedSides{
+		pair:     pair,
+		mappings: mappings,
+		conns:    map[string]*models.Connection{SideSource: source, SideTarget: target},
+		writers:  make(map[string]domain.RecordWriter, 2),
+		entities: make(map[string]string, 2),
+	}
+
+	for side, conn := range sides.conns {
+		connector, err := e.connectors.Get(conn.SystemType)
+		if err != nil {
+			return nil, err
+		}
+
+		writer, ok := connector.(domain.RecordWriter)
+		if !ok {
+			return nil, domain.NewErrorf("connector %q cannot write records", conn.SystemType)
+		}
+
+		sides.writers[side] = writer
+		sides.entities[side] = connector.DefaultEntity()
+	}
+
+	if mappings == nil {
+		var err error
+		sides.mappings, err = e.mappingRepo.GetByConnectionPair(ctx, source.ID, target.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sides, nil
+}
+
+// write - записать поля (в терминах цели) в запись стороны пары
+func (s *linkedSides) write(ctx context.Context, side, recordID string, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if side == SideSource {
+		fields = toSourceFields(s.mappings, fields)
+	}
+	return s.writers[side].UpdateRecord(ctx, s.conns[side], s.entities[side], recordID, fields)
+}
+
+func (s *linkedSides) recordID(link *models.RecordLink, side string) string {
+	if side == SideSource {
+		return link.SourceRecordID
+	}
+	return link.TargetRecordID
+}
+
+// syncLinked - синхронизация связанной записи двусторонней пары. Изменения
+// стороны события записываются на другую сторону, проигравшие в конфликте
+// значения возвращаются на сторону события. Событие без изменений - эхо
+// собственной записи движка. Действие, число конфликтов и попытки записи
+// сохраняются в result.
+func (e *SyncEngine) syncLinked(ctx context.Context, result *SyncResult, sides *linkedSides, link *models.RecordLink, side string, incoming map[string]interface{}, changedAt time.Time) error {
+	merge := mergeChanges(sides.pair, link, side, incoming, changedAt)
+
+	if len(merge.apply) == 0 && len(merge.revert) == 0 && len(merge.conflicts) == 0 {
+		result.Action = SyncActionUnchanged
+		if link.SyncedFrom != "" && link.SyncedFrom != side {
+			e.logger.Debug("SyncEngine: Suppressed echo", "link_id", link.ID, "side", side)
+			result.Action = SyncActionEcho
+		}
+		return nil
+	}
+
+	other := otherSide(side)
+	err := e.retry(ctx, result, func() error {
+		return sides.write(ctx, other, sides.recordID(link, other), merge.apply)
+	})
+	if err != nil {
+		return err
+	}
+	err = e.retry(ctx, result, func() error {
+		return sides.write(ctx, side, sides.recordID(link, side), merge.revert)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range merge.conflicts {
+		e.saveConflict(ctx, sides, link, side, c)
+	}
+
+	synced := make(map[string]interface{}, len(link.SyncedData)+len(merge.apply))
+	for field, value := range link.SyncedData {
+		synced[field] = value
+	}
+	for field, value := range merge.apply {
+		synced[field] = value
+	}
+
+	link.SyncedData = synced
+	link.SyncedFields = fieldNames(merge.apply)
+	link.SyncedFrom = side
+	link.SyncedAt = time.Now()
+	link.ChangedAt = changedAt
+	if err := e.linkRepo.SaveSyncState(ctx, link); err != nil {
+		e.logger.Error("SyncEngine: Failed to save link state", err, "link_id", link.ID)
+	}
+
+	result.Action = SyncActionUpdated
+	if len(merge.apply) == 0 && len(merge.revert) == 0 {
+		result.Action = SyncActionConflict
+	}
+	result.Conflicts = len(merge.conflicts)
+	return nil
+}
+
+func (e *SyncEngine) saveConflict(ctx context.Context, sides *linkedSides, link *models.RecordLink, side string, c fieldConflict) {
+	sourceValue, targetValue := c.incoming, c.current
+	if side == SideTarget {
+		sourceValue, targetValue = c.current, c.incoming
+	}
+
+	conflict := &models.SyncConflict{
+		RecordLinkID:       link.ID,
+		SourceConnectionID: link.SourceConnectionID,
+		TargetConnectionID: link.TargetConnectionID,
+		SourceRecordID:     link.SourceRecordID,
+		TargetRecordID:     link.TargetRecordID,
+		SourceField:        sourceFieldName(sides.mappings, c.field),
+		TargetField:        c.field,
+		SourceValue:        marshalValue(sourceValue),
+		TargetValue:        marshalValue(targetValue),
+		Policy:             c.policy,
+		Status:             ConflictStatusOpen,
+	}
+	if c.winner != "" {
+		conflict.Status = ConflictStatusResolved
+		conflict.Resolution = c.winner
+		conflict.ResolvedAt.Time, conflict.ResolvedAt.Valid = time.Now(), true
+	}
+
+	if err := e.conflicts.Create(ctx, conflict); err != nil {
+		e.logger.Error("SyncEngine: Failed to save conflict", err, "link_id", link.ID, "field", c.field)
+	}
+}
+
+// syncReverse - изменение записи цели двусторонней пары возвращается в
+// источник по тем же сопоставлениям в обратную сторону
+func (e *SyncEngine) syncReverse(ctx context.Context, target *models.Connection, pair *models.SyncPair, record *SourceRecord) SyncResult {
+	result := SyncResult{TargetConnectionID: pair.SourceConnectionID}
+
+	if record.RecordID == "" {
+		return e.fail(ctx, result, target.ID, record, nil, domain.NewError("record ID is required for reverse sync"))
+	}
+
+	source, err := e.connRepo.GetByID(ctx, pair.SourceConnectionID)
+	if err != nil {
+		return e.fail(ctx, result, target.ID, record, nil, domain.NewErrorf("source connection %d not found", pair.SourceConnectionID))
+	}
+
+	if !source.IsActive {
+		result.Status = SyncStatusSkipped
+		result.Error = "source connection is inactive"
+		return result
+	}
+
+	sides, err := e.loadSides(ctx, pair, source, target, nil)
+	if err != nil {
+		return e.fail(ctx, result, target.ID, record, nil, err)
+	}
+
+	incoming := reverseIncoming(sides.mappings, record.Payload)
+	reverse := toSourceFields(sides.mappings, incoming)
+
+	link, err := e.linkRepo.FindByTarget(ctx, source.ID, target.ID, record.RecordID, sides.entities[SideTarget])
+	if err != nil {
+		return e.fail(ctx, result, target.ID, record, reverse, err)
+	}
+
+	if link != nil {
+		result.RecordID = link.SourceRecordID
+
+		if err := e.syncLinked(ctx, &result, sides, link, SideTarget, incoming, record.changedAt()); err != nil {
+			return e.fail(ctx, result, target.ID, record, reverse, err)
+		}
+
+		return e.linkedResult(ctx, target.ID, record, reverse, result)
+	}
+
+	var recordID string
+	err = e.retry(ctx, &result, func() (err error) {
+		recordID, err = sides.writers[SideSource].CreateRecord(ctx, source, sides.entities[SideSource], reverse)
+		return err
+	})
+	if err != nil {
+		return e.fail(ctx, result, target.ID, record, reverse, err)
+	}
+	result.Action = SyncActionCreated
+	result.RecordID = recordID
+
+	err = e.linkRepo.Save(ctx, &models.RecordLink{
+		SourceConnectionID: source.ID,
+		SourceRecordID:     recordID,
+		TargetConnectionID: target.ID,
+		TargetRecordID:     record.RecordID,
+		EntityType:         sides.entities[SideTarget],
+		SyncedData:         incoming,
+		SyncedFields:       fieldNames(incoming),
+		SyncedFrom:         SideTarget,
+		SyncedAt:           time.Now(),
+		ChangedAt:          record.changedAt(),
+	})
+	if err != nil {
+		result.Warnings = append(result.Warnings, "record link is not saved: "+err.Error())
+	}
+
+	result.Status = SyncStatusSuccess
+	result.SyncLogID = e.log(ctx, successLog(target.ID, record, reverse, result))
+
+	return result
+}
+
+// linkedResult - статус и запись журнала по действию над связанной записью
+func (e *SyncEngine) linkedResult(ctx context.Context, sourceID int, record *SourceRecord, data map[string]interface{}, result SyncResult) SyncResult {
+	switch result.Action {
+	case SyncActionEcho, SyncActionUnchanged:
+		result.Status = SyncStatusSkipped
+	default:
+		result.Status = SyncStatusSuccess
+	}
+	if result.Conflicts > 0 {
+		result.Warnings = append(result.Warnings, "conflicting changes detected, see /api/sync/conflicts")
+	}
+
+	result.SyncLogID = e.log(ctx, successLog(sourceID, record, data, result))
+	return result
+}
+
+// ResolveConflict - записать значение победившей стороны на проигравшую
+func (e *SyncEngine) ResolveConflict(ctx context.Context, conflict *models.SyncConflict, winner string) error {
+	link, err := e.linkRepo.GetByID(ctx, conflict.RecordLinkID)
+	if err != nil {
+		return domain.NewErrorf("record link %d not found", conflict.RecordLinkID)
+	}
+
+	pair, err := e.pairRepo.Get(ctx, link.SourceConnectionID, link.TargetConnectionID)
+	if err != nil {
+		return err
+	}
+	if pair == nil {
+		pair = &models.SyncPair{SourceConnectionID: link.SourceConnectionID, TargetConnectionID: link.TargetConnectionID}
+	}
+
+	source, err := e.connRepo.GetByID(ctx, link.SourceConnectionID)
+	if err != nil {
+		return domain.NewErrorf("source connection %d not found", link.SourceConnectionID)
+	}
+	target, err := e.connRepo.GetByID(ctx, link.TargetConnectionID)
+	if err != nil {
+		return domain.NewErrorf("target connection %d not found", link.TargetConnectionID)
+	}
+
+	sides, err := e.loadSides(ctx, pair, source, target, nil)
+	if err != nil {
+		return err
+	}
+
+	raw := conflict.SourceValue
+	if winner == SideTarget {
+		raw = conflict.TargetValue
+	}
+	var value interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+	}
+
+	loser := otherSide(winner)
+	err = retryTemporary(ctx, e.logger, e.maxAttempts, e.retryDelay, new(int), func() error {
+		return sides.write(ctx, loser, sides.recordID(link, loser), map[string]interface{}{conflict.TargetField: value})
+	})
+	if err != nil {
+		return err
+	}
+
+	if link.SyncedData == nil {
+		link.SyncedData = make(map[string]interface{})
+	}
+	link.SyncedData[conflict.TargetField] = value
+	link.SyncedFields = []string{conflict.TargetField}
+	link.SyncedFrom = winner
+	link.SyncedAt = time.Now()
+
+	return e.linkRepo.SaveSyncState(ctx, link)
+}
+
+// reverseIncoming - значения сопоставленных полей цели из данных события цели
+func reverseIncoming(mappings []models.FieldMapping, payload map[string]interface{}) map[string]interface{} {
+	source := flattenSourcePayload(payload)
+	incoming := make(map[string]interface{})
+	for _, m := range mappings {
+		if value, ok := lookupField(source, m.TargetField); ok {
+			incoming[m.TargetField] = value
+		}
+	}
+	return incoming
+}
+
+// toSourceFields - поля цели в поля источника по обратным сопоставлениям.
+// Преобразования не обращаются, значение переносится как есть.
+func toSourceFields(mappings []models.FieldMapping, fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(fields))
+	for field, value := range fields {
+		if name := sourceFieldName(mappings, field); name != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+func sourceFieldName(mappings []models.FieldMapping, targetField string) string {
+	for _, m := range mappings {
+		if m.TargetField == targetField {
+			return m.SourceField
+		}
+	}
+	return ""
+}
+
+func fieldNames(fields map[string]interface{}) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sameJSON(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+func marshalValue(v interface{}) json.RawMessage {
+	raw, _ := json.Marshal(v)
+	return raw
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"integration-app/internal/domain/models"
+)
+
+func TestConflictPolicy(t *testing.T) {
+	tests := []struct {
+		name  string
+		pair  *models.SyncPair
+		field string
+		want  string
+	}{
+		{"default", &models.SyncPair{}, "TITLE", ConflictLastWriterWins},
+		{"pair policy", &models.SyncPair{ConflictPolicy: ConflictManual}, "TITLE", ConflictManual},
+		{"field policy wins over pair", &models.SyncPair{ConflictPolicy: ConflictManual, FieldPolicies: map[string]string{"TITLE": ConflictSourceWins}}, "TITLE", ConflictSourceWins},
+		{"other field uses pair policy", &models.SyncPair{ConflictPolicy: ConflictTargetWins, FieldPolicies: map[string]string{"TITLE": ConflictSourceWins}}, "PHONE", ConflictTargetWins},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conflictPolicy(tt.pair, tt.field); got != tt.want {
+				t.Errorf("conflictPolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Источник изменил TITLE в 10:05, движок записал его в цель в 10:10
+func TestMergeChanges(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2026, 1, 10, 10, minute, 0, 0, time.UTC)
+	}
+	link := func() *models.RecordLink {
+		return &models.RecordLink{
+			SyncedData:   map[string]interface{}{"TITLE": "A", "PHONE": "1"},
+			SyncedFields: []string{"TITLE"},
+			SyncedFrom:   SideSource,
+			SyncedAt:     at(10),
+			ChangedAt:    at(5),
+		}
+	}
+	lww := &models.SyncPair{ConflictPolicy: ConflictLastWriterWins}
+
+	tests := []struct {
+		name      string
+		pair      *models.SyncPair
+		side      string
+		incoming  map[string]interface{}
+		changedAt time.Time
+		apply     map[string]interface{}
+		revert    map[string]interface{}
+		winners   []string // победитель каждого конфликта, пусто - manual
+	}{
+		{
+			name: "echo of own write", pair: lww, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "A", "PHONE": "1"}, changedAt: at(11),
+		},
+		{
+			name: "unknown empty field is not a change", pair: lww, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "A", "EMAIL": nil}, changedAt: at(11),
+		},
+		{
+			name: "change after sync", pair: lww, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "B"}, changedAt: at(11),
+			apply: map[string]interface{}{"TITLE": "B"},
+		},
+		{
+			name: "concurrent change of another field", pair: lww, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "A", "PHONE": "2"}, changedAt: at(8),
+			apply: map[string]interface{}{"PHONE": "2"},
+		},
+		{
+			name: "same side is never concurrent", pair: lww, side: SideSource,
+			incoming: map[string]interface{}{"TITLE": "C"}, changedAt: at(8),
+			apply: map[string]interface{}{"TITLE": "C"},
+		},
+		{
+			name: "last writer wins: later change applies", pair: lww, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "B"}, changedAt: at(8),
+			apply:   map[string]interface{}{"TITLE": "B"},
+			winners: []string{SideTarget},
+		},
+		{
+			name: "last writer wins: earlier change is reverted", pair: lww, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "B"}, changedAt: at(3),
+			revert:  map[string]interface{}{"TITLE": "A"},
+			winners: []string{SideSource},
+		},
+		{
+			name: "source wins", pair: &models.SyncPair{ConflictPolicy: ConflictSourceWins}, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "B"}, changedAt: at(8),
+			revert:  map[string]interface{}{"TITLE": "A"},
+			winners: []string{SideSource},
+		},
+		{
+			name: "field policy overrides pair", pair: &models.SyncPair{ConflictPolicy: ConflictSourceWins, FieldPolicies: map[string]string{"TITLE": ConflictTargetWins}}, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "B"}, changedAt: at(3),
+			apply:   map[string]interface{}{"TITLE": "B"},
+			winners: []string{SideTarget},
+		},
+		{
+			name: "manual keeps both values", pair: &models.SyncPair{ConflictPolicy: ConflictManual}, side: SideTarget,
+			incoming: map[string]interface{}{"TITLE": "B", "PHONE": "2"}, changedAt: at(8),
+			apply:   map[string]interface{}{"PHONE": "2"},
+			winners: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeChanges(tt.pair, link(), tt.side, tt.incoming, tt.changedAt)
+
+			if tt.apply == nil {
+				tt.apply = map[string]interface{}{}
+			}
+			if tt.revert == nil {
+				tt.revert = map[string]interface{}{}
+			}
+			if !reflect.DeepEqual(got.apply, tt.apply) {
+				t.Errorf("apply = %v, want %v", got.apply, tt.apply)
+			}
+			if !reflect.DeepEqual(got.revert, tt.revert) {
+				t.Errorf("revert = %v, want %v", got.revert, tt.revert)
+			}
+
+			winners := make([]string, 0, len(got.conflicts))
+			for _, c := range got.conflicts {
+				winners = append(winners, c.winner)
+			}
+			if tt.winners == nil {
+				tt.winners = []string{}
+			}
+			if !reflect.DeepEqual(winners, tt.winners) {
+				t.Errorf("conflict winners = %q, want %q", winners, tt.winners)
+			}
+		})
+	}
+}
+
+func TestSyncLinkedWithoutChanges(t *testing.T) {
+	e := &SyncEngine{logger: nopLogger{}}
+	sides := &linkedSides{pair: &models.SyncPair{}}
+	incoming := map[string]interface{}{"TITLE": "A"}
+
+	tests := []struct {
+		name       string
+		syncedFrom string
+		side       string
+		want       string
+	}{
+		{"event from the other side is an echo", SideSource, SideTarget, SyncActionEcho},
+		{"repeated event of the same side", SideTarget, SideTarget, SyncActionUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &models.RecordLink{
+				SyncedData: map[string]interface{}{"TITLE": "A"},
+				SyncedFrom: tt.syncedFrom,
+				SyncedAt:   time.Now(),
+			}
+
+			var result SyncResult
+			if err := e.syncLinked(context.Background(), &result, sides, link, tt.side, incoming, time.Now()); err != nil {
+				t.Fatalf("syncLinked: %v", err)
+			}
+			if result.Action != tt.want {
+				t.Errorf("action = %q, want %q", result.Action, tt.want)
+			}
+		})
+	}
+}
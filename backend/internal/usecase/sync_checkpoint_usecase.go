@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+type SyncCheckpointUseCase struct {
+	repo       domain.SyncCheckpointRepository
+	connRepo   domain.ConnectionRepository
+	connectors domain.ConnectorRegistry
+	logger     domain.Logger
+}
+
+func NewSyncCheckpointUseCase(
+	repo domain.SyncCheckpointRepository,
+	connRepo domain.ConnectionRepository,
+	connectors domain.ConnectorRegistry,
+	logger domain.Logger,
+) *SyncCheckpointUseCase {
+	return &SyncCheckpointUseCase{
+		repo:       repo,
+		connRepo:   connRepo,
+		connectors: connectors,
+		logger:     logger,
+	}
+}
+
+// GetCheckpoints - позиции опроса всех подключений
+func (uc *SyncCheckpointUseCase) GetCheckpoints(ctx context.Context) ([]models.SyncCheckpoint, error) {
+	return uc.repo.GetAll(ctx)
+}
+
+// ResetCheckpoint - начать опрос заново с момента since. Записи, измененные
+// после него, будут забраны повторно; уже синхронизированные без изменений
+// отсекаются дедупликацией. Пустая сущность - poll_entity подключения,
+// а без него сущность коннектора по умолчанию, как при опросе.
+func (uc *SyncCheckpointUseCase) ResetCheckpoint(ctx context.Context, connectionID int, entity string, since time.Time) (*models.SyncCheckpoint, error) {
+	conn, err := uc.connRepo.GetByID(ctx, connectionID)
+	if err != nil {
+		return nil, domain.NewErrorf("connection %d not found", connectionID)
+	}
+
+	entity = strings.TrimSpace(entity)
+	if entity == "" {
+		entity, _ = connectionMetadata(conn)["poll_entity"].(string)
+	}
+	if entity == "" {
+		connector, err := uc.connectors.Get(conn.SystemType)
+		if err != nil {
+			return nil, err
+		}
+		entity = connector.DefaultEntity()
+	}
+	if since.IsZero() {
+		return nil, domain.NewError("since is required")
+	}
+	if since.After(time.Now()) {
+		return nil, domain.NewError("since cannot be in the future")
+	}
+
+	uc.logger.Info("UseCase: Resetting sync checkpoint", "connection_id", conn.ID, "entity", entity, "since", since)
+
+	checkpoint := &models.SyncCheckpoint{
+		ConnectionID: conn.ID,
+		Entity:       entity,
+		ModifiedAt:   since,
+	}
+	if err := uc.repo.Reset(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+type SyncConflictUseCase struct {
+	repo   domain.SyncConflictRepository
+	engine *SyncEngine
+	logger domain.Logger
+}
+
+func NewSyncConflictUseCase(
+	repo domain.SyncConflictRepository,
+	engine *SyncEngine,
+	logger domain.Logger,
+) *SyncConflictUseCase {
+	return &SyncConflictUseCase{
+		repo:   repo,
+		engine: engine,
+		logger: logger,
+	}
+}
+
+// GetConflicts - конфликты двусторонней синхронизации; status: open, resolved или пусто
+func (uc *SyncConflictUseCase) GetConflicts(ctx context.Context, status string) ([]models.SyncConflict, error) {
+	uc.logger.Info("UseCase: Getting sync conflicts", "status", status)
+
+	if status != "" && status != ConflictStatusOpen && status != ConflictStatusResolved {
+		return nil, domain.NewErrorf("unknown conflict status %q", status)
+	}
+
+	return uc.repo.GetByStatus(ctx, status)
+}
+
+func (uc *SyncConflictUseCase) GetConflictByID(ctx context.Context, id int) (*models.SyncConflict, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+// ResolveConflict - решение пользователя по конфликту из очереди ручного
+// разбора: значение стороны winner записывается на другую сторону
+func (uc *SyncConflictUseCase) ResolveConflict(ctx context.Context, id int, winner string) (*models.SyncConflict, error) {
+	uc.logger.Info("UseCase: Resolving sync conflict", "id", id, "winner", winner)
+
+	if winner != SideSource && winner != SideTarget {
+		return nil, domain.NewError("winner must be source or target")
+	}
+
+	conflict, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, domain.NewErrorf("conflict %d not found", id)
+	}
+
+	if conflict.Status != ConflictStatusOpen {
+		return nil, domain.NewErrorf("conflict %d is already resolved", id)
+	}
+
+	if err := uc.engine.ResolveConflict(ctx, conflict, winner); err != nil {
+		return nil, err
+	}
+
+	return uc.repo.Resolve(ctx, id, winner)
+}
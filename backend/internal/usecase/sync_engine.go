@@ -0,0 +1,591 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/utils"
+)
+
+const (
+	SyncStatusSkipped   = "skipped"
+	SyncStatusDuplicate = "duplicate"
+)
+
+// Действие движка над записью цели
+const (
+	SyncActionCreated   = "created"
+	SyncActionUpdated   = "updated"
+	SyncActionUnchanged = "unchanged"
+
+	// Запись не была связана, но в цели нашелся дубликат по полям пары
+	SyncActionMatchedUpdated = "matched_updated"
+	SyncActionMatchedCreated = "matched_created"
+	SyncActionMatchedSkipped = "matched_skipped"
+)
+
+// syncRetryDelay - пауза перед второй попыткой записи, дальше удваивается
+const syncRetryDelay = time.Second
+
+// SyncEngine - общий конвейер синхронизации: запись источника проходит через
+// активные сопоставления каждой пары источника и создается в целевой системе.
+// Результат по каждой цели сохраняется в sync_logs.
+type SyncEngine struct {
+	maxAttempts int
+	retryDelay  time.Duration
+	connRepo    domain.ConnectionRepository
+	mappingRepo domain.MappingRepository
+	linkRepo    domain.RecordLinkRepository
+	pairRepo    domain.SyncPairRepository
+	conflicts   domain.SyncConflictRepository
+	connectors  domain.ConnectorRegistry
+	schemaUC    *SchemaUseCase
+	syncUC      *SyncUseCase
+	logger      domain.Logger
+}
+
+func NewSyncEngine(
+	cfg *config.Config,
+	connRepo domain.ConnectionRepository,
+	mappingRepo domain.MappingRepository,
+	linkRepo domain.RecordLinkRepository,
+	pairRepo domain.SyncPairRepository,
+	conflicts domain.SyncConflictRepository,
+	connectors domain.ConnectorRegistry,
+	schemaUC *SchemaUseCase,
+	syncUC *SyncUseCase,
+	logger domain.Logger,
+) *SyncEngine {
+	return &SyncEngine{
+		maxAttempts: cfg.SyncMaxAttempts,
+		retryDelay:  syncRetryDelay,
+		connRepo:    connRepo,
+		mappingRepo: mappingRepo,
+		linkRepo:    linkRepo,
+		pairRepo:    pairRepo,
+		conflicts:   conflicts,
+		connectors:  connectors,
+		schemaUC:    schemaUC,
+		syncUC:      syncUC,
+		logger:      logger,
+	}
+}
+
+// SourceRecord - запись источника, поступившая в конвейер. По RecordID
+// повторная синхронизация записи обновляет ранее созданную запись цели.
+// ChangedAt - время изменения записи в источнике, по умолчанию время приема.
+// CorrelationID объединяет в журнале все доставки и шаги потоков записи,
+// SyncID - запуск синхронизации, в который входит запись (0 - вне запуска).
+type SourceRecord struct {
+	EventType      string
+	RecordID       string
+	IdempotencyKey string
+	CorrelationID  string
+	SyncID         int
+	ChangedAt      time.Time
+	Payload        map[string]interface{}
+}
+
+func (r *SourceRecord) changedAt() time.Time {
+	if r.ChangedAt.IsZero() {
+		return time.Now()
+	}
+	return r.ChangedAt
+}
+
+// SyncResult - итог синхронизации записи в одну целевую систему
+type SyncResult struct {
+	TargetConnectionID int      `json:"target_connection_id"`
+	Status             string   `json:"status"`
+	Action             string   `json:"action,omitempty"`
+	RecordID           string   `json:"record_id,omitempty"`
+	SyncLogID          int      `json:"sync_log_id,omitempty"`
+	CorrelationID      string   `json:"correlation_id,omitempty"`
+	Attempts           int      `json:"attempts,omitempty"`
+	Error              string   `json:"error,omitempty"`
+	Conflicts          int      `json:"conflicts,omitempty"`
+	Warnings           []string `json:"warnings,omitempty"`
+}
+
+// Process - синхронизировать запись источника во все цели, для которых
+// настроены сопоставления, и обратно в источники двусторонних пар, где
+// подключение выступает целью. Каждая цель обрабатывается параллельно и
+// независимо, со своими повторами и записью журнала; ошибка одной цели не
+// останавливает остальные.
+func (e *SyncEngine) Process(ctx context.Context, source *models.Connection, record *SourceRecord) ([]SyncResult, error) {
+	if record.CorrelationID == "" {
+		record.CorrelationID = utils.GenerateUUID()
+	}
+
+	e.logger.Info("SyncEngine: Processing record", "source_id", source.ID, "event_type", record.EventType, "correlation_id", record.CorrelationID)
+
+	targets, reverse, err := e.destinations(ctx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 && len(reverse) == 0 {
+		e.logger.Warn("SyncEngine: No mappings configured for source", "source_id", source.ID)
+	}
+
+	results := make([]SyncResult, len(targets)+len(reverse))
+	var wg sync.WaitGroup
+	for i, targetID := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = e.syncTarget(ctx, source, targetID, record)
+		}()
+	}
+	for i := range reverse {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[len(targets)+i] = e.syncReverse(ctx, source, &reverse[i], record)
+		}()
+	}
+	wg.Wait()
+
+	for i := range results {
+		results[i].CorrelationID = record.CorrelationID
+	}
+
+	return results, nil
+}
+
+// ProcessTarget - синхронизировать запись источника только в одну цель по
+// сопоставлениям пары источник -> цель, без обратных направлений
+func (e *SyncEngine) ProcessTarget(ctx context.Context, source *models.Connection, targetID int, record *SourceRecord) SyncResult {
+	if record.CorrelationID == "" {
+		record.CorrelationID = utils.GenerateUUID()
+	}
+
+	result := e.syncTarget(ctx, source, targetID, record)
+	result.CorrelationID = record.CorrelationID
+	return result
+}
+
+// retry - повтор записи в цель при временных сбоях. Число попыток копится в результате цели.
+func (e *SyncEngine) retry(ctx context.Context, result *SyncResult, op func() error) error {
+	return retryTemporary(ctx, e.logger, e.maxAttempts, e.retryDelay, &result.Attempts, op)
+}
+
+// retryTemporary - повтор операции с внешней системой, пока ошибка временная
+// и не исчерпаны попытки; пауза удваивается после каждой попытки
+func retryTemporary(ctx context.Context, logger domain.Logger, maxAttempts int, baseDelay time.Duration, attempts *int, op func() error) error {
+	for {
+		if *attempts == 0 {
+			*attempts = 1
+		}
+
+		err := op()
+		if err == nil || !domain.IsTemporary(err) || *attempts >= maxAttempts {
+			return err
+		}
+
+		delay := baseDelay << (*attempts - 1)
+		logger.Warn("Temporary error, retrying", "attempt", *attempts, "delay", delay.String(), "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		*attempts++
+	}
+}
+
+// destinations - цели по сопоставлениям источника и двусторонние пары, где
+// источник - цель. Явные сопоставления в обратную сторону важнее пары.
+func (e *SyncEngine) destinations(ctx context.Context, sourceID int) ([]int, []models.SyncPair, error) {
+	targets, err := e.mappingRepo.GetTargetIDs(ctx, sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pairs, err := e.pairRepo.GetReverse(ctx, sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	explicit := make(map[int]bool, len(targets))
+	for _, id := range targets {
+		explicit[id] = true
+	}
+
+	reverse := make([]models.SyncPair, 0, len(pairs))
+	for _, pair := range pairs {
+		if !explicit[pair.SourceConnectionID] {
+			reverse = append(reverse, pair)
+		}
+	}
+
+	return targets, reverse, nil
+}
+
+// LogDuplicate - повторная доставка уже обработанного события: в журнал
+// каждой цели пишется запись duplicate, синхронизация не выполняется
+func (e *SyncEngine) LogDuplicate(ctx context.Context, source *models.Connection, record *SourceRecord) ([]SyncResult, error) {
+	if record.CorrelationID == "" {
+		record.CorrelationID = utils.GenerateUUID()
+	}
+
+	e.logger.Info("SyncEngine: Skipping duplicate record", "source_id", source.ID, "key", record.IdempotencyKey)
+
+	targets, reverse, err := e.destinations(ctx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range reverse {
+		targets = append(targets, pair.SourceConnectionID)
+	}
+
+	results := make([]SyncResult, 0, len(targets))
+	for _, targetID := range targets {
+		results = append(results, SyncResult{
+			TargetConnectionID: targetID,
+			Status:             SyncStatusDuplicate,
+			CorrelationID:      record.CorrelationID,
+			SyncLogID: e.log(ctx, &models.SyncLog{
+				SourceConnectionID: source.ID,
+				TargetConnectionID: targetID,
+				EventType:          record.EventType,
+				Status:             SyncStatusDuplicate,
+				SourceData:         marshalData(record.Payload),
+				SourceRecordID:     record.RecordID,
+				IdempotencyKey:     record.IdempotencyKey,
+				CorrelationID:      record.CorrelationID,
+				SyncID:             record.SyncID,
+			}),
+		})
+	}
+
+	return results, nil
+}
+
+func (e *SyncEngine) syncTarget(ctx context.Context, source *models.Connection, targetID int, record *SourceRecord) SyncResult {
+	result := SyncResult{TargetConnectionID: targetID}
+
+	target, err := e.connRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return e.fail(ctx, result, source.ID, record, nil, domain.NewErrorf("target connection %d not found", targetID))
+	}
+
+	if !target.IsActive {
+		result.Status = SyncStatusSkipped
+		result.Error = "target connection is inactive"
+		return result
+	}
+
+	connector, err := e.connectors.Get(target.SystemType)
+	if err != nil {
+		return e.fail(ctx, result, source.ID, record, nil, err)
+	}
+
+	writer, ok := connector.(domain.RecordWriter)
+	if !ok {
+		return e.fail(ctx, result, source.ID, record, nil,
+			domain.NewErrorf("connector %q cannot create records", target.SystemType))
+	}
+
+	mappings, err := e.mappingRepo.GetByConnectionPair(ctx, source.ID, targetID)
+	if err != nil {
+		return e.fail(ctx, result, source.ID, record, nil, err)
+	}
+
+	pair, err := e.pairRepo.Get(ctx, source.ID, targetID)
+	if err != nil {
+		return e.fail(ctx, result, source.ID, record, nil, err)
+	}
+
+	// Фильтр пары проверяется до сопоставлений, по исходным полям записи
+	if pair != nil && pair.Filter != "" {
+		passed, err := matchFilter(pair.Filter, flattenSourcePayload(record.Payload))
+		if err != nil {
+			return e.fail(ctx, result, source.ID, record, nil, err)
+		}
+		if !passed {
+			return e.filtered(ctx, source.ID, record, result)
+		}
+	}
+
+	mapped := applyMappings(mappings, record.Payload)
+	result.Warnings = mapped.Warnings
+
+	entity := connector.DefaultEntity()
+	hash := hashKey(string(marshalData(mapped.Payload)))
+
+	var link *models.RecordLink
+	if record.RecordID != "" {
+		link, err = e.linkRepo.Find(ctx, source.ID, record.RecordID, targetID, entity)
+		if err != nil {
+			return e.fail(ctx, result, source.ID, record, mapped.Payload, err)
+		}
+	}
+
+	if link != nil && pair != nil && pair.Bidirectional {
+		result.RecordID = link.TargetRecordID
+
+		sides, err := e.loadSides(ctx, pair, source, target, mappings)
+		if err != nil {
+			return e.fail(ctx, result, source.ID, record, mapped.Payload, err)
+		}
+
+		if err := e.syncLinked(ctx, &result, sides, link, SideSource, mapped.Payload, record.changedAt()); err != nil {
+			return e.fail(ctx, result, source.ID, record, mapped.Payload, err)
+		}
+
+		return e.linkedResult(ctx, source.ID, record, mapped.Payload, result)
+	}
+
+	if link != nil {
+		result.RecordID = link.TargetRecordID
+
+		// Данные для цели не изменились с прошлой синхронизации
+		if link.LastSyncedHash == hash {
+			result.Status = SyncStatusSkipped
+			result.Action = SyncActionUnchanged
+			result.SyncLogID = e.log(ctx, successLog(source.ID, record, mapped.Payload, result))
+			return result
+		}
+
+		err := e.retry(ctx, &result, func() error {
+			return writer.UpdateRecord(ctx, target, entity, link.TargetRecordID, mapped.Payload)
+		})
+		if err != nil {
+			return e.fail(ctx, result, source.ID, record, mapped.Payload, err)
+		}
+		result.Action = SyncActionUpdated
+	} else {
+		var matchID, onMatch string
+		err := e.retry(ctx, &result, func() (err error) {
+			matchID, onMatch, err = e.findMatch(ctx, pair, target, connector, entity, mapped.Payload)
+			return err
+		})
+		if err != nil {
+			return e.fail(ctx, result, source.ID, record, mapped.Payload, domain.NewErrorf("duplicate search failed: %v", err))
+		}
+
+		switch {
+		case matchID == "":
+			result.Action = SyncActionCreated
+		case onMatch == MatchActionSkip:
+			result.Status = SyncStatusSkipped
+			result.Action = SyncActionMatchedSkipped
+			result.RecordID = matchID
+			result.SyncLogID = e.log(ctx, successLog(source.ID, record, mapped.Payload, result))
+			return result
+		case onMatch == MatchActionCreate:
+			result.Action = SyncActionMatchedCreated
+			result.Warnings = append(result.Warnings, "existing target record "+matchID+" matches, creating a new one")
+		default:
+			err := e.retry(ctx, &result, func() error {
+				return writer.UpdateRecord(ctx, target, entity, matchID, mapped.Payload)
+			})
+			if err != nil {
+				return e.fail(ctx, result, source.ID, record, mapped.Payload, err)
+			}
+			result.Action = SyncActionMatchedUpdated
+			result.RecordID = matchID
+		}
+
+		if result.RecordID == "" {
+			required, err := e.schemaUC.RequiredFields(ctx, targetID, entity)
+			if err != nil {
+				result.Warnings = append(result.Warnings, "required fields are not checked: "+err.Error())
+			}
+
+			if missing := missingFields(mapped.Payload, required); len(missing) > 0 {
+				return e.fail(ctx, result, source.ID, record, mapped.Payload,
+					domain.NewErrorf("required target fields are empty: %s", strings.Join(missing, ", ")))
+			}
+
+			var recordID string
+			err = e.retry(ctx, &result, func() (err error) {
+				recordID, err = writer.CreateRecord(ctx, target, entity, mapped.Payload)
+				return err
+			})
+			if err != nil {
+				return e.fail(ctx, result, source.ID, record, mapped.Payload, err)
+			}
+			result.RecordID = recordID
+		}
+	}
+
+	if record.RecordID != "" {
+		err := e.linkRepo.Save(ctx, &models.RecordLink{
+			SourceConnectionID: source.ID,
+			SourceRecordID:     record.RecordID,
+			TargetConnectionID: targetID,
+			TargetRecordID:     result.RecordID,
+			EntityType:         entity,
+			LastSyncedHash:     hash,
+			SyncedData:         mapped.Payload,
+			SyncedFields:       fieldNames(mapped.Payload),
+			SyncedFrom:         SideSource,
+			SyncedAt:           time.Now(),
+			ChangedAt:          record.changedAt(),
+		})
+		if err != nil {
+			result.Warnings = append(result.Warnings, "record link is not saved: "+err.Error())
+		}
+	}
+
+	result.Status = SyncStatusSuccess
+	result.SyncLogID = e.log(ctx, successLog(source.ID, record, mapped.Payload, result))
+
+	return result
+}
+
+// findMatch - поиск дубликата в цели по полям сопоставления пары. Поля
+// проверяются по порядку, первое совпадение выигрывает. Пустой ID - дубликата
+// нет или поиск для пары не настроен.
+func (e *SyncEngine) findMatch(ctx context.Context, pair *models.SyncPair, target *models.Connection, connector domain.Connector, entity string, payload map[string]interface{}) (string, string, error) {
+	if pair == nil || len(pair.MatchFields) == 0 {
+		return "", "", nil
+	}
+
+	matcher, ok := connector.(domain.RecordMatcher)
+	if !ok {
+		return "", "", domain.NewErrorf("connector %q cannot search records", target.SystemType)
+	}
+
+	for _, field := range pair.MatchFields {
+		values := matchValues(field, payload[field])
+		if len(values) == 0 {
+			continue
+		}
+
+		ids, err := matcher.FindRecords(ctx, target, entity, field, values)
+		if err != nil {
+			return "", "", err
+		}
+		if len(ids) > 0 {
+			e.logger.Info("SyncEngine: Found matching target record", "target_id", target.ID, "field", field, "record_id", ids[0])
+			return ids[0], pair.OnMatch, nil
+		}
+	}
+
+	return "", pair.OnMatch, nil
+}
+
+// matchValues - нормализованные значения поля для поиска: телефоны к +7...,
+// email к нижнему регистру. Понимает множественные поля Bitrix24 {VALUE: ...}.
+func matchValues(field string, value interface{}) []string {
+	var raw []interface{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		raw = v
+	case []map[string]interface{}:
+		for _, item := range v {
+			raw = append(raw, item)
+		}
+	default:
+		raw = []interface{}{v}
+	}
+
+	upper := strings.ToUpper(field)
+	seen := make(map[string]bool)
+	values := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]interface{}); ok {
+			item = m["VALUE"]
+		}
+
+		s := strings.TrimSpace(toString(item))
+		switch {
+		case strings.Contains(upper, "PHONE"):
+			s = normalizePhone(s)
+		case strings.Contains(upper, "EMAIL"):
+			s = normalizeEmail(s)
+		}
+
+		if s != "" && !seen[s] {
+			seen[s] = true
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+func successLog(sourceID int, record *SourceRecord, mapped map[string]interface{}, result SyncResult) *models.SyncLog {
+	return &models.SyncLog{
+		SourceConnectionID: sourceID,
+		TargetConnectionID: result.TargetConnectionID,
+		EventType:          record.EventType,
+		Status:             result.Status,
+		Action:             result.Action,
+		SourceData:         marshalData(record.Payload),
+		TargetData:         marshalData(mapped),
+		SourceRecordID:     record.RecordID,
+		TargetRecordID:     result.RecordID,
+		IdempotencyKey:     record.IdempotencyKey,
+		CorrelationID:      record.CorrelationID,
+		SyncID:             record.SyncID,
+		Attempts:           result.Attempts,
+	}
+}
+
+// filtered - запись не прошла фильтр пары: в журнал попадает пропуск с причиной
+func (e *SyncEngine) filtered(ctx context.Context, sourceID int, record *SourceRecord, result SyncResult) SyncResult {
+	e.logger.Info("SyncEngine: Record skipped by filter", "source_id", sourceID, "target_id", result.TargetConnectionID)
+
+	result.Status = SyncStatusSkipped
+	result.Action = SyncActionFiltered
+	result.Error = filterSkippedMessage
+
+	log := successLog(sourceID, record, nil, result)
+	log.ErrorMessage = filterSkippedMessage
+	result.SyncLogID = e.log(ctx, log)
+
+	return result
+}
+
+// fail - записать ошибку синхронизации в журнал и вернуть ее в результате
+func (e *SyncEngine) fail(ctx context.Context, result SyncResult, sourceID int, record *SourceRecord, mapped map[string]interface{}, cause error) SyncResult {
+	e.logger.Error("SyncEngine: Sync failed", cause, "source_id", sourceID, "target_id", result.TargetConnectionID)
+
+	result.Status = SyncStatusError
+	result.Error = cause.Error()
+	result.SyncLogID = e.log(ctx, &models.SyncLog{
+		SourceConnectionID: sourceID,
+		TargetConnectionID: result.TargetConnectionID,
+		EventType:          record.EventType,
+		Status:             SyncStatusError,
+		SourceData:         marshalData(record.Payload),
+		TargetData:         marshalData(mapped),
+		ErrorMessage:       cause.Error(),
+		Action:             result.Action,
+		SourceRecordID:     record.RecordID,
+		TargetRecordID:     result.RecordID,
+		IdempotencyKey:     record.IdempotencyKey,
+		CorrelationID:      record.CorrelationID,
+		SyncID:             record.SyncID,
+		Attempts:           result.Attempts,
+	})
+
+	return result
+}
+
+func (e *SyncEngine) log(ctx context.Context, log *models.SyncLog) int {
+	if err := e.syncUC.LogSync(ctx, log); err != nil {
+		return 0
+	}
+	return log.ID
+}
+
+func marshalData(data map[string]interface{}) json.RawMessage {
+	if data == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(data)
+	return raw
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// Что делать, если в цели найден дубликат записи
+const (
+	MatchActionUpdate = "update"
+	MatchActionCreate = "create"
+	MatchActionSkip   = "skip"
+)
+
+var matchActions = map[string]bool{
+	MatchActionUpdate: true,
+	MatchActionCreate: true,
+	MatchActionSkip:   true,
+}
+
+type SyncPairUseCase struct {
+	repo        domain.SyncPairRepository
+	connRepo    domain.ConnectionRepository
+	mappingRepo domain.MappingRepository
+	connectors  domain.ConnectorRegistry
+	schemas     *SchemaUseCase
+	logger      domain.Logger
+}
+
+func NewSyncPairUseCase(
+	repo domain.SyncPairRepository,
+	connRepo domain.ConnectionRepository,
+	mappingRepo domain.MappingRepository,
+	connectors domain.ConnectorRegistry,
+	schemas *SchemaUseCase,
+	logger domain.Logger,
+) *SyncPairUseCase {
+	return &SyncPairUseCase{
+		repo:        repo,
+		connRepo:    connRepo,
+		mappingRepo: mappingRepo,
+		connectors:  connectors,
+		schemas:     schemas,
+		logger:      logger,
+	}
+}
+
+// SyncPairSettings - настройки пары, которые задает пользователь.
+// FieldPolicies задает политику конфликтов для отдельных полей цели,
+// Filter - выражение, которому должна удовлетворять запись источника.
+type SyncPairSettings struct {
+	MatchFields    []string          `json:"match_fields"`
+	OnMatch        string            `json:"on_match"`
+	Bidirectional  bool              `json:"bidirectional"`
+	ConflictPolicy string            `json:"conflict_policy"`
+	FieldPolicies  map[string]string `json:"field_policies"`
+	Filter         string            `json:"filter"`
+}
+
+// GetPair - настройки пары; для пары без сохраненных настроек - значения по умолчанию
+func (uc *SyncPairUseCase) GetPair(ctx context.Context, sourceID, targetID int) (*models.SyncPair, error) {
+	pair, err := uc.repo.Get(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pair == nil {
+		pair = &models.SyncPair{
+			SourceConnectionID: sourceID,
+			TargetConnectionID: targetID,
+			MatchFields:        []string{},
+			OnMatch:            MatchActionUpdate,
+			ConflictPolicy:     ConflictLastWriterWins,
+			FieldPolicies:      map[string]string{},
+		}
+	}
+
+	return pair, nil
+}
+
+// SavePair - сохранить настройки поиска дубликатов пары
+func (uc *SyncPairUseCase) SavePair(ctx context.Context, sourceID, targetID int, settings *SyncPairSettings) (*models.SyncPair, error) {
+	uc.logger.Info("UseCase: Saving sync pair", "source_id", sourceID, "target_id", targetID)
+
+	source, err := uc.connRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, domain.NewErrorf("source connection %d not found", sourceID)
+	}
+
+	target, err := uc.connRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, domain.NewErrorf("target connection %d not found", targetID)
+	}
+
+	if settings.OnMatch == "" {
+		settings.OnMatch = MatchActionUpdate
+	}
+	if !matchActions[settings.OnMatch] {
+		return nil, domain.NewErrorf("unknown on_match action %q", settings.OnMatch)
+	}
+
+	fields := make([]string, 0, len(settings.MatchFields))
+	seen := make(map[string]bool)
+	for _, field := range settings.MatchFields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, domain.NewError("match field name cannot be empty")
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+
+	if len(fields) > 0 {
+		connector, err := uc.connectors.Get(target.SystemType)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := connector.(domain.RecordMatcher); !ok {
+			return nil, domain.NewErrorf("connector %q cannot search records", target.SystemType)
+		}
+		if err := uc.validateMatchFields(ctx, target, connector.DefaultEntity(), fields); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.ConflictPolicy == "" {
+		settings.ConflictPolicy = ConflictLastWriterWins
+	}
+	if !conflictPolicies[settings.ConflictPolicy] {
+		return nil, domain.NewErrorf("unknown conflict policy %q", settings.ConflictPolicy)
+	}
+	for field, policy := range settings.FieldPolicies {
+		if !conflictPolicies[policy] {
+			return nil, domain.NewErrorf("unknown conflict policy %q for field %s", policy, field)
+		}
+	}
+
+	settings.Filter = strings.TrimSpace(settings.Filter)
+	if err := validateFilter(settings.Filter); err != nil {
+		return nil, err
+	}
+
+	if settings.Bidirectional {
+		if err := uc.validateBidirectional(ctx, source, target); err != nil {
+			return nil, err
+		}
+	}
+
+	pair := &models.SyncPair{
+		SourceConnectionID: sourceID,
+		TargetConnectionID: targetID,
+		MatchFields:        fields,
+		OnMatch:            settings.OnMatch,
+		Bidirectional:      settings.Bidirectional,
+		ConflictPolicy:     settings.ConflictPolicy,
+		FieldPolicies:      settings.FieldPolicies,
+		Filter:             settings.Filter,
+	}
+	if err := uc.repo.Save(ctx, pair); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// validateMatchFields - поля поиска дубликатов должны быть в схеме сущности
+// цели: поиск по несуществующему полю нашел бы произвольные записи
+func (uc *SyncPairUseCase) validateMatchFields(ctx context.Context, target *models.Connection, entity string, fields []string) error {
+	schema, err := uc.schemas.GetFields(ctx, target.ID, entity, false)
+	if err != nil {
+		return domain.NewErrorf("cannot check match fields against %s fields: %v", entity, err)
+	}
+
+	known := make(map[string]bool, len(schema.Fields))
+	for _, f := range schema.Fields {
+		known[f.Name] = true
+	}
+	for _, field := range fields {
+		if !known[field] {
+			return domain.NewErrorf("match field %q is not a field of %s", field, entity)
+		}
+	}
+
+	return nil
+}
+
+// validateBidirectional - в двусторонней паре обе стороны записывают данные,
+// а обратное направление не настроено отдельными сопоставлениями
+func (uc *SyncPairUseCase) validateBidirectional(ctx context.Context, source, target *models.Connection) error {
+	for _, conn := range []*models.Connection{source, target} {
+		connector, err := uc.connectors.Get(conn.SystemType)
+		if err != nil {
+			return err
+		}
+		if _, ok := connector.(domain.RecordWriter); !ok {
+			return domain.NewErrorf("connector %q cannot write records, pair cannot be bidirectional", conn.SystemType)
+		}
+	}
+
+	reverse, err := uc.mappingRepo.GetByConnectionPair(ctx, target.ID, source.ID)
+	if err != nil {
+		return err
+	}
+	if len(reverse) > 0 {
+		return domain.NewErrorf("connections %d -> %d already have their own mappings", target.ID, source.ID)
+	}
+
+	reversePair, err := uc.repo.Get(ctx, target.ID, source.ID)
+	if err != nil {
+		return err
+	}
+	if reversePair != nil && reversePair.Bidirectional {
+		return domain.NewErrorf("pair %d -> %d is already bidirectional", target.ID, source.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/utils"
+
+	"go.uber.org/fx"
+)
+
+// Статусы записей очереди синхронизации
+const (
+	SyncQueuePending = "pending"
+	SyncQueueRunning = "running"
+	SyncQueueDone    = "done"
+	SyncQueueFailed  = "failed"
+	// SyncQueueCancelled - запись снята с очереди отменой запуска опроса
+	SyncQueueCancelled = "cancelled"
+)
+
+const (
+	syncQueuePollInterval = 5 * time.Second
+	syncQueueBatchSize    = 50
+	syncQueueStaleAfter   = 10 * time.Minute
+
+	// Запись, не синхронизированная хотя бы в одну цель, повторяется с
+	// удвоением паузы, после syncQueueMaxAttempts неудач остается failed
+	syncQueueMaxAttempts = 5
+	syncQueueRetryDelay  = time.Minute
+)
+
+// SyncQueueWorker - синхронизирует записи, поставленные в очередь опросом
+// подключений. Записи забираются с SKIP LOCKED, поэтому очередь можно
+// разбирать на всех репликах одновременно.
+type SyncQueueWorker struct {
+	queue    domain.SyncQueueRepository
+	connRepo domain.ConnectionRepository
+	inbound  *InboundUseCase
+	runs     *SyncRunUseCase
+	logger   domain.Logger
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewSyncQueueWorker(
+	lc fx.Lifecycle,
+	queue domain.SyncQueueRepository,
+	connRepo domain.ConnectionRepository,
+	inbound *InboundUseCase,
+	runs *SyncRunUseCase,
+	logger domain.Logger,
+) *SyncQueueWorker {
+	w := &SyncQueueWorker{
+		queue:    queue,
+		connRepo: connRepo,
+		inbound:  inbound,
+		runs:     runs,
+		logger:   logger,
+		stop:     make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			w.wg.Add(1)
+			go w.loop()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			w.logger.Info("Waiting for queued records in progress")
+			close(w.stop)
+
+			done := make(chan struct{})
+			go func() {
+				w.wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return w
+}
+
+func (w *SyncQueueWorker) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(syncQueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.drain(context.Background())
+		}
+	}
+}
+
+// drain - разобрать очередь пачками, пока она не опустеет
+func (w *SyncQueueWorker) drain(ctx context.Context) {
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		items, err := w.queue.ClaimDue(ctx, syncQueueBatchSize, syncQueueStaleAfter)
+		if err != nil || len(items) == 0 {
+			return
+		}
+
+		// Записи синхронизируются в порядке постановки в очередь
+		sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+		conns := make(map[int]*models.Connection)
+		runs := make(map[int]bool)
+		for i := range items {
+			status, errMsg := w.process(ctx, &items[i], conns)
+			if status == SyncQueuePending {
+				delay := syncQueueRetryDelay << items[i].Attempts
+				if err := w.queue.Retry(ctx, items[i].ID, errMsg, time.Now().Add(delay)); err != nil {
+					w.logger.Error("Failed to reschedule sync queue item", err, "id", items[i].ID)
+				}
+			} else if err := w.queue.Finish(ctx, items[i].ID, status, errMsg); err != nil {
+				w.logger.Error("Failed to save sync queue status", err, "id", items[i].ID)
+			}
+			if items[i].SyncID > 0 {
+				runs[items[i].SyncID] = true
+			}
+		}
+
+		for syncID := range runs {
+			w.finishRun(ctx, syncID)
+		}
+	}
+}
+
+// finishRun - завершить запуск опроса, если все его записи синхронизированы,
+// иначе обновить его счетчики
+func (w *SyncQueueWorker) finishRun(ctx context.Context, syncID int) {
+	open, err := w.queue.CountOpen(ctx, syncID)
+	if err != nil {
+		return
+	}
+	if open > 0 {
+		w.runs.RefreshRun(ctx, syncID)
+		return
+	}
+	w.runs.FinishRun(ctx, syncID, nil)
+}
+
+// process - синхронизировать запись очереди. Статус pending - запись
+// не синхронизирована хотя бы в одну цель и будет повторена.
+func (w *SyncQueueWorker) process(ctx context.Context, item *models.SyncQueueItem, conns map[int]*models.Connection) (string, string) {
+	conn, ok := conns[item.ConnectionID]
+	if !ok {
+		var err error
+		if conn, err = w.connRepo.GetByID(ctx, item.ConnectionID); err != nil {
+			return SyncQueueFailed, "connection not found"
+		}
+		conns[item.ConnectionID] = conn
+	}
+	if !conn.IsActive {
+		return SyncQueueFailed, "connection is inactive"
+	}
+
+	record := &SourceRecord{
+		EventType:      PollEventType,
+		RecordID:       item.SourceRecordID,
+		IdempotencyKey: idempotencyKey(conn, "", item.Payload),
+		CorrelationID:  utils.GenerateUUID(),
+		SyncID:         item.SyncID,
+		ChangedAt:      recordChangedAt(conn, item.Payload),
+		Payload:        item.Payload,
+	}
+	if _, err := w.inbound.Ingest(ctx, conn, record); err != nil {
+		w.logger.Error("Failed to sync queued record", err, "connection_id", conn.ID, "record_id", record.RecordID, "attempt", item.Attempts+1)
+		if item.Attempts+1 < syncQueueMaxAttempts {
+			return SyncQueuePending, err.Error()
+		}
+		return SyncQueueFailed, err.Error()
+	}
+
+	return SyncQueueDone, ""
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+// Источники запусков синхронизации
+const (
+	SyncRunWebhook  = "webhook"
+	SyncRunPoll     = "poll"
+	SyncRunBackfill = "backfill"
+	SyncRunManual   = "manual"
+)
+
+// Статусы запусков помимо success и error
+const (
+	SyncRunRunning   = "running"
+	SyncRunCancelled = "cancelled"
+)
+
+var syncRunTriggers = map[string]bool{
+	SyncRunWebhook:  true,
+	SyncRunPoll:     true,
+	SyncRunBackfill: true,
+	SyncRunManual:   true,
+}
+
+var syncRunStatuses = map[string]bool{
+	SyncRunRunning:    true,
+	SyncStatusSuccess: true,
+	SyncStatusError:   true,
+	SyncRunCancelled:  true,
+}
+
+type SyncRunUseCase struct {
+	repo      domain.SyncRunRepository
+	logRepo   domain.SyncLogRepository
+	queue     domain.SyncQueueRepository
+	backfills domain.SyncBackfillRepository
+	flowJobs  domain.FlowJobRepository
+	logger    domain.Logger
+}
+
+func NewSyncRunUseCase(
+	repo domain.SyncRunRepository,
+	logRepo domain.SyncLogRepository,
+	queue domain.SyncQueueRepository,
+	backfills domain.SyncBackfillRepository,
+	flowJobs domain.FlowJobRepository,
+	logger domain.Logger,
+) *SyncRunUseCase {
+	return &SyncRunUseCase{
+		repo:      repo,
+		logRepo:   logRepo,
+		queue:     queue,
+		backfills: backfills,
+		flowJobs:  flowJobs,
+		logger:    logger,
+	}
+}
+
+// StartRun - начать запуск синхронизации от имени пользователя из контекста.
+// Возвращает ID запуска для SourceRecord.SyncID; если запуск не сохранился,
+// синхронизация идет без него (0).
+func (uc *SyncRunUseCase) StartRun(ctx context.Context, run *models.SyncRun) int {
+	run.Status = SyncRunRunning
+	run.Initiator = domain.UserFromContext(ctx)
+
+	if err := uc.repo.Create(ctx, run); err != nil {
+		return 0
+	}
+	return run.ID
+}
+
+// FinishRun - завершить запуск; статус определяется по cause и ошибкам в журнале
+func (uc *SyncRunUseCase) FinishRun(ctx context.Context, id int, cause error) {
+	if id == 0 {
+		return
+	}
+
+	errMsg := ""
+	if cause != nil {
+		errMsg = cause.Error()
+	}
+	run := &models.SyncRun{ID: id}
+	if err := uc.repo.Finish(ctx, run, errMsg); err != nil {
+		return
+	}
+
+	uc.logger.Info("UseCase: Sync run finished", "id", id, "trigger_type", run.TriggerType, "status", run.Status, "processed", run.Processed, "failed", run.Failed)
+}
+
+// RefreshRun - пересчитать счетчики долгого запуска по журналу
+func (uc *SyncRunUseCase) RefreshRun(ctx context.Context, id int) {
+	if id == 0 {
+		return
+	}
+	uc.repo.UpdateCounts(ctx, id)
+}
+
+// ReopenRun - вернуть в работу запуск, завершенный ошибкой
+func (uc *SyncRunUseCase) ReopenRun(ctx context.Context, id int) {
+	if id == 0 {
+		return
+	}
+	uc.repo.Reopen(ctx, id)
+}
+
+// GetRuns - последние запуски по фильтру
+func (uc *SyncRunUseCase) GetRuns(ctx context.Context, filter models.SyncRunFilter) ([]models.SyncRun, error) {
+	if filter.TriggerType != "" && !syncRunTriggers[filter.TriggerType] {
+		return nil, domain.NewErrorf("unknown trigger type %q", filter.TriggerType)
+	}
+	if filter.Status != "" && !syncRunStatuses[filter.Status] {
+		return nil, domain.NewErrorf("unknown run status %q", filter.Status)
+	}
+	return uc.repo.Find(ctx, filter)
+}
+
+func (uc *SyncRunUseCase) GetRun(ctx context.Context, id int) (*models.SyncRun, error) {
+	run, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, domain.ErrNotFound
+	}
+	return run, nil
+}
+
+// GetRunLogs - записи журнала запуска в порядке выполнения
+func (uc *SyncRunUseCase) GetRunLogs(ctx context.Context, id int) ([]models.SyncLog, error) {
+	if _, err := uc.GetRun(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.logRepo.GetBySyncID(ctx, id)
+}
+
+// CancelRun - отменить выполняющийся запуск. Ожидающие записи опроса
+// снимаются с очереди, выгрузка останавливается после текущей записи,
+// отложенные шаги потоков запуска любого типа отменяются. Запись, которая
+// синхронизируется в момент отмены, дописывается.
+func (uc *SyncRunUseCase) CancelRun(ctx context.Context, id int) (*models.SyncRun, error) {
+	uc.logger.Info("UseCase: Cancelling sync run", "id", id)
+
+	run, err := uc.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled, err := uc.repo.Cancel(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, domain.NewErrorf("sync run %d is %s and cannot be cancelled", run.ID, run.Status)
+	}
+
+	switch run.TriggerType {
+	case SyncRunPoll:
+		count, err := uc.queue.CancelBySyncID(ctx, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		uc.logger.Info("UseCase: Queued records cancelled", "id", run.ID, "count", count)
+	case SyncRunBackfill:
+		if _, err := uc.backfills.CancelBySyncID(ctx, run.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	count, err := uc.flowJobs.CancelBySyncID(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	uc.logger.Info("UseCase: Delayed flow steps cancelled", "id", run.ID, "count", count)
+
+	uc.RefreshRun(ctx, run.ID)
+	return uc.GetRun(ctx, run.ID)
+}
+
+// DeleteOldRuns - удалить завершенные запуски старше olderThanDays дней
+func (uc *SyncRunUseCase) DeleteOldRuns(ctx context.Context, olderThanDays int) error {
+	return uc.repo.DeleteOld(ctx, olderThanDays)
+}
@@ -8,17 +8,26 @@ import (
 	"integration-app/internal/domain/models"
 )
 
+const (
+	SyncStatusSuccess = "success"
+	SyncStatusError   = "error"
+	SyncStatusPending = "pending"
+)
+
 type SyncUseCase struct {
 	repo   domain.SyncLogRepository
+	events domain.EventPublisher
 	logger domain.Logger
 }
 
 func NewSyncUseCase(
 	repo domain.SyncLogRepository,
+	events domain.EventPublisher,
 	logger domain.Logger,
 ) *SyncUseCase {
 	return &SyncUseCase{
 		repo:   repo,
+		events: events,
 		logger: logger,
 	}
 }
@@ -35,37 +44,76 @@ func (uc *SyncUseCase) GetErrorLogs(ctx context.Context) ([]models.SyncLog, erro
 	return uc.repo.GetErrorLogs(ctx)
 }
 
+// GetTrace - все записи журнала одного входящего события: доставки во все
+// цели и шаги потоков в порядке выполнения
+func (uc *SyncUseCase) GetTrace(ctx context.Context, correlationID string) ([]models.SyncLog, error) {
+	uc.logger.Info("UseCase: Getting sync trace", "correlation_id", correlationID)
+
+	logs, err := uc.repo.GetByCorrelationID(ctx, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	return logs, nil
+}
+
 // LogSuccessSync - логировать успешную синхронизацию
 func (uc *SyncUseCase) LogSuccessSync(ctx context.Context, sourceID, targetID int, data map[string]interface{}) error {
 	uc.logger.Info("UseCase: Logging successful sync", "source_id", sourceID, "target_id", targetID)
 
 	sourceData, _ := json.Marshal(data)
 
-	log := &models.SyncLog{
+	return uc.LogSync(ctx, &models.SyncLog{
 		SourceConnectionID: sourceID,
 		TargetConnectionID: targetID,
-		Status:             "success",
+		Status:             SyncStatusSuccess,
 		SourceData:         sourceData,
-	}
-
-	return uc.repo.Create(ctx, log)
+	})
 }
 
 // LogErrorSync - логировать ошибку синхронизации
 func (uc *SyncUseCase) LogErrorSync(ctx context.Context, sourceID, targetID int, errMsg string, sourceData map[string]interface{}) error {
-	uc.logger.Error("UseCase: Logging sync error", nil, "source_id", sourceID, "target_id", targetID)
+	uc.logger.Error("UseCase: Logging sync error", domain.NewError(errMsg), "source_id", sourceID, "target_id", targetID)
 
 	data, _ := json.Marshal(sourceData)
 
-	log := &models.SyncLog{
+	return uc.LogSync(ctx, &models.SyncLog{
 		SourceConnectionID: sourceID,
 		TargetConnectionID: targetID,
-		Status:             "error",
+		Status:             SyncStatusError,
 		SourceData:         data,
 		ErrorMessage:       errMsg,
+	})
+}
+
+// LogSync - сохранить результат синхронизации и уведомить вебхуки источника и цели
+func (uc *SyncUseCase) LogSync(ctx context.Context, log *models.SyncLog) error {
+	if err := uc.repo.Create(ctx, log); err != nil {
+		uc.logger.Error("Failed to save sync log", err, "source_id", log.SourceConnectionID, "target_id", log.TargetConnectionID)
+		return err
 	}
 
-	return uc.repo.Create(ctx, log)
+	switch log.Status {
+	case SyncStatusSuccess:
+		uc.publishSyncEvent(ctx, domain.EventSyncSucceeded, log)
+	case SyncStatusError:
+		uc.publishSyncEvent(ctx, domain.EventSyncFailed, log)
+	}
+
+	return nil
+}
+
+// LogTrace - сохранить запись журнала без уведомления вебхуков
+// (промежуточные шаги потоков)
+func (uc *SyncUseCase) LogTrace(ctx context.Context, log *models.SyncLog) error {
+	if err := uc.repo.Create(ctx, log); err != nil {
+		uc.logger.Error("Failed to save sync log", err, "source_id", log.SourceConnectionID, "flow_id", log.FlowID)
+		return err
+	}
+	return nil
 }
 
 // LogPendingSync - логировать ожидающую синхронизацию
@@ -75,8 +123,28 @@ func (uc *SyncUseCase) LogPendingSync(ctx context.Context, sourceID, targetID in
 	log := &models.SyncLog{
 		SourceConnectionID: sourceID,
 		TargetConnectionID: targetID,
-		Status:             "pending",
+		Status:             SyncStatusPending,
 	}
 
 	return uc.repo.Create(ctx, log)
 }
+
+// publishSyncEvent - уведомить вебхуки источника и цели о результате синхронизации
+func (uc *SyncUseCase) publishSyncEvent(ctx context.Context, eventType string, log *models.SyncLog) {
+	data := map[string]interface{}{
+		"sync_log_id":          log.ID,
+		"source_connection_id": log.SourceConnectionID,
+		"target_connection_id": log.TargetConnectionID,
+		"event_type":           log.EventType,
+		"status":               log.Status,
+	}
+	if log.ErrorMessage != "" {
+		data["error_message"] = log.ErrorMessage
+	}
+
+	uc.events.Publish(ctx, &domain.Event{
+		Type:          eventType,
+		ConnectionIDs: []int{log.SourceConnectionID, log.TargetConnectionID},
+		Data:          data,
+	})
+}
@@ -0,0 +1,412 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"integration-app/internal/config"
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+	"integration-app/internal/repository"
+	"integration-app/internal/utils"
+
+	"go.uber.org/fx"
+)
+
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookID        = "X-Webhook-ID"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+	HeaderWebhookAttempt   = "X-Webhook-Attempt"
+
+	webhookUserAgent = "integration-app-webhooks/0.0.1"
+
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+
+	responseExcerptSize = 1024
+	retryPollInterval   = 15 * time.Second
+	retryBatchSize      = 20
+	retryBaseDelay      = 30 * time.Second
+	retryMaxDelay       = time.Hour
+)
+
+// WebhookDispatcher - доставляет события приложения на исходящие вебхуки подключений.
+// Каждая попытка записывается в webhook_deliveries; неудачные попытки повторяются
+// с экспоненциальной задержкой, а вебхук отключается после серии неудач подряд.
+type WebhookDispatcher struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+	client       *http.Client
+	timeout      time.Duration
+	maxAttempts  int
+	disableAfter int
+	logger       domain.Logger
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewWebhookDispatcher(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	webhookRepo repository.WebhookRepository,
+	deliveryRepo domain.WebhookDeliveryRepository,
+	guard domain.URLGuard,
+	logger domain.Logger,
+) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		client:       guard.NewClient(0), // ограничение по времени задается контекстом попытки
+		timeout:      time.Duration(cfg.WebhookTimeoutSec) * time.Second,
+		maxAttempts:  cfg.WebhookMaxAttempts,
+		disableAfter: cfg.WebhookDisableAfter,
+		logger:       logger,
+		stop:         make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			d.wg.Add(1)
+			go d.retryLoop()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			d.logger.Info("Waiting for in-flight webhook deliveries")
+			close(d.stop)
+
+			done := make(chan struct{})
+			go func() {
+				d.wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return d
+}
+
+// webhookPayload - тело исходящего запроса
+type webhookPayload struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"`
+	WebhookID    int                    `json:"webhook_id"`
+	ConnectionID int                    `json:"connection_id"`
+	OccurredAt   time.Time              `json:"occurred_at"`
+	Data         map[string]interface{} `json:"data"`
+}
+
+// Publish - асинхронно отправляет событие всем активным вебхукам его подключений,
+// подписанным на этот тип события. Не блокирует вызывающий код.
+func (d *WebhookDispatcher) Publish(ctx context.Context, event *domain.Event) {
+	if event.ID == "" {
+		event.ID = utils.GenerateUUID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		// Контекст запроса к этому моменту может быть уже отменен
+		ctx := context.Background()
+
+		for _, webhook := range d.subscribers(ctx, event) {
+			d.wg.Add(1)
+			go func(webhook *models.Webhook) {
+				defer d.wg.Done()
+				d.deliver(ctx, webhook, event)
+			}(webhook)
+		}
+	}()
+}
+
+// Redeliver - повторно и синхронно отправляет тело сохраненной попытки.
+// Ручная повторная отправка не планирует автоматических повторов.
+func (d *WebhookDispatcher) Redeliver(ctx context.Context, webhook *models.Webhook, previous *models.WebhookDelivery) *models.WebhookDelivery {
+	return d.send(ctx, webhook, &models.WebhookDelivery{
+		WebhookID:    webhook.ID,
+		EventID:      previous.EventID,
+		EventType:    previous.EventType,
+		RequestBody:  previous.RequestBody,
+		Attempt:      1,
+		RedeliveryOf: sql.NullInt64{Int64: int64(previous.ID), Valid: true},
+	}, false)
+}
+
+// Test - синхронно отправляет пример события из каталога, подходящего под подписку
+// вебхука. Попытка записывается в журнал, но не влияет на счетчик неудач и не повторяется.
+func (d *WebhookDispatcher) Test(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	info, ok := domain.LookupEventType(webhook.EventType)
+	if !ok {
+		return nil, domain.NewErrorf("unknown event type %q", webhook.EventType)
+	}
+
+	eventID := utils.GenerateUUID()
+	body, err := json.Marshal(webhookPayload{
+		ID:           eventID,
+		Type:         info.Type,
+		WebhookID:    webhook.ID,
+		ConnectionID: webhook.ConnectionID,
+		OccurredAt:   time.Now().UTC(),
+		Data:         info.Example,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID:   webhook.ID,
+		EventID:     eventID,
+		EventType:   info.Type,
+		RequestBody: string(body),
+		Attempt:     1,
+	}
+	d.post(ctx, webhook, delivery)
+
+	if err := d.deliveryRepo.Create(ctx, delivery); err != nil {
+		d.logger.Error("Failed to record webhook delivery", err, "webhook_id", webhook.ID)
+	}
+
+	return delivery, nil
+}
+
+func (d *WebhookDispatcher) subscribers(ctx context.Context, event *domain.Event) []*models.Webhook {
+	seen := make(map[int]bool)
+	result := make([]*models.Webhook, 0)
+
+	for _, connectionID := range event.ConnectionIDs {
+		webhooks, err := d.webhookRepo.GetActiveByConnectionID(ctx, connectionID)
+		if err != nil {
+			d.logger.Error("Failed to load webhooks for event", err, "event", event.Type, "connection_id", connectionID)
+			continue
+		}
+
+		for _, w := range webhooks {
+			if seen[w.ID] || !domain.MatchEventType(w.EventType, event.Type) || !domain.MatchEventFilters(w.Filters, event.Data) {
+				continue
+			}
+			seen[w.ID] = true
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// deliver - первая попытка доставки события на вебхук
+func (d *WebhookDispatcher) deliver(ctx context.Context, webhook *models.Webhook, event *domain.Event) {
+	body, err := json.Marshal(webhookPayload{
+		ID:           event.ID,
+		Type:         event.Type,
+		WebhookID:    webhook.ID,
+		ConnectionID: webhook.ConnectionID,
+		OccurredAt:   event.OccurredAt,
+		Data:         event.Data,
+	})
+	if err != nil {
+		d.logger.Error("Failed to encode webhook payload", err, "webhook_id", webhook.ID)
+		return
+	}
+
+	d.send(ctx, webhook, &models.WebhookDelivery{
+		WebhookID:   webhook.ID,
+		EventID:     event.ID,
+		EventType:   event.Type,
+		RequestBody: string(body),
+		Attempt:     1,
+	}, true)
+}
+
+// send - один POST на callback URL с подписью и ограничением по времени.
+// Результат сохраняется в журнал доставок; при retry неудачная попытка
+// получает время следующего повтора.
+func (d *WebhookDispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, retry bool) *models.WebhookDelivery {
+	d.post(ctx, webhook, delivery)
+
+	if delivery.Status == DeliveryStatusFailed && retry && delivery.Attempt < d.maxAttempts {
+		delivery.NextRetryAt = sql.NullTime{Time: time.Now().Add(retryDelay(delivery.Attempt)), Valid: true}
+	}
+
+	if err := d.deliveryRepo.Create(ctx, delivery); err != nil {
+		d.logger.Error("Failed to record webhook delivery", err, "webhook_id", webhook.ID)
+	}
+
+	updated, err := d.webhookRepo.RecordDeliveryResult(ctx, webhook.ID, delivery.Status == DeliveryStatusSuccess, d.disableAfter)
+	if err != nil {
+		d.logger.Error("Failed to update webhook failure streak", err, "webhook_id", webhook.ID)
+	} else if webhook.IsActive && !updated.IsActive {
+		d.logger.Warn("Webhook disabled after consecutive failures",
+			"webhook_id", webhook.ID, "failures", updated.ConsecutiveFailures)
+	}
+
+	return delivery
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Status = DeliveryStatusFailed
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	// В журнале хранится JSON-конверт, в формат вебхука он кодируется при каждой
+	// попытке, поэтому повторы учитывают текущие настройки вебхука
+	body, contentType, formatHeaders, err := encodeWebhookPayload(webhook, []byte(delivery.RequestBody))
+	if err != nil {
+		delivery.ErrorMessage = err.Error()
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		delivery.ErrorMessage = err.Error()
+		return
+	}
+
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range formatHeaders {
+		req.Header.Set(name, value)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	req.Header.Set(HeaderWebhookID, delivery.EventID)
+	req.Header.Set(HeaderWebhookAttempt, strconv.Itoa(delivery.Attempt))
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, webhookSignature(webhook, timestamp, body))
+
+	started := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.LatencyMs = time.Since(started).Milliseconds()
+	if err != nil {
+		delivery.ErrorMessage = err.Error()
+		d.logger.Error("Webhook delivery failed", err, "webhook_id", webhook.ID, "event", delivery.EventType)
+		return
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerptSize))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(excerpt), "")
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		delivery.ErrorMessage = "receiver returned status " + strconv.Itoa(resp.StatusCode)
+		d.logger.Warn("Webhook receiver returned non-2xx status",
+			"webhook_id", webhook.ID, "event", delivery.EventType, "status", resp.StatusCode)
+		return
+	}
+
+	delivery.Status = DeliveryStatusSuccess
+	d.logger.Info("Webhook delivered", "webhook_id", webhook.ID, "event", delivery.EventType,
+		"status", resp.StatusCode, "latency_ms", delivery.LatencyMs)
+}
+
+// retryLoop - периодически повторяет неудачные попытки, время которых подошло
+func (d *WebhookDispatcher) retryLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(retryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.retryDue(context.Background())
+		}
+	}
+}
+
+func (d *WebhookDispatcher) retryDue(ctx context.Context) {
+	due, err := d.deliveryRepo.ClaimDueRetries(ctx, retryBatchSize)
+	if err != nil {
+		return
+	}
+
+	for _, previous := range due {
+		webhook, err := d.webhookRepo.GetByID(ctx, previous.WebhookID)
+		if err != nil || !webhook.IsActive {
+			d.logger.Info("Skipping retry of inactive or deleted webhook", "webhook_id", previous.WebhookID)
+			continue
+		}
+
+		next := &models.WebhookDelivery{
+			WebhookID:    webhook.ID,
+			EventID:      previous.EventID,
+			EventType:    previous.EventType,
+			RequestBody:  previous.RequestBody,
+			Attempt:      previous.Attempt + 1,
+			RedeliveryOf: previous.RedeliveryOf,
+		}
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.send(ctx, webhook, next, true)
+		}()
+	}
+}
+
+// retryDelay - 30s, 1m, 2m, 4m ... но не больше часа
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// webhookSignature - подпись текущим секретом, а в период после ротации
+// через запятую еще и предыдущим, чтобы получатель успел сменить ключ
+func webhookSignature(webhook *models.Webhook, timestamp string, body []byte) string {
+	signature := SignWebhookPayload(utils.FromNullString(webhook.SecretKey), timestamp, body)
+
+	if webhook.PreviousSecretKey.Valid && webhook.PreviousSecretUntil.Valid &&
+		time.Now().Before(webhook.PreviousSecretUntil.Time) {
+		signature += "," + SignWebhookPayload(webhook.PreviousSecretKey.String, timestamp, body)
+	}
+
+	return signature
+}
+
+// SignWebhookPayload - подпись "sha256=<hex>" от HMAC-SHA256(secret, timestamp + "." + body).
+// Получатель пересчитывает ее по заголовку X-Webhook-Timestamp и сырому телу запроса.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
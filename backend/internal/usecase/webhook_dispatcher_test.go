@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"integration-app/internal/domain/models"
+)
+
+const (
+	testWebhookTimestamp = "1760000000"
+	testWebhookBody      = `{"event":"sync.succeeded"}`
+
+	// HMAC-SHA256 от "1760000000.{"event":"sync.succeeded"}"
+	testSignatureCurrent  = "sha256=fbda25d502cc2e9c191e3b54b51f07d50df341c5e644ca7444d56fa56dac5551"
+	testSignaturePrevious = "sha256=66a5dc7424c4facf2f6ee36f1f7ab9c26d52b907cc7484962e2b40edfd0c9f0c"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := SignWebhookPayload("secret", testWebhookTimestamp, []byte(testWebhookBody))
+	if got != testSignatureCurrent {
+		t.Errorf("SignWebhookPayload() = %q, want %q", got, testSignatureCurrent)
+	}
+
+	if other := SignWebhookPayload("secret", "1760000001", []byte(testWebhookBody)); other == got {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestWebhookSignatureDuringRotation(t *testing.T) {
+	previous := func(secret string, until time.Time) *models.Webhook {
+		return &models.Webhook{
+			SecretKey:           sql.NullString{String: "secret", Valid: true},
+			PreviousSecretKey:   sql.NullString{String: secret, Valid: secret != ""},
+			PreviousSecretUntil: sql.NullTime{Time: until, Valid: !until.IsZero()},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		webhook *models.Webhook
+		want    string
+	}{
+		{"no rotation", previous("", time.Time{}), testSignatureCurrent},
+		{"within grace period", previous("old-secret", time.Now().Add(time.Hour)), testSignatureCurrent + "," + testSignaturePrevious},
+		{"grace period is over", previous("old-secret", time.Now().Add(-time.Minute)), testSignatureCurrent},
+		{"previous secret without expiry", previous("old-secret", time.Time{}), testSignatureCurrent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookSignature(tt.webhook, testWebhookTimestamp, []byte(testWebhookBody)); got != tt.want {
+				t.Errorf("webhookSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"integration-app/internal/domain"
+	"integration-app/internal/domain/models"
+)
+
+const (
+	PayloadFormatJSON              = "json"
+	PayloadFormatCloudEvents       = "cloudevents"        // CloudEvents 1.0, structured mode
+	PayloadFormatCloudEventsBinary = "cloudevents-binary" // CloudEvents 1.0, binary mode
+	PayloadFormatForm              = "form"               // application/x-www-form-urlencoded
+
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsSource      = "/integration-app/connections/"
+)
+
+var payloadFormats = map[string]bool{
+	PayloadFormatJSON:              true,
+	PayloadFormatCloudEvents:       true,
+	PayloadFormatCloudEventsBinary: true,
+	PayloadFormatForm:              true,
+}
+
+var headerNamePattern = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+.^_` + "`" + `|~-]+$`)
+
+// reservedHeaders - заголовки, которые выставляет сам dispatcher
+var reservedHeaders = map[string]bool{
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+	"User-Agent":     true,
+}
+
+// cloudEvent - конверт CloudEvents 1.0 в structured mode
+type cloudEvent struct {
+	SpecVersion     string                 `json:"specversion"`
+	ID              string                 `json:"id"`
+	Source          string                 `json:"source"`
+	Type            string                 `json:"type"`
+	Time            time.Time              `json:"time"`
+	DataContentType string                 `json:"datacontenttype"`
+	WebhookID       int                    `json:"webhookid"`
+	Data            map[string]interface{} `json:"data"`
+}
+
+// validateWebhookFormat - формат тела и пользовательские заголовки вебхука
+func validateWebhookFormat(webhook *models.Webhook) error {
+	if webhook.PayloadFormat == "" {
+		webhook.PayloadFormat = PayloadFormatJSON
+	}
+
+	if !payloadFormats[webhook.PayloadFormat] {
+		return domain.NewErrorf("unknown payload format %q, expected one of: json, cloudevents, cloudevents-binary, form",
+			webhook.PayloadFormat)
+	}
+
+	for name, value := range webhook.Headers {
+		if !headerNamePattern.MatchString(name) {
+			return domain.NewErrorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return domain.NewErrorf("invalid value of header %q", name)
+		}
+
+		canonical := http.CanonicalHeaderKey(name)
+		lower := strings.ToLower(canonical)
+		if reservedHeaders[canonical] || strings.HasPrefix(lower, "x-webhook-") || strings.HasPrefix(lower, "ce-") {
+			return domain.NewErrorf("header %q is set by the webhook dispatcher and cannot be overridden", name)
+		}
+	}
+
+	return nil
+}
+
+// encodeWebhookPayload - кодирует сохраненное тело попытки (JSON webhookPayload)
+// в формат вебхука. Возвращает тело, Content-Type и дополнительные заголовки.
+func encodeWebhookPayload(webhook *models.Webhook, raw []byte) ([]byte, string, map[string]string, error) {
+	format := webhook.PayloadFormat
+	if format == "" || format == PayloadFormatJSON {
+		return raw, "application/json", nil, nil
+	}
+
+	var payload webhookPayload
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, "", nil, domain.NewErrorf("failed to decode stored payload: %v", err)
+	}
+
+	source := cloudEventsSource + strconv.Itoa(payload.ConnectionID)
+
+	switch format {
+	case PayloadFormatCloudEvents:
+		body, err := json.Marshal(cloudEvent{
+			SpecVersion:     cloudEventsSpecVersion,
+			ID:              payload.ID,
+			Source:          source,
+			Type:            payload.Type,
+			Time:            payload.OccurredAt,
+			DataContentType: "application/json",
+			WebhookID:       payload.WebhookID,
+			Data:            payload.Data,
+		})
+		return body, "application/cloudevents+json", nil, err
+
+	case PayloadFormatCloudEventsBinary:
+		body, err := json.Marshal(payload.Data)
+		return body, "application/json", map[string]string{
+			"Ce-Specversion": cloudEventsSpecVersion,
+			"Ce-Id":          payload.ID,
+			"Ce-Source":      source,
+			"Ce-Type":        payload.Type,
+			"Ce-Time":        payload.OccurredAt.Format(time.RFC3339Nano),
+			"Ce-Webhookid":   strconv.Itoa(payload.WebhookID),
+		}, err
+
+	case PayloadFormatForm:
+		form := url.Values{}
+		form.Set("id", payload.ID)
+		form.Set("type", payload.Type)
+		form.Set("webhook_id", strconv.Itoa(payload.WebhookID))
+		form.Set("connection_id", strconv.Itoa(payload.ConnectionID))
+		form.Set("occurred_at", payload.OccurredAt.Format(time.RFC3339))
+		flattenForm(form, "data", payload.Data)
+		return []byte(form.Encode()), "application/x-www-form-urlencoded", nil, nil
+	}
+
+	return nil, "", nil, domain.NewErrorf("unknown payload format %q", format)
+}
+
+// flattenForm - вложенные значения в PHP-стиле: data[diff][added][0][source_field]=...
+func flattenForm(form url.Values, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flattenForm(form, prefix+"["+k+"]", v[k])
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenForm(form, fmt.Sprintf("%s[%d]", prefix, i), item)
+		}
+	case nil:
+		form.Add(prefix, "")
+	case bool:
+		// как http_build_query в PHP
+		if v {
+			form.Add(prefix, "1")
+		} else {
+			form.Add(prefix, "0")
+		}
+	default:
+		form.Add(prefix, fmt.Sprint(v))
+	}
+}
@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"time"
 
+	"integration-app/internal/config"
 	"integration-app/internal/domain"
 	"integration-app/internal/domain/models"
 	"integration-app/internal/repository"
@@ -10,20 +12,43 @@ import (
 )
 
 type WebhookUseCase struct {
-	webhookRepo repository.WebhookRepository
-	logger      domain.Logger
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+	dispatcher   *WebhookDispatcher
+	guard        domain.URLGuard
+	secretGrace  time.Duration
+	logger       domain.Logger
 }
 
 func NewWebhookUseCase(
+	cfg *config.Config,
 	webhookRepo repository.WebhookRepository,
+	deliveryRepo domain.WebhookDeliveryRepository,
+	dispatcher *WebhookDispatcher,
+	guard domain.URLGuard,
 	logger domain.Logger,
 ) *WebhookUseCase {
 	return &WebhookUseCase{
-		webhookRepo: webhookRepo,
-		logger:      logger,
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		dispatcher:   dispatcher,
+		guard:        guard,
+		secretGrace:  time.Duration(cfg.WebhookSecretGrace) * time.Hour,
+		logger:       logger,
 	}
 }
 
+// WebhookPatch - частичное изменение вебхука
+type WebhookPatch struct {
+	ConnectionID  *int                    `json:"connection_id"`
+	EventType     *string                 `json:"event_type"`
+	Filters       *map[string]interface{} `json:"filters"`
+	CallbackURL   *string                 `json:"callback_url"`
+	PayloadFormat *string                 `json:"payload_format"`
+	Headers       *map[string]string      `json:"headers"`
+	IsActive      *bool                   `json:"is_active"`
+}
+
 func (uc *WebhookUseCase) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
 	if webhook == nil {
 		return domain.NewError("webhook cannot be nil")
@@ -37,8 +62,16 @@ func (uc *WebhookUseCase) CreateWebhook(ctx context.Context, webhook *models.Web
 		return domain.NewError("callback URL is required")
 	}
 
-	if webhook.EventType == "" {
-		return domain.NewError("event type is required")
+	if err := uc.guard.ValidateURL(ctx, webhook.CallbackURL); err != nil {
+		return domain.NewErrorf("invalid callback URL: %v", err)
+	}
+
+	if err := domain.ValidateEventSubscription(webhook.EventType, webhook.Filters); err != nil {
+		return err
+	}
+
+	if err := validateWebhookFormat(webhook); err != nil {
+		return err
 	}
 
 	if utils.IsNullString(webhook.SecretKey) {
@@ -56,7 +89,7 @@ func (uc *WebhookUseCase) GetWebhookByID(ctx context.Context, id int) (*models.W
 	webhook, err := uc.webhookRepo.GetByID(ctx, id)
 	if err != nil {
 		uc.logger.Error("Failed to get webhook", err, "id", id)
-		return nil, domain.NewErrorf("failed to get webhook: %w", err)
+		return nil, domain.NewErrorf("failed to get webhook: %v", err)
 	}
 
 	if webhook == nil {
@@ -74,12 +107,14 @@ func (uc *WebhookUseCase) GetWebhooksByConnectionID(ctx context.Context, connect
 	webhooks, err := uc.webhookRepo.GetByConnectionID(ctx, connectionID)
 	if err != nil {
 		uc.logger.Error("Failed to get webhooks", err, "connectionID", connectionID)
-		return nil, domain.NewErrorf("failed to get webhooks: %w", err)
+		return nil, domain.NewErrorf("failed to get webhooks: %v", err)
 	}
 
 	return webhooks, nil
 }
 
+// UpdateWebhook - заменить настройки подписки вебхука. Секрет и счетчик неудач
+// не меняются; IsActive включает или выключает вебхук.
 func (uc *WebhookUseCase) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
 	if webhook == nil {
 		return domain.NewError("webhook cannot be nil")
@@ -89,11 +124,133 @@ func (uc *WebhookUseCase) UpdateWebhook(ctx context.Context, webhook *models.Web
 		return domain.NewError("webhook ID is required")
 	}
 
+	if webhook.ConnectionID == 0 {
+		return domain.NewError("connection ID is required")
+	}
+
 	if webhook.CallbackURL == "" {
 		return domain.NewError("callback URL is required")
 	}
 
-	return uc.webhookRepo.Update(ctx, webhook)
+	if err := uc.guard.ValidateURL(ctx, webhook.CallbackURL); err != nil {
+		return domain.NewErrorf("invalid callback URL: %v", err)
+	}
+
+	if err := domain.ValidateEventSubscription(webhook.EventType, webhook.Filters); err != nil {
+		return err
+	}
+
+	if err := validateWebhookFormat(webhook); err != nil {
+		return err
+	}
+
+	current, err := uc.GetWebhookByID(ctx, webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.webhookRepo.Update(ctx, webhook); err != nil {
+		uc.logger.Error("Failed to update webhook", err, "id", webhook.ID)
+		return domain.NewErrorf("failed to update webhook: %v", err)
+	}
+
+	if webhook.IsActive != current.IsActive {
+		if _, err := uc.SetWebhookActive(ctx, webhook.ID, webhook.IsActive); err != nil {
+			return err
+		}
+	}
+
+	updated, err := uc.GetWebhookByID(ctx, webhook.ID)
+	if err != nil {
+		return err
+	}
+	*webhook = *updated
+
+	return nil
+}
+
+// PatchWebhook - изменить только переданные поля вебхука
+func (uc *WebhookUseCase) PatchWebhook(ctx context.Context, id int, patch *WebhookPatch) (*models.Webhook, error) {
+	webhook, err := uc.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.ConnectionID != nil {
+		webhook.ConnectionID = *patch.ConnectionID
+	}
+	if patch.EventType != nil {
+		webhook.EventType = *patch.EventType
+	}
+	if patch.Filters != nil {
+		webhook.Filters = *patch.Filters
+	}
+	if patch.CallbackURL != nil {
+		webhook.CallbackURL = *patch.CallbackURL
+	}
+	if patch.PayloadFormat != nil {
+		webhook.PayloadFormat = *patch.PayloadFormat
+	}
+	if patch.Headers != nil {
+		webhook.Headers = *patch.Headers
+	}
+	if patch.IsActive != nil {
+		webhook.IsActive = *patch.IsActive
+	}
+
+	if err := uc.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// SetWebhookActive - включить или выключить вебхук. Включение сбрасывает
+// счетчик неудачных доставок подряд.
+func (uc *WebhookUseCase) SetWebhookActive(ctx context.Context, id int, active bool) (*models.Webhook, error) {
+	uc.logger.Info("UseCase: Switching webhook", "id", id, "active", active)
+
+	if id <= 0 {
+		return nil, domain.NewError("invalid webhook ID")
+	}
+
+	webhook, err := uc.webhookRepo.SetActive(ctx, id, active)
+	if err != nil {
+		uc.logger.Error("Failed to switch webhook", err, "id", id)
+		return nil, domain.NewErrorf("failed to switch webhook: %v", err)
+	}
+
+	return webhook, nil
+}
+
+// RotateSecret - выдать новый секрет. Предыдущий еще secretGrace подписывает
+// запросы вместе с новым, чтобы получатель успел обновить ключ.
+func (uc *WebhookUseCase) RotateSecret(ctx context.Context, id int) (*models.Webhook, error) {
+	uc.logger.Info("UseCase: Rotating webhook secret", "id", id)
+
+	if _, err := uc.GetWebhookByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	webhook, err := uc.webhookRepo.RotateSecret(ctx, id, uc.generateSecretKey(), time.Now().Add(uc.secretGrace))
+	if err != nil {
+		uc.logger.Error("Failed to rotate webhook secret", err, "id", id)
+		return nil, domain.NewErrorf("failed to rotate webhook secret: %v", err)
+	}
+
+	return webhook, nil
+}
+
+// TestWebhook - отправить пример события и вернуть ответ получателя
+func (uc *WebhookUseCase) TestWebhook(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	uc.logger.Info("UseCase: Testing webhook", "id", id)
+
+	webhook, err := uc.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.dispatcher.Test(ctx, webhook)
 }
 
 func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, id int) error {
@@ -104,6 +261,11 @@ func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, id int) error {
 	return uc.webhookRepo.Delete(ctx, id)
 }
 
+// GetEventTypes - каталог типов событий, на которые можно подписаться
+func (uc *WebhookUseCase) GetEventTypes() []domain.EventTypeInfo {
+	return domain.EventCatalog
+}
+
 func (uc *WebhookUseCase) generateSecretKey() string {
 	return utils.GenerateUUID()
 }
@@ -115,3 +277,30 @@ func (uc *WebhookUseCase) GetAllWebhooks(ctx context.Context) ([]*models.Webhook
 func (uc *WebhookUseCase) GetActiveWebhooks(ctx context.Context) ([]*models.Webhook, error) {
 	return uc.webhookRepo.GetActive(ctx)
 }
+
+const deliveriesPageSize = 100
+
+func (uc *WebhookUseCase) GetDeliveries(ctx context.Context, webhookID int) ([]models.WebhookDelivery, error) {
+	if _, err := uc.GetWebhookByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return uc.deliveryRepo.GetByWebhookID(ctx, webhookID, deliveriesPageSize)
+}
+
+// Redeliver - повторно отправить тело сохраненной попытки доставки
+func (uc *WebhookUseCase) Redeliver(ctx context.Context, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	uc.logger.Info("UseCase: Redelivering webhook", "webhook_id", webhookID, "delivery_id", deliveryID)
+
+	webhook, err := uc.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := uc.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil || previous.WebhookID != webhookID {
+		return nil, domain.NewErrorf("delivery %d not found for webhook %d", deliveryID, webhookID)
+	}
+
+	return uc.dispatcher.Redeliver(ctx, webhook, previous), nil
+}
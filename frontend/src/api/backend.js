@@ -4,10 +4,17 @@ export const api = {
   // Connections
   getConnections: () => fetch(`${API_URL}/connections`).then(r => r.json()),
   createConnection: (data) => fetch(`${API_URL}/connections`, { method: 'POST', body: JSON.stringify(data) }).then(r => r.json()),
+  getConnectionFields: (id, { entity = '', refresh = false } = {}) => fetch(`${API_URL}/connections/${id}/fields?entity=${entity}&refresh=${refresh}`).then(r => r.json()),
   
   // Mappings
   getMappings: () => fetch(`${API_URL}/mappings`).then(r => r.json()),
   saveMappings: (data) => fetch(`${API_URL}/mappings`, { method: 'POST', body: JSON.stringify(data) }).then(r => r.json()),
+  suggestMappings: (data) => fetch(`${API_URL}/mappings/suggest`, { method: 'POST', body: JSON.stringify(data) }).then(r => r.json()),
+  getPairMappings: (src, dst) => fetch(`${API_URL}/connections/${src}/targets/${dst}/mappings`).then(r => r.json()),
+  replacePairMappings: (src, dst, data) => fetch(`${API_URL}/connections/${src}/targets/${dst}/mappings`, { method: 'PUT', body: JSON.stringify(data) }).then(r => r.json()),
+  updateMapping: (src, dst, id, data) => fetch(`${API_URL}/connections/${src}/targets/${dst}/mappings/${id}`, { method: 'PATCH', body: JSON.stringify(data) }).then(r => r.json()),
+  deleteMapping: (src, dst, id) => fetch(`${API_URL}/connections/${src}/targets/${dst}/mappings/${id}`, { method: 'DELETE' }).then(r => r.json()),
+  previewMappings: (data) => fetch(`${API_URL}/mappings/preview`, { method: 'POST', body: JSON.stringify(data) }).then(r => r.json()),
   
   // Webhooks
   getWebhooks: () => fetch(`${API_URL}/webhooks`).then(r => r.json()),